@@ -22,6 +22,7 @@ import (
 type ImageComponent interface {
 	SquashImage(from string, to string) (string, error)
 	TagImageWithReference(image.ID, reference.Named) error
+	TagPlatformVariantWithReference(image.ID, reference.Named) error
 }
 
 // Builder defines interface for running a build
@@ -58,6 +59,10 @@ func (b *Backend) Build(ctx context.Context, config backend.BuildConfig) (string
 	if err != nil {
 		return "", err
 	}
+	// Images that are built for a specific platform are added to the tags as
+	// the variant for that platform, so that building the same tags for
+	// multiple platforms keeps the images for all of them.
+	tagger.platformVariants = options.Platform != ""
 
 	var build *builder.Result
 	if useBuildKit {
@@ -14,6 +14,9 @@ type Tagger struct {
 	imageComponent ImageComponent
 	stdout         io.Writer
 	repoAndTags    []reference.Named
+	// platformVariants adds the image to the tags as a platform variant,
+	// instead of replacing the images of the tags.
+	platformVariants bool
 }
 
 // NewTagger returns a new Tagger for tagging the images of a build.
@@ -32,8 +35,12 @@ func NewTagger(backend ImageComponent, stdout io.Writer, names []string) (*Tagge
 
 // TagImages creates image tags for the imageID
 func (bt *Tagger) TagImages(imageID image.ID) error {
+	tag := bt.imageComponent.TagImageWithReference
+	if bt.platformVariants {
+		tag = bt.imageComponent.TagPlatformVariantWithReference
+	}
 	for _, rt := range bt.repoAndTags {
-		if err := bt.imageComponent.TagImageWithReference(imageID, rt); err != nil {
+		if err := tag(imageID, rt); err != nil {
 			return err
 		}
 		fmt.Fprintf(bt.stdout, "Successfully tagged %s\n", reference.FamiliarString(rt))
@@ -0,0 +1,406 @@
+package middleware // import "github.com/docker/docker/api/server/middleware"
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/server/httpstatus"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// AuditConfig is the configuration of the audit log of the API, which
+// records the requests to the API with the client that made them, their
+// target and their result.
+type AuditConfig struct {
+	// Output is the path of the file to which audit records are written as
+	// JSON lines, or "syslog" to write them to syslog.
+	Output string `json:"output"`
+	// SyslogAddress is the address of the syslog server, such as
+	// "udp://host:514". It defaults to the local syslog server.
+	SyslogAddress string `json:"syslog-address,omitempty"`
+	// MaxSize is the size at which the audit log file is rotated, such as
+	// "100m". The file is not rotated if it is not set.
+	MaxSize string `json:"max-size,omitempty"`
+	// MaxFile is the maximum number of audit log files that are kept.
+	MaxFile int `json:"max-file,omitempty"`
+	// Compress compresses rotated audit log files.
+	Compress bool `json:"compress,omitempty"`
+	// Routes are the routes of which requests are audited, in the format of
+	// the routes of RBAC rules. If it is not set, all requests except GET
+	// and HEAD requests are audited.
+	Routes []string `json:"routes,omitempty"`
+	// Redact are the names of fields of request bodies of which the values
+	// are redacted, in addition to fields that commonly hold secrets.
+	Redact []string `json:"redact,omitempty"`
+}
+
+// Validate returns an error if c is not valid.
+func (c *AuditConfig) Validate() error {
+	if c.Output == "" {
+		return errors.New("invalid audit configuration: output is required")
+	}
+	if c.Output != auditSyslog && c.SyslogAddress != "" {
+		return errors.New("invalid audit configuration: syslog-address requires output syslog")
+	}
+	for _, route := range c.Routes {
+		if _, _, err := parseRBACRoute(route); err != nil {
+			return errors.Wrap(err, "invalid audit configuration")
+		}
+	}
+	if _, err := parseAuditMaxSize(c.MaxSize); err != nil {
+		return errors.Wrap(err, "invalid audit configuration")
+	}
+	return nil
+}
+
+const (
+	// auditSyslog is the output of audit logs that are written to syslog.
+	auditSyslog = "syslog"
+
+	// maxAuditBody is the maximum size of request bodies that are recorded.
+	maxAuditBody = 64 << 10
+
+	// maxAuditResponse is the size of the start of responses in which the
+	// ID of created objects is looked up.
+	maxAuditResponse = 1 << 10
+
+	// auditRedacted replaces redacted values.
+	auditRedacted = "[REDACTED]"
+)
+
+// defaultAuditRedact are the fields of request bodies that are always
+// redacted, as they commonly hold secrets.
+var defaultAuditRedact = []string{
+	"Auth",
+	"Data",
+	"Env",
+	"IdentityToken",
+	"Password",
+	"RegistryToken",
+	"SigningCAKey",
+	"UnlockKey",
+}
+
+// AuditRecord is a record of a request in the audit log.
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+	// User is the common name of the subject of the TLS client certificate
+	// of the client.
+	User string `json:"user,omitempty"`
+	// UID, GID and PID are the credentials of the process of the client,
+	// for clients of a Unix socket.
+	UID        *uint32 `json:"uid,omitempty"`
+	GID        *uint32 `json:"gid,omitempty"`
+	PID        *int32  `json:"pid,omitempty"`
+	RemoteAddr string  `json:"remote_addr,omitempty"`
+	Method     string  `json:"method"`
+	// Route is the route of the request, such as "/containers/{name}/start".
+	Route string `json:"route"`
+	Path  string `json:"path"`
+	// Target is the name or ID of the object of the request, or the ID of the
+	// object that the request created.
+	Target string `json:"target,omitempty"`
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Duration is the duration of the request in seconds.
+	Duration float64 `json:"duration"`
+	// Body is the JSON body of the request, with redacted secrets.
+	Body          json.RawMessage `json:"body,omitempty"`
+	BodyTruncated bool            `json:"body_truncated,omitempty"`
+}
+
+// auditSink writes audit records.
+type auditSink interface {
+	WriteRecord([]byte) error
+	Close() error
+}
+
+// AuditMiddleware is the middleware that records requests to the API in an
+// audit log. Requests are not recorded if it has no configuration.
+type AuditMiddleware struct {
+	mu     sync.RWMutex
+	config *AuditConfig
+	redact map[string]bool
+	sink   auditSink
+}
+
+// NewAuditMiddleware creates a new AuditMiddleware with the given
+// configuration, which may be nil.
+func NewAuditMiddleware(config *AuditConfig) (*AuditMiddleware, error) {
+	m := &AuditMiddleware{}
+	if err := m.SetConfig(config); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// SetConfig sets the configuration of the audit log, and opens its output.
+func (m *AuditMiddleware) SetConfig(config *AuditConfig) error {
+	var (
+		sink   auditSink
+		redact map[string]bool
+	)
+	if config != nil {
+		if err := config.Validate(); err != nil {
+			return err
+		}
+		var err error
+		sink, err = newAuditSink(config)
+		if err != nil {
+			return errors.Wrap(err, "failed to open audit log")
+		}
+		redact = make(map[string]bool)
+		for _, f := range append(append([]string{}, defaultAuditRedact...), config.Redact...) {
+			redact[strings.ToLower(f)] = true
+		}
+	}
+
+	m.mu.Lock()
+	old := m.sink
+	m.config, m.redact, m.sink = config, redact, sink
+	m.mu.Unlock()
+
+	if old != nil {
+		return old.Close()
+	}
+	return nil
+}
+
+// Close closes the output of the audit log.
+func (m *AuditMiddleware) Close() error {
+	return m.SetConfig(nil)
+}
+
+// WrapHandler returns a new handler function wrapping the previous one in the request chain.
+func (m *AuditMiddleware) WrapHandler(handler func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error) func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		m.mu.RLock()
+		config, redact := m.config, m.redact
+		m.mu.RUnlock()
+
+		route := requestRoute(r)
+		if config == nil || !auditsRoute(config, r.Method, route) {
+			return handler(ctx, w, r, vars)
+		}
+
+		subject := requestSubject(ctx, r)
+		record := AuditRecord{
+			Time:       time.Now().UTC(),
+			User:       subject.user,
+			RemoteAddr: r.RemoteAddr,
+			Method:     r.Method,
+			Route:      route,
+			Path:       r.URL.Path,
+			Target:     vars["name"],
+		}
+		if record.Target == "" {
+			record.Target = vars["id"]
+		}
+		if subject.creds != nil {
+			record.UID, record.GID, record.PID = &subject.creds.UID, &subject.creds.GID, &subject.creds.PID
+		}
+		record.Body, record.BodyTruncated = auditBody(r, redact)
+
+		rw := &auditResponseWriter{ResponseWriter: w, capture: strings.HasSuffix(route, "/create")}
+		err := handler(ctx, rw, r, vars)
+
+		record.Duration = time.Since(record.Time).Seconds()
+		record.Status = rw.status
+		if err != nil {
+			record.Error = err.Error()
+			if !rw.wroteHeader {
+				record.Status = httpstatus.FromError(err)
+			}
+		} else if record.Status == 0 {
+			record.Status = http.StatusOK
+		}
+		if record.Target == "" {
+			record.Target = rw.createdID()
+		}
+		m.write(record)
+		return err
+	}
+}
+
+func (m *AuditMiddleware) write(record AuditRecord) {
+	b, err := json.Marshal(record)
+	if err != nil {
+		logrus.WithError(err).Error("failed to marshal audit record")
+		return
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.sink == nil {
+		return
+	}
+	if err := m.sink.WriteRecord(b); err != nil {
+		logrus.WithError(err).WithField("route", record.Route).Error("failed to write audit record")
+	}
+}
+
+func auditsRoute(config *AuditConfig, method, route string) bool {
+	if len(config.Routes) == 0 {
+		return method != http.MethodGet && method != http.MethodHead
+	}
+	return RBACRule{Routes: config.Routes}.matchesRoute(method, route)
+}
+
+// auditBody returns the JSON body of r with redacted secrets, and restores
+// the body for the handler of the request. It returns true if the body is
+// too large to be recorded.
+func auditBody(r *http.Request, redact map[string]bool) (json.RawMessage, bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, false
+	}
+	if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct != "application/json" {
+		return nil, false
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxAuditBody+1))
+	if len(body) > maxAuditBody || err != nil {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		return nil, true
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var v interface{}
+	if len(bytes.TrimSpace(body)) == 0 || json.Unmarshal(body, &v) != nil {
+		return nil, false
+	}
+	b, err := json.Marshal(redactValue(v, redact))
+	if err != nil {
+		return nil, false
+	}
+	return b, false
+}
+
+// redactValue replaces the values of the fields of v with names in redact.
+// Environment variables keep their names.
+func redactValue(v interface{}, redact map[string]bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			if redact[strings.ToLower(k)] {
+				v[k] = redactField(value)
+			} else {
+				v[k] = redactValue(value, redact)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactValue(value, redact)
+		}
+	}
+	return v
+}
+
+func redactField(v interface{}) interface{} {
+	values, ok := v.([]interface{})
+	if !ok {
+		if v == nil {
+			return nil
+		}
+		return auditRedacted
+	}
+	redacted := make([]interface{}, len(values))
+	for i, value := range values {
+		if s, ok := value.(string); ok && strings.Contains(s, "=") {
+			redacted[i] = strings.SplitN(s, "=", 2)[0] + "=" + auditRedacted
+		} else {
+			redacted[i] = auditRedacted
+		}
+	}
+	return redacted
+}
+
+// auditResponseWriter records the status of responses, and the start of the
+// responses of create requests.
+type auditResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	capture     bool
+	captured    []byte
+}
+
+func (w *auditResponseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = status, true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *auditResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = http.StatusOK, true
+	}
+	if w.capture && len(w.captured) < maxAuditResponse {
+		n := maxAuditResponse - len(w.captured)
+		if n > len(b) {
+			n = len(b)
+		}
+		w.captured = append(w.captured, b[:n]...)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher.
+func (w *auditResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker.
+func (w *auditResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response does not support hijacking")
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil && !w.wroteHeader {
+		w.status, w.wroteHeader = http.StatusSwitchingProtocols, true
+	}
+	return conn, rw, err
+}
+
+// CloseNotify implements http.CloseNotifier.
+func (w *auditResponseWriter) CloseNotify() <-chan bool {
+	//nolint:staticcheck // ignore SA1019: http.CloseNotifier is deprecated, but handlers may still use it.
+	if cn, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return cn.CloseNotify()
+	}
+	return make(chan bool)
+}
+
+// createdID returns the ID of the object that was created by the request, if
+// any.
+func (w *auditResponseWriter) createdID() string {
+	if len(w.captured) == 0 {
+		return ""
+	}
+	var resp struct {
+		ID  string `json:"Id"`
+		ID2 string `json:"ID"`
+	}
+	if json.Unmarshal(w.captured, &resp) != nil {
+		return ""
+	}
+	if resp.ID != "" {
+		return resp.ID
+	}
+	return resp.ID2
+}
@@ -0,0 +1,111 @@
+package middleware // import "github.com/docker/docker/api/server/middleware"
+
+import (
+	"net/url"
+	"time"
+
+	syslog "github.com/RackSec/srslog"
+	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/logger/loggerutils"
+	units "github.com/docker/go-units"
+	"github.com/pkg/errors"
+)
+
+// auditSyslogTag is the tag of audit records that are written to syslog.
+const auditSyslogTag = "dockerd-audit"
+
+func newAuditSink(config *AuditConfig) (auditSink, error) {
+	if config.Output == auditSyslog {
+		return newAuditSyslogSink(config.SyslogAddress)
+	}
+	return newAuditFileSink(config)
+}
+
+func parseAuditMaxSize(maxSize string) (int64, error) {
+	if maxSize == "" {
+		return -1, nil
+	}
+	capacity, err := units.RAMInBytes(maxSize)
+	if err != nil {
+		return 0, err
+	}
+	if capacity <= 0 {
+		return 0, errors.New("max-size must be a positive number")
+	}
+	return capacity, nil
+}
+
+// auditFileSink writes audit records to a JSON lines file, which is rotated
+// the same way as the log files of the json-file logging driver.
+type auditFileSink struct {
+	file *loggerutils.LogFile
+}
+
+func newAuditFileSink(config *AuditConfig) (*auditFileSink, error) {
+	capacity, err := parseAuditMaxSize(config.MaxSize)
+	if err != nil {
+		return nil, err
+	}
+	maxFiles := config.MaxFile
+	if maxFiles < 1 {
+		maxFiles = 1
+	}
+	// The message is put back in the pool of messages before the line is
+	// written, so it must be copied.
+	marshal := func(msg *logger.Message) ([]byte, error) {
+		line := make([]byte, 0, len(msg.Line)+1)
+		return append(append(line, msg.Line...), '\n'), nil
+	}
+	file, err := loggerutils.NewLogFile(config.Output, capacity, maxFiles, config.Compress, marshal, nil, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &auditFileSink{file: file}, nil
+}
+
+func (s *auditFileSink) WriteRecord(record []byte) error {
+	msg := logger.NewMessage()
+	msg.Line = append(msg.Line, record...)
+	msg.Timestamp = time.Now()
+	return s.file.WriteLogEntry(msg)
+}
+
+func (s *auditFileSink) Close() error {
+	return s.file.Close()
+}
+
+// auditSyslogSink writes audit records to syslog.
+type auditSyslogSink struct {
+	writer *syslog.Writer
+}
+
+func newAuditSyslogSink(address string) (*auditSyslogSink, error) {
+	var proto, addr string
+	if address != "" {
+		u, err := url.Parse(address)
+		if err != nil {
+			return nil, err
+		}
+		switch u.Scheme {
+		case "unix", "unixgram":
+			proto, addr = u.Scheme, u.Path
+		case "udp", "tcp":
+			proto, addr = u.Scheme, u.Host
+		default:
+			return nil, errors.Errorf("unsupported syslog address scheme: %q", u.Scheme)
+		}
+	}
+	writer, err := syslog.Dial(proto, addr, syslog.LOG_AUTH|syslog.LOG_INFO, auditSyslogTag)
+	if err != nil {
+		return nil, err
+	}
+	return &auditSyslogSink{writer: writer}, nil
+}
+
+func (s *auditSyslogSink) WriteRecord(record []byte) error {
+	return s.writer.Info(string(record))
+}
+
+func (s *auditSyslogSink) Close() error {
+	return s.writer.Close()
+}
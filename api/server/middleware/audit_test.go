@@ -0,0 +1,134 @@
+package middleware // import "github.com/docker/docker/api/server/middleware"
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/gorilla/mux"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestAuditConfigValidate(t *testing.T) {
+	for doc, tc := range map[string]struct {
+		config AuditConfig
+		err    string
+	}{
+		"no output":      {AuditConfig{}, "output is required"},
+		"syslog address": {AuditConfig{Output: "/var/log/audit.log", SyslogAddress: "udp://localhost"}, "syslog-address requires output syslog"},
+		"invalid route":  {AuditConfig{Output: "syslog", Routes: []string{"POST containers"}}, "paths must start with /"},
+		"invalid size":   {AuditConfig{Output: "/var/log/audit.log", MaxSize: "big"}, "invalid audit configuration"},
+	} {
+		assert.Check(t, is.ErrorContains(tc.config.Validate(), tc.err), doc)
+	}
+	assert.Check(t, (&AuditConfig{Output: "syslog", SyslogAddress: "udp://localhost"}).Validate())
+}
+
+func TestAuditRedact(t *testing.T) {
+	redact := map[string]bool{"env": true, "password": true, "token": true}
+	body := `{"Image":"busybox","Env":["FOO=bar","BAZ"],"Spec":{"Password":"secret","Items":[{"Token":"t"}]}}`
+	req := httptest.NewRequest(http.MethodPost, "/containers/create", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	redacted, truncated := auditBody(req, redact)
+	assert.Check(t, !truncated)
+	assert.Check(t, is.Equal(string(redacted), `{"Env":["FOO=[REDACTED]","[REDACTED]"],"Image":"busybox","Spec":{"Items":[{"Token":"[REDACTED]"}],"Password":"[REDACTED]"}}`))
+
+	// The body is passed on to the handler.
+	b, err := io.ReadAll(req.Body)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(b), body))
+
+	// Bodies that are not JSON are not recorded.
+	req = httptest.NewRequest(http.MethodPost, "/build", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-tar")
+	redacted, _ = auditBody(req, redact)
+	assert.Check(t, is.Nil(redacted))
+}
+
+func TestAuditMiddleware(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	m, err := NewAuditMiddleware(&AuditConfig{Output: path})
+	assert.NilError(t, err)
+	defer m.Close()
+
+	router := mux.NewRouter()
+	for _, route := range []struct {
+		method, path string
+		handler      func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error
+	}{
+		{http.MethodGet, "/containers/json", func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+			return nil
+		}},
+		{http.MethodPost, "/containers/create", func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+			w.WriteHeader(http.StatusCreated)
+			return json.NewEncoder(w).Encode(map[string]string{"Id": "abc123"})
+		}},
+		{http.MethodDelete, "/containers/{name:.*}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+			return errdefs.NotFound(io.EOF)
+		}},
+	} {
+		h := m.WrapHandler(route.handler)
+		router.Path("/v{version:[0-9.]+}" + route.path).Methods(route.method).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = h(r.Context(), w, r, mux.Vars(r))
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1.42/containers/create", strings.NewReader(`{"Image":"busybox","Env":["SECRET=value"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "alice"}}}}
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodDelete, "/v1.42/containers/missing", nil)
+	req = req.WithContext(context.WithValue(req.Context(), peerCredentialsKey{}, &PeerCredentials{PID: 42, UID: 1000, GID: 1000}))
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// GET requests are not audited by default.
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1.42/containers/json", nil))
+
+	// Requests are not audited without configuration.
+	assert.NilError(t, m.SetConfig(nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/v1.42/containers/foo", nil))
+
+	f, err := os.Open(path)
+	assert.NilError(t, err)
+	defer f.Close()
+	var records []AuditRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record AuditRecord
+		assert.NilError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	assert.NilError(t, scanner.Err())
+	assert.Assert(t, is.Len(records, 2))
+
+	create := records[0]
+	assert.Check(t, is.Equal(create.User, "alice"))
+	assert.Check(t, is.Equal(create.Method, http.MethodPost))
+	assert.Check(t, is.Equal(create.Route, "/containers/create"))
+	assert.Check(t, is.Equal(create.Target, "abc123"))
+	assert.Check(t, is.Equal(create.Status, http.StatusCreated))
+	assert.Check(t, is.Equal(string(create.Body), `{"Env":["SECRET=[REDACTED]"],"Image":"busybox"}`))
+
+	remove := records[1]
+	assert.Check(t, is.Equal(remove.Route, "/containers/{name}"))
+	assert.Check(t, is.Equal(remove.Target, "missing"))
+	assert.Check(t, is.Equal(remove.Status, http.StatusNotFound))
+	assert.Check(t, is.Equal(remove.Error, "EOF"))
+	assert.Assert(t, remove.UID != nil && remove.PID != nil)
+	assert.Check(t, is.Equal(*remove.UID, uint32(1000)))
+	assert.Check(t, is.Equal(*remove.PID, int32(42)))
+}
@@ -0,0 +1,270 @@
+package middleware // import "github.com/docker/docker/api/server/middleware"
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/errdefs"
+	metrics "github.com/docker/go-metrics"
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+// rateLimitIdleTimeout is the time after which the state of the rate limits
+// of clients that made no requests is removed.
+const rateLimitIdleTimeout = 10 * time.Minute
+
+var throttledRequests metrics.LabeledCounter
+
+func init() {
+	ns := metrics.NewNamespace("engine", "daemon", nil)
+	throttledRequests = ns.NewLabeledCounter("api_throttled_requests", "The number of API requests that were rejected by rate limits", "route", "limit")
+	metrics.Register(ns)
+}
+
+// RateLimitConfig is the configuration of the rate limits of the API. The
+// limits apply to each client separately, where clients are identified by the
+// subject of their TLS client certificate, by the user of their process for
+// connections to a Unix socket, or else by their address.
+type RateLimitConfig struct {
+	// Rules are the rate limits of routes. Only the first rule that matches
+	// a request applies to it.
+	Rules []RateLimitRule `json:"rules"`
+}
+
+// RateLimitRule limits the rate and the concurrency of requests of each
+// client to routes of the API. The limits of a rule are shared by all of its
+// routes.
+type RateLimitRule struct {
+	// Routes are the routes to which the rule applies, in the format of the
+	// routes of RBAC rules.
+	Routes []string `json:"routes"`
+	// User restricts the rule to clients with a TLS client certificate with
+	// this common name.
+	User string `json:"user,omitempty"`
+	// UID restricts the rule to clients of a Unix socket with this user ID.
+	UID *uint32 `json:"uid,omitempty"`
+	// GID restricts the rule to clients of a Unix socket with this group ID.
+	GID *uint32 `json:"gid,omitempty"`
+	// Rate is the number of requests per second that each client can make.
+	// The rate is not limited if it is not set.
+	Rate float64 `json:"rate,omitempty"`
+	// Burst is the number of requests that each client can make at once. It
+	// defaults to the rate, and to at least one request.
+	Burst int `json:"burst,omitempty"`
+	// MaxConcurrent is the maximum number of requests of each client that
+	// are processed concurrently, such as streams of events or logs. The
+	// concurrency is not limited if it is not set.
+	MaxConcurrent int `json:"max-concurrent,omitempty"`
+}
+
+// Validate returns an error if c is not valid.
+func (c *RateLimitConfig) Validate() error {
+	for i, rule := range c.Rules {
+		if len(rule.Routes) == 0 {
+			return errors.Errorf("invalid rate limit rule %d: rules require routes", i)
+		}
+		for _, route := range rule.Routes {
+			if _, _, err := parseRBACRoute(route); err != nil {
+				return errors.Wrapf(err, "invalid rate limit rule %d", i)
+			}
+		}
+		if rule.Rate < 0 || rule.Burst < 0 || rule.MaxConcurrent < 0 {
+			return errors.Errorf("invalid rate limit rule %d: limits must not be negative", i)
+		}
+	}
+	return nil
+}
+
+func (rule RateLimitRule) burst() int {
+	if rule.Burst > 0 {
+		return rule.Burst
+	}
+	if b := int(math.Ceil(rule.Rate)); b > 1 {
+		return b
+	}
+	return 1
+}
+
+// RateLimitMiddleware is the middleware that rejects requests of clients that
+// exceed the rate limits of the API with a 429 status. Requests are not
+// limited if it has no configuration.
+type RateLimitMiddleware struct {
+	mu    sync.RWMutex
+	state *rateLimitState
+}
+
+// NewRateLimitMiddleware creates a new RateLimitMiddleware with the given
+// configuration, which may be nil.
+func NewRateLimitMiddleware(config *RateLimitConfig) *RateLimitMiddleware {
+	m := &RateLimitMiddleware{}
+	m.SetConfig(config)
+	return m
+}
+
+// SetConfig sets the configuration of the rate limits. The limits of clients
+// are reset.
+func (m *RateLimitMiddleware) SetConfig(config *RateLimitConfig) {
+	var state *rateLimitState
+	if config != nil && len(config.Rules) > 0 {
+		state = &rateLimitState{config: config, clients: make(map[string]*rateLimitClient)}
+	}
+	m.mu.Lock()
+	m.state = state
+	m.mu.Unlock()
+}
+
+// WrapHandler returns a new handler function wrapping the previous one in the request chain.
+func (m *RateLimitMiddleware) WrapHandler(handler func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error) func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		m.mu.RLock()
+		state := m.state
+		m.mu.RUnlock()
+		if state == nil {
+			return handler(ctx, w, r, vars)
+		}
+
+		route := requestRoute(r)
+		subject := requestSubject(ctx, r)
+		release, err := state.acquire(subject, r, route, time.Now())
+		if err != nil {
+			if e, ok := err.(rateLimitError); ok {
+				throttledRequests.WithValues(route, e.limit).Inc()
+				w.Header().Set("Retry-After", strconv.Itoa(e.retryAfterSeconds()))
+			}
+			return errdefs.ResourceExhausted(err)
+		}
+		defer release()
+		return handler(ctx, w, r, vars)
+	}
+}
+
+// rateLimitState is the state of the rate limits of clients for a
+// configuration.
+type rateLimitState struct {
+	config *RateLimitConfig
+
+	mu        sync.Mutex
+	clients   map[string]*rateLimitClient
+	lastSweep time.Time
+}
+
+// rateLimitClient is the state of a rule for a client.
+type rateLimitClient struct {
+	limiter  *rate.Limiter
+	active   int
+	lastUsed time.Time
+}
+
+// acquire returns an error if the request exceeds the limits of the first rule
+// that applies to it. Otherwise, it returns a function that must be called
+// when the request completes.
+func (s *rateLimitState) acquire(subject rbacSubject, r *http.Request, route string, now time.Time) (release func(), _ error) {
+	index := -1
+	for i, rule := range s.config.Rules {
+		if subject.matches(RBACBinding{User: rule.User, UID: rule.UID, GID: rule.GID}) && (RBACRule{Routes: rule.Routes}).matchesRoute(r.Method, route) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return func() {}, nil
+	}
+	rule := s.config.Rules[index]
+	key := fmt.Sprintf("%d %s", index, rateLimitClientKey(subject, r))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+	c, ok := s.clients[key]
+	if !ok {
+		c = &rateLimitClient{}
+		if rule.Rate > 0 {
+			c.limiter = rate.NewLimiter(rate.Limit(rule.Rate), rule.burst())
+		}
+		s.clients[key] = c
+	}
+	c.lastUsed = now
+
+	if rule.MaxConcurrent > 0 && c.active >= rule.MaxConcurrent {
+		return nil, rateLimitError{
+			limit:      "concurrency",
+			retryAfter: time.Second,
+			msg:        fmt.Sprintf("too many concurrent requests of %s to %s %s: the limit is %d", subject, r.Method, route, rule.MaxConcurrent),
+		}
+	}
+	if c.limiter != nil {
+		res := c.limiter.ReserveN(now, 1)
+		if delay := res.DelayFrom(now); delay > 0 {
+			res.CancelAt(now)
+			return nil, rateLimitError{
+				limit:      "rate",
+				retryAfter: delay,
+				msg:        fmt.Sprintf("too many requests of %s to %s %s: the limit is %g per second", subject, r.Method, route, rule.Rate),
+			}
+		}
+	}
+
+	c.active++
+	return func() {
+		s.mu.Lock()
+		c.active--
+		c.lastUsed = time.Now()
+		s.mu.Unlock()
+	}, nil
+}
+
+// sweep removes the state of clients that made no requests recently.
+func (s *rateLimitState) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < rateLimitIdleTimeout {
+		return
+	}
+	s.lastSweep = now
+	for key, c := range s.clients {
+		if c.active == 0 && now.Sub(c.lastUsed) > rateLimitIdleTimeout {
+			delete(s.clients, key)
+		}
+	}
+}
+
+// rateLimitClientKey returns the identity of the client of r for its rate
+// limits.
+func rateLimitClientKey(subject rbacSubject, r *http.Request) string {
+	switch {
+	case subject.user != "":
+		return "user " + subject.user
+	case subject.creds != nil:
+		return "uid " + strconv.FormatUint(uint64(subject.creds.UID), 10)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "addr " + host
+}
+
+type rateLimitError struct {
+	limit      string
+	retryAfter time.Duration
+	msg        string
+}
+
+func (e rateLimitError) Error() string {
+	return e.msg
+}
+
+// retryAfterSeconds returns the value of the Retry-After header of the
+// response, which is at least one second.
+func (e rateLimitError) retryAfterSeconds() int {
+	if s := int(math.Ceil(e.retryAfter.Seconds())); s > 1 {
+		return s
+	}
+	return 1
+}
@@ -0,0 +1,119 @@
+package middleware // import "github.com/docker/docker/api/server/middleware"
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/server/httpstatus"
+	"github.com/gorilla/mux"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestRateLimitConfigValidate(t *testing.T) {
+	for doc, tc := range map[string]struct {
+		config string
+		err    string
+	}{
+		"rule without routes": {`{"rules": [{"rate": 1}]}`, `invalid rate limit rule 0: rules require routes`},
+		"relative path":       {`{"rules": [{"routes": ["GET events"]}]}`, `paths must start with /`},
+		"negative limit":      {`{"rules": [{"routes": ["*"], "max-concurrent": -1}]}`, `limits must not be negative`},
+	} {
+		var config RateLimitConfig
+		assert.NilError(t, json.Unmarshal([]byte(tc.config), &config), doc)
+		assert.Check(t, is.ErrorContains(config.Validate(), tc.err), doc)
+	}
+}
+
+func TestRateLimitState(t *testing.T) {
+	admin := uint32(0)
+	state := &rateLimitState{
+		config: &RateLimitConfig{Rules: []RateLimitRule{
+			{Routes: []string{"*"}, UID: &admin},
+			{Routes: []string{"GET /containers/json"}, Rate: 1, Burst: 2},
+			{Routes: []string{"GET /events"}, MaxConcurrent: 1},
+		}},
+		clients: make(map[string]*rateLimitClient),
+	}
+	alice := rbacSubject{user: "alice"}
+	bob := rbacSubject{user: "bob"}
+	root := rbacSubject{creds: &PeerCredentials{UID: admin}}
+	list := httptest.NewRequest(http.MethodGet, "/containers/json", nil)
+	events := httptest.NewRequest(http.MethodGet, "/events", nil)
+	now := time.Now()
+
+	acquire := func(subject rbacSubject, r *http.Request, route string, now time.Time) error {
+		release, err := state.acquire(subject, r, route, now)
+		if err == nil {
+			release()
+		}
+		return err
+	}
+
+	// The burst of requests is allowed, after which the rate applies.
+	for i := 0; i < 2; i++ {
+		assert.NilError(t, acquire(alice, list, "/containers/json", now))
+	}
+	err := acquire(alice, list, "/containers/json", now)
+	assert.Check(t, is.ErrorContains(err, `too many requests of user "alice" to GET /containers/json`))
+	assert.Check(t, is.Equal(err.(rateLimitError).retryAfterSeconds(), 1))
+	assert.Check(t, acquire(alice, list, "/containers/json", now.Add(time.Second)))
+
+	// Clients are limited separately.
+	assert.Check(t, acquire(bob, list, "/containers/json", now))
+
+	// Rules for clients take precedence.
+	for i := 0; i < 5; i++ {
+		assert.Check(t, acquire(root, list, "/containers/json", now))
+	}
+
+	// Concurrent requests are limited until they complete.
+	release, err := state.acquire(alice, events, "/events", now)
+	assert.NilError(t, err)
+	err = acquire(alice, events, "/events", now)
+	assert.Check(t, is.ErrorContains(err, `too many concurrent requests of user "alice" to GET /events: the limit is 1`))
+	release()
+	assert.Check(t, acquire(alice, events, "/events", now))
+
+	// Idle clients are removed.
+	// The time of the release of the requests is the current time.
+	state.sweep(time.Now().Add(2 * rateLimitIdleTimeout))
+	assert.Check(t, is.Len(state.clients, 0))
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	m := NewRateLimitMiddleware(&RateLimitConfig{Rules: []RateLimitRule{{Routes: []string{"POST /containers/{name}/exec"}, Rate: 0.1}}})
+
+	router := mux.NewRouter()
+	h := m.WrapHandler(func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		return nil
+	})
+	router.Path("/containers/{name:.*}/exec").Methods(http.MethodPost).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := h(r.Context(), w, r, mux.Vars(r)); err != nil {
+			w.WriteHeader(httpstatus.FromError(err))
+		}
+	})
+
+	request := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/containers/foo/exec", nil)
+		req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "alice"}}}}
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+	assert.Check(t, is.Equal(request().Code, http.StatusOK))
+	resp := request()
+	assert.Check(t, is.Equal(resp.Code, http.StatusTooManyRequests))
+	assert.Check(t, is.Equal(resp.Header().Get("Retry-After"), "10"))
+
+	// Requests are not limited without configuration.
+	m.SetConfig(nil)
+	assert.Check(t, is.Equal(request().Code, http.StatusOK))
+}
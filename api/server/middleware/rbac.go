@@ -0,0 +1,488 @@
+package middleware // import "github.com/docker/docker/api/server/middleware"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// RBACConfig is the configuration of the role-based access control of the
+// API. Clients are bound to roles by the subject of their TLS client
+// certificate, or by the user and group of their process for connections to a
+// Unix socket. Requests are only allowed if a rule of a role of the client
+// allows them.
+type RBACConfig struct {
+	// Roles are the roles, by name.
+	Roles map[string]RBACRole `json:"roles"`
+	// Bindings bind clients to roles.
+	Bindings []RBACBinding `json:"bindings"`
+}
+
+// RBACRole is a role of the role-based access control of the API.
+type RBACRole struct {
+	// Rules are the rules of requests that are allowed for the role.
+	Rules []RBACRule `json:"rules"`
+}
+
+// RBACRule allows requests to routes of the API.
+type RBACRule struct {
+	// Routes are the routes to which the rule applies, as an optional
+	// method and the path of the route without the API version, for example
+	// "POST /containers/{name}/exec". A path that ends with "*" matches all
+	// paths with its prefix, such as "/containers/*", and "*" matches all
+	// routes. The names of the variables of paths are ignored, so that
+	// "/exec/{id}/start" matches the same route as "/exec/{name}/start".
+	Routes []string `json:"routes"`
+	// Labels restricts the rule to requests for containers, execs, images,
+	// volumes and networks with these labels. For requests that create
+	// containers, volumes and networks, the labels of the request apply.
+	// Containers that are created with the namespaces or volumes of other
+	// containers, or links to them, are only allowed if these containers
+	// have the labels as well. Rules with labels do not apply to other
+	// requests, such as lists.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// RBACBinding binds the clients that match all of its criteria to roles.
+type RBACBinding struct {
+	// User is the common name of the subject of the TLS client certificate.
+	User string `json:"user,omitempty"`
+	// UID is the user ID of the process of clients of a Unix socket.
+	UID *uint32 `json:"uid,omitempty"`
+	// GID is the group ID of the process of clients of a Unix socket.
+	GID *uint32 `json:"gid,omitempty"`
+	// Roles are the names of the roles of the clients.
+	Roles []string `json:"roles"`
+}
+
+// Validate returns an error if c is not valid.
+func (c *RBACConfig) Validate() error {
+	for name, role := range c.Roles {
+		for _, rule := range role.Rules {
+			if len(rule.Routes) == 0 {
+				return errors.Errorf("invalid rbac role %q: rules require routes", name)
+			}
+			for _, route := range rule.Routes {
+				if _, _, err := parseRBACRoute(route); err != nil {
+					return errors.Wrapf(err, "invalid rbac role %q", name)
+				}
+			}
+		}
+	}
+	for i, b := range c.Bindings {
+		if b.User == "" && b.UID == nil && b.GID == nil {
+			return errors.Errorf("invalid rbac binding %d: bindings require a user, uid or gid", i)
+		}
+		for _, role := range b.Roles {
+			if _, ok := c.Roles[role]; !ok {
+				return errors.Errorf("invalid rbac binding %d: unknown role %q", i, role)
+			}
+		}
+	}
+	return nil
+}
+
+func parseRBACRoute(route string) (method, path string, _ error) {
+	fields := strings.Fields(route)
+	switch len(fields) {
+	case 1:
+		path = fields[0]
+	case 2:
+		method, path = strings.ToUpper(fields[0]), fields[1]
+	default:
+		return "", "", errors.Errorf("invalid route %q", route)
+	}
+	if path != "*" && !strings.HasPrefix(path, "/") {
+		return "", "", errors.Errorf("invalid route %q: paths must start with /", route)
+	}
+	if method == "*" {
+		method = ""
+	}
+	return method, normalizeRoutePath(path), nil
+}
+
+// ResourceLabelsFunc returns the labels of the resource with the given name
+// of the given kind, which is the first element of the path of its routes,
+// such as "containers" or "images".
+type ResourceLabelsFunc func(ctx context.Context, kind, name string) (map[string]string, error)
+
+// rbacLabelKinds are the kinds of resources for which the labels of rules
+// are matched.
+var rbacLabelKinds = map[string]bool{
+	"containers": true,
+	"exec":       true,
+	"images":     true,
+	"volumes":    true,
+	"networks":   true,
+}
+
+// maxRBACCreateBody is the maximum size of the bodies of create requests of
+// which labels are matched.
+const maxRBACCreateBody = 1 << 20
+
+// RBACMiddleware is the middleware that enforces the role-based access
+// control of the API. Requests are allowed unconditionally if it has no
+// configuration.
+type RBACMiddleware struct {
+	mu     sync.RWMutex
+	config *RBACConfig
+	labels ResourceLabelsFunc
+}
+
+// NewRBACMiddleware creates a new RBACMiddleware with the given
+// configuration, which may be nil.
+func NewRBACMiddleware(config *RBACConfig) *RBACMiddleware {
+	return &RBACMiddleware{config: config}
+}
+
+// SetConfig sets the configuration of the role-based access control.
+func (m *RBACMiddleware) SetConfig(config *RBACConfig) {
+	m.mu.Lock()
+	m.config = config
+	m.mu.Unlock()
+}
+
+// SetResourceLabels sets the function that returns the labels of resources
+// for the label selectors of rules.
+func (m *RBACMiddleware) SetResourceLabels(labels ResourceLabelsFunc) {
+	m.mu.Lock()
+	m.labels = labels
+	m.mu.Unlock()
+}
+
+// WrapHandler returns a new handler function wrapping the previous one in the request chain.
+func (m *RBACMiddleware) WrapHandler(handler func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error) func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		m.mu.RLock()
+		config, labels := m.config, m.labels
+		m.mu.RUnlock()
+
+		if config != nil {
+			if err := authorizeRBAC(ctx, config, labels, r, vars); err != nil {
+				return err
+			}
+		}
+		return handler(ctx, w, r, vars)
+	}
+}
+
+// rbacRequest is a request that is authorized by the role-based access
+// control.
+type rbacRequest struct {
+	ctx     context.Context
+	r       *http.Request
+	vars    map[string]string
+	route   string
+	labels  ResourceLabelsFunc
+	fetched bool
+	values  map[string]string
+	refs    []string
+	err     error
+
+	// refLabels are the labels of the containers referenced by a container
+	// create request, by reference.
+	refLabels map[string]map[string]string
+}
+
+// resourceLabels returns the labels of the resource of the request, and
+// whether the request is for a resource that has labels.
+func (req *rbacRequest) resourceLabels() (map[string]string, bool, error) {
+	if !req.fetched {
+		req.fetched = true
+		req.values, req.err = req.fetchLabels()
+	}
+	return req.values, req.values != nil || req.err != nil, req.err
+}
+
+func (req *rbacRequest) fetchLabels() (map[string]string, error) {
+	kind := strings.SplitN(strings.TrimPrefix(req.route, "/"), "/", 2)[0]
+	if !rbacLabelKinds[kind] {
+		return nil, nil
+	}
+	if req.route == "/"+kind+"/create" && kind != "images" {
+		labels, refs, err := createLabels(req.r)
+		req.refs = refs
+		return labels, err
+	}
+	name := req.vars["name"]
+	if name == "" {
+		name = req.vars["id"]
+	}
+	if name == "" || req.labels == nil {
+		return nil, nil
+	}
+	labels, err := req.labels(req.ctx, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	return labels, nil
+}
+
+// containerLabels returns the labels of the container with the given
+// reference, which is referenced by a container create request.
+func (req *rbacRequest) containerLabels(ref string) (map[string]string, error) {
+	if labels, ok := req.refLabels[ref]; ok {
+		return labels, nil
+	}
+	if req.labels == nil {
+		return nil, errdefs.NotFound(errors.Errorf("no such container: %s", ref))
+	}
+	labels, err := req.labels(req.ctx, "containers", ref)
+	if err != nil {
+		return nil, err
+	}
+	if req.refLabels == nil {
+		req.refLabels = make(map[string]map[string]string)
+	}
+	req.refLabels[ref] = labels
+	return labels, nil
+}
+
+// createLabels returns the labels of the body of a create request, and the
+// containers that it references, and restores the body for the handler of
+// the request.
+func createLabels(r *http.Request) (map[string]string, []string, error) {
+	if r.Body == nil {
+		return map[string]string{}, nil, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRBACCreateBody+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(body) > maxRBACCreateBody {
+		return nil, nil, errdefs.InvalidParameter(errors.New("request body is too large"))
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var config struct {
+		Labels     map[string]string
+		HostConfig struct {
+			PidMode     string
+			IpcMode     string
+			NetworkMode string
+			VolumesFrom []string
+			Links       []string
+		}
+	}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &config); err != nil {
+			return nil, nil, errdefs.InvalidParameter(err)
+		}
+	}
+	if config.Labels == nil {
+		config.Labels = map[string]string{}
+	}
+
+	var refs []string
+	for _, mode := range []string{config.HostConfig.PidMode, config.HostConfig.IpcMode, config.HostConfig.NetworkMode} {
+		if ref := strings.TrimPrefix(mode, "container:"); ref != mode {
+			refs = append(refs, ref)
+		}
+	}
+	for _, v := range config.HostConfig.VolumesFrom {
+		// The reference may be followed by a mode, such as "name:ro".
+		refs = append(refs, strings.SplitN(v, ":", 2)[0])
+	}
+	for _, l := range config.HostConfig.Links {
+		// Links are "name:alias", where name may start with "/".
+		refs = append(refs, strings.TrimPrefix(strings.SplitN(l, ":", 2)[0], "/"))
+	}
+	return config.Labels, refs, nil
+}
+
+func authorizeRBAC(ctx context.Context, config *RBACConfig, labels ResourceLabelsFunc, r *http.Request, vars map[string]string) error {
+	route := requestRoute(r)
+	if route == "/_ping" {
+		// Clients negotiate the API version with pings, so that they must
+		// be allowed for all clients.
+		return nil
+	}
+
+	subject := requestSubject(ctx, r)
+	req := &rbacRequest{ctx: ctx, r: r, vars: vars, route: route, labels: labels}
+	for _, b := range config.Bindings {
+		if !subject.matches(b) {
+			continue
+		}
+		for _, name := range b.Roles {
+			for _, rule := range config.Roles[name].Rules {
+				ok, err := rule.allows(req)
+				if err != nil {
+					return err
+				}
+				if ok {
+					return nil
+				}
+			}
+		}
+	}
+	return errdefs.Forbidden(errors.Errorf("access denied: %s is not allowed to %s %s", subject, r.Method, route))
+}
+
+func (rule RBACRule) allows(req *rbacRequest) (bool, error) {
+	if !rule.matchesRoute(req.r.Method, req.route) {
+		return false, nil
+	}
+	if len(rule.Labels) == 0 {
+		return true, nil
+	}
+	labels, ok, err := req.resourceLabels()
+	if !ok || errdefs.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !rule.matchesLabels(labels) {
+		return false, nil
+	}
+	for _, ref := range req.refs {
+		labels, err := req.containerLabels(ref)
+		if errdefs.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if !rule.matchesLabels(labels) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (rule RBACRule) matchesLabels(labels map[string]string) bool {
+	for k, v := range rule.Labels {
+		if value, ok := labels[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (rule RBACRule) matchesRoute(method, route string) bool {
+	route = normalizeRoutePath(route)
+	for _, r := range rule.Routes {
+		m, path, err := parseRBACRoute(r)
+		if err != nil || (m != "" && m != method) {
+			continue
+		}
+		if path == route || (strings.HasSuffix(path, "*") && strings.HasPrefix(route, strings.TrimSuffix(path, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	routeVariable     = regexp.MustCompile(`\{(\w+):[^}]*\}`)
+	routeVariableName = regexp.MustCompile(`\{[^}]*\}`)
+	routeVersion      = regexp.MustCompile(`^/v\{version\}`)
+)
+
+// normalizeRoutePath returns path without the names of its variables, such as
+// "/containers/{}/exec", so that routes match regardless of the names of
+// their variables.
+func normalizeRoutePath(path string) string {
+	return routeVariableName.ReplaceAllString(path, "{}")
+}
+
+// requestRoute returns the path of the route of r, without the API version
+// and the patterns of its variables, such as "/containers/{name}/exec".
+func requestRoute(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return r.URL.Path
+	}
+	path, err := route.GetPathTemplate()
+	if err != nil {
+		return r.URL.Path
+	}
+	path = routeVariable.ReplaceAllString(path, "{$1}")
+	return routeVersion.ReplaceAllString(path, "")
+}
+
+// rbacSubject identifies a client of the API.
+type rbacSubject struct {
+	user  string
+	creds *PeerCredentials
+}
+
+func requestSubject(ctx context.Context, r *http.Request) rbacSubject {
+	var s rbacSubject
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		s.user = r.TLS.PeerCertificates[0].Subject.CommonName
+	}
+	s.creds = PeerCredentialsFromContext(ctx)
+	return s
+}
+
+func (s rbacSubject) matches(b RBACBinding) bool {
+	if b.User != "" && b.User != s.user {
+		return false
+	}
+	if b.UID != nil && (s.creds == nil || *b.UID != s.creds.UID) {
+		return false
+	}
+	if b.GID != nil && (s.creds == nil || *b.GID != s.creds.GID) {
+		return false
+	}
+	return true
+}
+
+func (s rbacSubject) String() string {
+	switch {
+	case s.user != "":
+		return fmt.Sprintf("user %q", s.user)
+	case s.creds != nil:
+		return fmt.Sprintf("uid %d (gid %d)", s.creds.UID, s.creds.GID)
+	}
+	return "anonymous client"
+}
+
+// PeerCredentials are the credentials of the process of a client of a Unix
+// socket.
+type PeerCredentials struct {
+	PID int32
+	UID uint32
+	GID uint32
+}
+
+type peerCredentialsKey struct{}
+
+// WithPeerCredentials returns ctx with the credentials of the peer of conn,
+// if conn is a connection to a Unix socket and its peer credentials are
+// supported on the platform. It is used as the ConnContext of the HTTP
+// servers of the API.
+func WithPeerCredentials(ctx context.Context, conn net.Conn) context.Context {
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return ctx
+	}
+	creds, err := peerCredentials(uc)
+	if err != nil || creds == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, peerCredentialsKey{}, creds)
+}
+
+// PeerCredentialsFromContext returns the peer credentials of the client of
+// the request with ctx, if any.
+func PeerCredentialsFromContext(ctx context.Context) *PeerCredentials {
+	creds, _ := ctx.Value(peerCredentialsKey{}).(*PeerCredentials)
+	return creds
+}
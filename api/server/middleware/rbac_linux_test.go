@@ -0,0 +1,31 @@
+package middleware // import "github.com/docker/docker/api/server/middleware"
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestWithPeerCredentials(t *testing.T) {
+	l, err := net.Listen("unix", filepath.Join(t.TempDir(), "docker.sock"))
+	assert.NilError(t, err)
+	defer l.Close()
+
+	client, err := net.Dial("unix", l.Addr().String())
+	assert.NilError(t, err)
+	defer client.Close()
+	conn, err := l.Accept()
+	assert.NilError(t, err)
+	defer conn.Close()
+
+	creds := PeerCredentialsFromContext(WithPeerCredentials(context.Background(), conn))
+	assert.Assert(t, creds != nil)
+	assert.Check(t, is.Equal(creds.UID, uint32(os.Getuid())))
+	assert.Check(t, is.Equal(creds.GID, uint32(os.Getgid())))
+	assert.Check(t, is.Equal(creds.PID, int32(os.Getpid())))
+}
@@ -0,0 +1,172 @@
+package middleware // import "github.com/docker/docker/api/server/middleware"
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/server/router"
+	"github.com/docker/docker/api/server/router/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/gorilla/mux"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+const rbacTestConfig = `{
+	"roles": {
+		"admin": {"rules": [{"routes": ["*"]}]},
+		"team-a": {"rules": [
+			{"routes": ["GET /containers/json", "GET /containers/{name}/json"]},
+			{"routes": ["POST /containers/{name}/exec", "POST /exec/{id}/start", "POST /containers/create"], "labels": {"team": "a"}}
+		]},
+		"viewer": {"rules": [{"routes": ["GET /images/*"]}]}
+	},
+	"bindings": [
+		{"uid": 0, "roles": ["admin"]},
+		{"user": "alice", "roles": ["team-a"]},
+		{"gid": 100, "roles": ["viewer"]}
+	]
+}`
+
+func TestRBACConfigValidate(t *testing.T) {
+	var config RBACConfig
+	assert.NilError(t, json.Unmarshal([]byte(rbacTestConfig), &config))
+	assert.Check(t, config.Validate())
+
+	for doc, tc := range map[string]struct {
+		config string
+		err    string
+	}{
+		"rule without routes":     {`{"roles": {"a": {"rules": [{}]}}}`, `invalid rbac role "a": rules require routes`},
+		"relative path":           {`{"roles": {"a": {"rules": [{"routes": ["GET containers"]}]}}}`, `paths must start with /`},
+		"unknown role":            {`{"bindings": [{"uid": 1000, "roles": ["missing"]}]}`, `unknown role "missing"`},
+		"binding without subject": {`{"roles": {"a": {"rules": [{"routes": ["*"]}]}}, "bindings": [{"roles": ["a"]}]}`, `bindings require a user, uid or gid`},
+	} {
+		var config RBACConfig
+		assert.NilError(t, json.Unmarshal([]byte(tc.config), &config), doc)
+		assert.Check(t, is.ErrorContains(config.Validate(), tc.err), doc)
+	}
+}
+
+func TestRBACMiddleware(t *testing.T) {
+	var config RBACConfig
+	assert.NilError(t, json.Unmarshal([]byte(rbacTestConfig), &config))
+
+	labels := map[string]map[string]string{
+		"containers/a1":  {"team": "a"},
+		"containers/b1":  {"team": "b"},
+		"exec/a1-exec":   {"team": "a"},
+		"exec/b1-exec":   {"team": "b"},
+		"images/busybox": nil,
+	}
+	mw := NewRBACMiddleware(&config)
+	mw.SetResourceLabels(func(_ context.Context, kind, name string) (map[string]string, error) {
+		l, ok := labels[kind+"/"+name]
+		if !ok {
+			return nil, errdefs.NotFound(io.EOF)
+		}
+		return l, nil
+	})
+
+	// The routes of the container router are used as is, so that the rules
+	// are matched against the names of the variables of the actual routes.
+	routes := container.NewRouter(nil, nil, false).Routes()
+	routes = append(routes,
+		router.NewGetRoute("/_ping", nil),
+		router.NewGetRoute("/images/{name:.*}/json", nil),
+	)
+
+	var handled string
+	m := mux.NewRouter()
+	for _, route := range routes {
+		h := mw.WrapHandler(func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+			if r.Body != nil {
+				body, _ := io.ReadAll(r.Body)
+				handled = string(body)
+			}
+			return nil
+		})
+		handler := func(w http.ResponseWriter, r *http.Request) {
+			if err := h(r.Context(), w, r, mux.Vars(r)); err != nil {
+				assert.Check(t, errdefs.IsForbidden(err), err)
+				w.WriteHeader(http.StatusForbidden)
+			}
+		}
+		m.Path("/v{version:[0-9.]+}" + route.Path()).Methods(route.Method()).HandlerFunc(handler)
+		m.Path(route.Path()).Methods(route.Method()).HandlerFunc(handler)
+	}
+
+	root := uint32(0)
+	other := uint32(1000)
+	users := uint32(100)
+	testCases := []struct {
+		doc     string
+		user    string
+		creds   *PeerCredentials
+		method  string
+		path    string
+		body    string
+		allowed bool
+	}{
+		{doc: "ping", method: http.MethodGet, path: "/_ping", allowed: true},
+		{doc: "anonymous", method: http.MethodGet, path: "/containers/json"},
+		{doc: "root", creds: &PeerCredentials{UID: root, GID: root}, method: http.MethodDelete, path: "/v1.42/containers/b1", allowed: true},
+		{doc: "unbound uid", creds: &PeerCredentials{UID: other, GID: other}, method: http.MethodGet, path: "/containers/json"},
+		{doc: "gid", creds: &PeerCredentials{UID: other, GID: users}, method: http.MethodGet, path: "/images/busybox/json", allowed: true},
+		{doc: "gid other route", creds: &PeerCredentials{UID: other, GID: users}, method: http.MethodGet, path: "/containers/json"},
+		{doc: "list", user: "alice", method: http.MethodGet, path: "/v1.42/containers/json", allowed: true},
+		{doc: "inspect", user: "alice", method: http.MethodGet, path: "/containers/b1/json", allowed: true},
+		{doc: "remove", user: "alice", method: http.MethodDelete, path: "/containers/a1"},
+		{doc: "exec labelled", user: "alice", method: http.MethodPost, path: "/containers/a1/exec", allowed: true},
+		{doc: "exec other team", user: "alice", method: http.MethodPost, path: "/containers/b1/exec"},
+		{doc: "exec missing container", user: "alice", method: http.MethodPost, path: "/containers/missing/exec"},
+		{doc: "start exec labelled", user: "alice", method: http.MethodPost, path: "/exec/a1-exec/start", allowed: true},
+		{doc: "start exec other team", user: "alice", method: http.MethodPost, path: "/exec/b1-exec/start"},
+		{doc: "create labelled", user: "alice", method: http.MethodPost, path: "/containers/create", body: `{"Image":"busybox","Labels":{"team":"a"}}`, allowed: true},
+		{doc: "create unlabelled", user: "alice", method: http.MethodPost, path: "/containers/create", body: `{"Image":"busybox"}`},
+		{doc: "create joining labelled", user: "alice", method: http.MethodPost, path: "/containers/create", body: `{"Image":"busybox","Labels":{"team":"a"},"HostConfig":{"NetworkMode":"container:a1","VolumesFrom":["a1:ro"]}}`, allowed: true},
+		{doc: "create joining other team pid", user: "alice", method: http.MethodPost, path: "/containers/create", body: `{"Image":"busybox","Labels":{"team":"a"},"HostConfig":{"PidMode":"container:b1"}}`},
+		{doc: "create joining other team ipc", user: "alice", method: http.MethodPost, path: "/containers/create", body: `{"Image":"busybox","Labels":{"team":"a"},"HostConfig":{"IpcMode":"container:b1"}}`},
+		{doc: "create joining other team network", user: "alice", method: http.MethodPost, path: "/containers/create", body: `{"Image":"busybox","Labels":{"team":"a"},"HostConfig":{"NetworkMode":"container:b1"}}`},
+		{doc: "create volumes from other team", user: "alice", method: http.MethodPost, path: "/containers/create", body: `{"Image":"busybox","Labels":{"team":"a"},"HostConfig":{"VolumesFrom":["a1","b1:rw"]}}`},
+		{doc: "create linking other team", user: "alice", method: http.MethodPost, path: "/containers/create", body: `{"Image":"busybox","Labels":{"team":"a"},"HostConfig":{"Links":["/b1:db"]}}`},
+		{doc: "create joining missing container", user: "alice", method: http.MethodPost, path: "/containers/create", body: `{"Image":"busybox","Labels":{"team":"a"},"HostConfig":{"NetworkMode":"container:missing"}}`},
+		{doc: "other user", user: "bob", method: http.MethodGet, path: "/containers/json"},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.doc, func(t *testing.T) {
+			handled = ""
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if tc.user != "" {
+				req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: tc.user}}}}
+			}
+			if tc.creds != nil {
+				req = req.WithContext(context.WithValue(req.Context(), peerCredentialsKey{}, tc.creds))
+			}
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			if tc.allowed {
+				assert.Check(t, is.Equal(resp.Code, http.StatusOK))
+				// The body of create requests is passed on to the handler.
+				assert.Check(t, is.Equal(handled, tc.body))
+			} else {
+				assert.Check(t, is.Equal(resp.Code, http.StatusForbidden))
+			}
+		})
+	}
+
+	// Requests are allowed without configuration.
+	mw.SetConfig(nil)
+	resp := httptest.NewRecorder()
+	m.ServeHTTP(resp, httptest.NewRequest(http.MethodDelete, "/containers/b1", nil))
+	assert.Check(t, is.Equal(resp.Code, http.StatusOK))
+}
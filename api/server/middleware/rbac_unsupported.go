@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package middleware // import "github.com/docker/docker/api/server/middleware"
+
+import "net"
+
+// peerCredentials returns nil, as peer credentials are not supported on this
+// platform.
+func peerCredentials(*net.UnixConn) (*PeerCredentials, error) {
+	return nil, nil
+}
@@ -16,6 +16,8 @@ import (
 type execBackend interface {
 	ContainerExecCreate(name string, config *types.ExecConfig) (string, error)
 	ContainerExecInspect(id string) (*backend.ExecInspect, error)
+	ContainerExecKill(name string, sig uint64) error
+	ContainerExecList(name string) ([]types.ExecSummary, error)
 	ContainerExecResize(name string, height, width int) error
 	ContainerExecStart(ctx context.Context, name string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error
 	ExecExists(name string) (bool, error)
@@ -23,8 +25,11 @@ type execBackend interface {
 
 // copyBackend includes functions to implement to provide container copy functionality.
 type copyBackend interface {
+	ContainerApplyChangesToDir(name, path string, copyUIDGID bool, content io.Reader) error
+	ContainerArchiveManifest(name, path string) ([]types.ContainerPathManifestEntry, error)
 	ContainerArchivePath(name string, path string) (content io.ReadCloser, stat *types.ContainerPathStat, err error)
 	ContainerCopy(name string, res string) (io.ReadCloser, error)
+	ContainerCopyFromContainer(name, path, source, sourcePath string, options types.CopyBetweenContainersOptions) error
 	ContainerExport(name string, out io.Writer) error
 	ContainerExtractToDir(name, path string, copyUIDGID, noOverwriteDirNonDir bool, content io.Reader) error
 	ContainerStatPath(name string, path string) (stat *types.ContainerPathStat, err error)
@@ -49,10 +54,13 @@ type stateBackend interface {
 // monitorBackend includes functions to implement to provide containers monitoring functionality.
 type monitorBackend interface {
 	ContainerChanges(name string) ([]archive.Change, error)
+	ContainerChangesDetails(ctx context.Context, name string, config *backend.ContainerChangesConfig) error
 	ContainerInspect(name string, size bool, version string) (interface{}, error)
 	ContainerLogs(ctx context.Context, name string, config *types.ContainerLogsOptions) (msgs <-chan *backend.LogMessage, tty bool, err error)
 	ContainerStats(ctx context.Context, name string, config *backend.ContainerStatsConfig) error
+	ContainerStatsAll(ctx context.Context, config *backend.ContainerStatsAllConfig) error
 	ContainerTop(name string, psArgs string) (*container.ContainerTopOKBody, error)
+	ContainerTopProcesses(name string) (*container.TopProcessList, error)
 
 	Containers(config *types.ContainerListOptions) ([]*types.Container, error)
 }
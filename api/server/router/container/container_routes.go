@@ -123,6 +123,29 @@ func (s *containerRouter) getContainersStats(ctx context.Context, w http.Respons
 	return s.backend.ContainerStats(ctx, vars["name"], config)
 }
 
+func (s *containerRouter) getContainersStatsAll(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+	filter, err := filters.FromJSON(r.Form.Get("filters"))
+	if err != nil {
+		return err
+	}
+
+	stream := httputils.BoolValueOrDefault(r, "stream", true)
+	if !stream {
+		w.Header().Set("Content-Type", "application/json")
+	}
+
+	config := &backend.ContainerStatsAllConfig{
+		Filters:   filter,
+		Stream:    stream,
+		OutStream: w,
+	}
+
+	return s.backend.ContainerStatsAll(ctx, config)
+}
+
 func (s *containerRouter) getContainersLogs(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := httputils.ParseForm(r); err != nil {
 		return err
@@ -409,6 +432,21 @@ func (s *containerRouter) postContainersWait(ctx context.Context, w http.Respons
 }
 
 func (s *containerRouter) getContainersChanges(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	if httputils.BoolValue(r, "details") && versions.GreaterThanOrEqualTo(httputils.VersionFromContext(ctx), "1.42") {
+		w.Header().Set("Content-Type", "application/json")
+		config := &backend.ContainerChangesConfig{
+			Image:     r.Form.Get("image"),
+			Paths:     r.Form["path"],
+			Digests:   httputils.BoolValue(r, "digests"),
+			OutStream: w,
+		}
+		return s.backend.ContainerChangesDetails(ctx, vars["name"], config)
+	}
+
 	changes, err := s.backend.ContainerChanges(vars["name"])
 	if err != nil {
 		return err
@@ -422,6 +460,21 @@ func (s *containerRouter) getContainersTop(ctx context.Context, w http.ResponseW
 		return err
 	}
 
+	switch format := r.Form.Get("format"); format {
+	case "":
+	case container.TopFormatProcesses:
+		if r.Form.Get("ps_args") != "" {
+			return errdefs.InvalidParameter(errors.New("ps_args cannot be used with format processes"))
+		}
+		procList, err := s.backend.ContainerTopProcesses(vars["name"])
+		if err != nil {
+			return err
+		}
+		return httputils.WriteJSON(w, http.StatusOK, procList)
+	default:
+		return errdefs.InvalidParameter(errors.Errorf("invalid top format %q", format))
+	}
+
 	procList, err := s.backend.ContainerTop(vars["name"], r.Form.Get("ps_args"))
 	if err != nil {
 		return err
@@ -12,7 +12,9 @@ import (
 	"github.com/docker/docker/api/server/httputils"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/versions"
+	"github.com/docker/docker/errdefs"
 	gddohttputil "github.com/golang/gddo/httputil"
+	"github.com/pkg/errors"
 )
 
 type pathError struct{}
@@ -134,5 +136,51 @@ func (s *containerRouter) putContainersArchive(ctx context.Context, w http.Respo
 	noOverwriteDirNonDir := httputils.BoolValue(r, "noOverwriteDirNonDir")
 	copyUIDGID := httputils.BoolValue(r, "copyUIDGID")
 
+	if httputils.BoolValue(r, "sync") && versions.GreaterThanOrEqualTo(httputils.VersionFromContext(ctx), "1.42") {
+		return s.backend.ContainerApplyChangesToDir(v.Name, v.Path, copyUIDGID, r.Body)
+	}
 	return s.backend.ContainerExtractToDir(v.Name, v.Path, copyUIDGID, noOverwriteDirNonDir, r.Body)
 }
+
+func (s *containerRouter) getContainersArchiveManifest(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	v, err := httputils.ArchiveFormValues(r, vars)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := s.backend.ContainerArchiveManifest(v.Name, v.Path)
+	if err != nil {
+		return err
+	}
+
+	return httputils.WriteJSON(w, http.StatusOK, manifest)
+}
+
+func (s *containerRouter) postContainersArchiveCopy(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	v, err := httputils.ArchiveFormValues(r, vars)
+	if err != nil {
+		return err
+	}
+
+	source := r.Form.Get("source")
+	if source == "" {
+		return errdefs.InvalidParameter(errors.New("source container is required"))
+	}
+	sourcePath := r.Form.Get("sourcePath")
+	if sourcePath == "" {
+		return errdefs.InvalidParameter(errors.New("source path is required"))
+	}
+
+	options := types.CopyBetweenContainersOptions{
+		AllowOverwriteDirWithFile: !httputils.BoolValue(r, "noOverwriteDirNonDir"),
+		CopyUIDGID:                httputils.BoolValue(r, "copyUIDGID"),
+		Sync:                      httputils.BoolValue(r, "sync"),
+		Delete:                    httputils.BoolValue(r, "delete"),
+	}
+	if err := s.backend.ContainerCopyFromContainer(v.Name, v.Path, source, sourcePath, options); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -20,6 +20,10 @@ type DiskUsageOptions struct {
 
 	// Volumes controls whether volume disk usage should be computed.
 	Volumes bool
+
+	// Dedup controls whether a report of file content that is duplicated
+	// across image layers should be computed.
+	Dedup bool
 }
 
 // Backend is the methods that need to be implemented to provide
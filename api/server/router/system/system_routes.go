@@ -134,16 +134,22 @@ func (s *systemRouter) getDiskUsage(ctx context.Context, w http.ResponseWriter,
 		}
 	}
 
+	var getDedup bool
+	if versions.GreaterThanOrEqualTo(version, "1.42") {
+		getDedup = httputils.BoolValue(r, "dedup")
+	}
+
 	eg, ctx := errgroup.WithContext(ctx)
 
 	var systemDiskUsage *types.DiskUsage
-	if getContainers || getImages || getVolumes {
+	if getContainers || getImages || getVolumes || getDedup {
 		eg.Go(func() error {
 			var err error
 			systemDiskUsage, err = s.backend.SystemDiskUsage(ctx, DiskUsageOptions{
 				Containers: getContainers,
 				Images:     getImages,
 				Volumes:    getVolumes,
+				Dedup:      getDedup,
 			})
 			return err
 		})
@@ -186,6 +192,7 @@ func (s *systemRouter) getDiskUsage(ctx context.Context, w http.ResponseWriter,
 		du.Images = systemDiskUsage.Images
 		du.Containers = systemDiskUsage.Containers
 		du.Volumes = systemDiskUsage.Volumes
+		du.Dedup = systemDiskUsage.Dedup
 	}
 	return httputils.WriteJSON(w, http.StatusOK, du)
 }
@@ -56,7 +56,8 @@ func (s *Server) Accept(addr string, listeners ...net.Listener) {
 	for _, listener := range listeners {
 		httpServer := &HTTPServer{
 			srv: &http.Server{
-				Addr: addr,
+				Addr:        addr,
+				ConnContext: middleware.WithPeerCredentials,
 			},
 			l: listener,
 		}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
 )
 
 // ContainerAttachConfig holds the streams to use when connecting to a container to view logs.
@@ -76,6 +77,31 @@ type ContainerStatsConfig struct {
 	Version   string
 }
 
+// ContainerStatsAllConfig holds information for configuring the runtime
+// behavior of a backend.ContainerStatsAll() call.
+type ContainerStatsAllConfig struct {
+	// Filters selects the containers of which stats are streamed, with the
+	// filters of the list of containers. Only running containers are
+	// selected, and containers that start while streaming are added.
+	Filters   filters.Args
+	Stream    bool
+	OutStream io.Writer
+}
+
+// ContainerChangesConfig holds information for configuring the runtime
+// behavior of a backend.ContainerChangesDetails() call.
+type ContainerChangesConfig struct {
+	// Image is the image to compare the container with. The container is
+	// compared with its own image if it is not set.
+	Image string
+	// Paths restricts the changes to the files in these paths.
+	Paths []string
+	// Digests adds the digests of the content of regular files to the
+	// changes.
+	Digests   bool
+	OutStream io.Writer
+}
+
 // ExecInspect holds information about a running process started
 // with docker exec.
 type ExecInspect struct {
@@ -124,4 +150,7 @@ type CommitConfig struct {
 	ContainerMountLabel string
 	ContainerOS         string
 	ParentImageID       string
+	// SourceDateEpoch, if set, is the creation time of the image, and the
+	// time to which the timestamps of files in the committed layer are clamped.
+	SourceDateEpoch *time.Time
 }
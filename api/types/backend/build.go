@@ -2,6 +2,7 @@ package backend // import "github.com/docker/docker/api/types/backend"
 
 import (
 	"io"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/pkg/streamformatter"
@@ -36,10 +37,24 @@ type BuildConfig struct {
 	Options        *types.ImageBuildOptions
 }
 
+// ImageCacheOptions are the options supported by MakeImageCache
+type ImageCacheOptions struct {
+	AuthConfig map[string]types.AuthConfig
+	Output     io.Writer
+	Platform   *specs.Platform
+	// SourceDateEpoch, if set, is the creation time of the images of the
+	// build, so that only images that were created at that time are used as
+	// cache.
+	SourceDateEpoch *time.Time
+}
+
 // GetImageAndLayerOptions are the options supported by GetImageAndReleasableLayer
 type GetImageAndLayerOptions struct {
 	PullOption PullOption
 	AuthConfig map[string]types.AuthConfig
 	Output     io.Writer
 	Platform   *specs.Platform
+	// SourceDateEpoch, if set, is the time to which the timestamps of files
+	// in layers committed from the image are clamped.
+	SourceDateEpoch *time.Time
 }
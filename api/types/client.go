@@ -93,11 +93,51 @@ type ContainerStartOptions struct {
 	CheckpointDir string
 }
 
+// ContainerDiffOptions holds parameters to get the detailed changes of the
+// filesystem of a container.
+type ContainerDiffOptions struct {
+	// Image is the image to compare the container with, instead of the
+	// image of the container.
+	Image string
+	// Paths restricts the changes to the files in these paths.
+	Paths []string
+	// Digests adds the digests of the content of regular files to the
+	// changes.
+	Digests bool
+}
+
+// ContainerStatsAllOptions holds parameters to get the stats of several
+// containers in one stream.
+type ContainerStatsAllOptions struct {
+	// Filters selects the running containers of which stats are returned,
+	// with the filters of the list of containers.
+	Filters filters.Args
+	// Stream keeps returning the stats of the containers, including the
+	// containers that start later, instead of returning a single batch.
+	Stream bool
+}
+
 // CopyToContainerOptions holds information
 // about files to copy into a container
 type CopyToContainerOptions struct {
 	AllowOverwriteDirWithFile bool
 	CopyUIDGID                bool
+	// Sync indicates that the content is a set of changes to the directory,
+	// in the format of image layers, in which deleted files are whiteouts.
+	Sync bool
+}
+
+// CopyBetweenContainersOptions holds parameters to copy files from one
+// container to another.
+type CopyBetweenContainersOptions struct {
+	AllowOverwriteDirWithFile bool
+	CopyUIDGID                bool
+	// Sync copies only the files of the source directory that differ from
+	// the destination directory.
+	Sync bool
+	// Delete removes files from the destination directory that are not in
+	// the source directory when syncing.
+	Delete bool
 }
 
 // EventsOptions holds parameters to filter events with.
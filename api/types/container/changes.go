@@ -0,0 +1,37 @@
+package container // import "github.com/docker/docker/api/types/container"
+
+import (
+	"os"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// FilesystemChange describes a change of a file in the filesystem of a
+// container, with the details of the file before and after the change. It is
+// returned by GET "/containers/{name:.*}/changes" with the details parameter.
+type FilesystemChange struct {
+	// Path is the path of the file in the container.
+	Path string
+	// Kind is the kind of the change, as in ContainerChangeResponseItem.
+	Kind uint8
+	// Old is the file before the change. It is not set for added files.
+	Old *FilesystemChangeFile `json:",omitempty"`
+	// New is the file after the change. It is not set for deleted files.
+	New *FilesystemChangeFile `json:",omitempty"`
+}
+
+// FilesystemChangeFile describes a file of a FilesystemChange.
+type FilesystemChangeFile struct {
+	// Size is the size of the file in bytes. It is zero for directories.
+	Size  int64
+	Mode  os.FileMode
+	UID   int
+	GID   int
+	Mtime time.Time
+	// LinkTarget is the target of symbolic links.
+	LinkTarget string `json:",omitempty"`
+	// Digest is the digest of the content of regular files, if digests are
+	// requested.
+	Digest digest.Digest `json:",omitempty"`
+}
@@ -0,0 +1,40 @@
+package container // import "github.com/docker/docker/api/types/container"
+
+import "time"
+
+// TopFormatProcesses is the format of GET "/containers/{name:.*}/top" that
+// returns a TopProcessList instead of the output of ps.
+const TopFormatProcesses = "processes"
+
+// TopProcessList is the list of the processes of a container that is returned
+// by GET "/containers/{name:.*}/top?format=processes".
+type TopProcessList struct {
+	Processes []TopProcess
+}
+
+// TopProcess is a process of a container.
+type TopProcess struct {
+	// Pid is the process ID of the process on the host.
+	Pid int
+	// PPid is the process ID of the parent of the process on the host.
+	PPid int
+	// UID is the effective user ID of the process on the host.
+	UID uint32
+	// User is the name of the user of the process in the container, if it
+	// is known.
+	User string `json:",omitempty"`
+	// State is the state of the process, such as "R" (running) or "S"
+	// (sleeping), as in the STAT column of ps.
+	State string `json:",omitempty"`
+	// CPUPercent is the percentage of CPU time that the process used since it
+	// started.
+	CPUPercent float64
+	// CPUTime is the CPU time that the process used, in seconds.
+	CPUTime float64
+	// RSS is the resident set size of the process, in bytes.
+	RSS uint64
+	// StartTime is the time the process started.
+	StartTime time.Time
+	// Cmd is the command line of the process.
+	Cmd []string
+}
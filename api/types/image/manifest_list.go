@@ -0,0 +1,29 @@
+package image // import "github.com/docker/docker/api/types/image"
+
+import specs "github.com/opencontainers/image-spec/specs-go/v1"
+
+// ManifestList is a manifest list, or OCI image index, that is composed of
+// local images and pushed to a registry.
+type ManifestList struct {
+	// MediaType is the media type of the manifest list, which is either the
+	// media type of Docker manifest lists or of OCI image indexes. It
+	// defaults to the media type of Docker manifest lists.
+	MediaType string `json:",omitempty"`
+
+	// Manifests are the images of the manifest list.
+	Manifests []ManifestListEntry
+}
+
+// ManifestListEntry is a local image of a manifest list.
+type ManifestListEntry struct {
+	// Image is the name or ID of the local image.
+	Image string
+
+	// Platform is the platform of the image in the manifest list. It
+	// defaults to the platform of the image. If Image is a tag that has
+	// images for multiple platforms, the image for Platform is used.
+	Platform *specs.Platform `json:",omitempty"`
+
+	// Annotations are the annotations of the image in the manifest list.
+	Annotations map[string]string `json:",omitempty"`
+}
@@ -52,6 +52,47 @@ type CPUStats struct {
 
 	// Throttling Data. Linux only.
 	ThrottlingData ThrottlingData `json:"throttling_data,omitempty"`
+
+	// Pressure stall information of CPU. Linux only, with cgroup v2.
+	Pressure *PressureStats `json:"pressure,omitempty"`
+}
+
+// PressureStats stores the pressure stall information (PSI) of a resource,
+// which is the share of time in which tasks of the cgroup were stalled waiting
+// for the resource. Not used on Windows.
+type PressureStats struct {
+	// Some is the time in which at least some tasks were stalled.
+	Some PressureData `json:"some"`
+	// Full is the time in which all non-idle tasks were stalled at the same
+	// time.
+	Full PressureData `json:"full"`
+}
+
+// PressureData stores the stall times of one line of a pressure file.
+type PressureData struct {
+	// Percentages of time stalled, averaged over 10, 60 and 300 seconds.
+	Avg10  float64 `json:"avg10"`
+	Avg60  float64 `json:"avg60"`
+	Avg300 float64 `json:"avg300"`
+	// Total time stalled.
+	// Units: microseconds.
+	Total uint64 `json:"total"`
+}
+
+// MemoryEvents stores the number of times the memory events of a container
+// occurred, from the memory.events file of cgroup v2. Not used on Windows.
+type MemoryEvents struct {
+	// Number of times the cgroup was reclaimed below its low boundary.
+	Low uint64 `json:"low"`
+	// Number of times the cgroup was throttled as it exceeded its high
+	// boundary.
+	High uint64 `json:"high"`
+	// Number of times the cgroup was about to exceed its max boundary.
+	Max uint64 `json:"max"`
+	// Number of times the cgroup ran out of memory.
+	OOM uint64 `json:"oom"`
+	// Number of processes of the cgroup killed by the OOM killer.
+	OOMKill uint64 `json:"oom_kill"`
 }
 
 // MemoryStats aggregates all memory stats since container inception on Linux.
@@ -69,6 +110,12 @@ type MemoryStats struct {
 	// number of times memory usage hits limits.
 	Failcnt uint64 `json:"failcnt,omitempty"`
 	Limit   uint64 `json:"limit,omitempty"`
+	// number of processes killed by the OOM killer.
+	OOMKills uint64 `json:"oom_kills,omitempty"`
+	// memory events, with cgroup v2 only.
+	Events *MemoryEvents `json:"events,omitempty"`
+	// pressure stall information of memory, with cgroup v2 only.
+	Pressure *PressureStats `json:"pressure,omitempty"`
 
 	// Windows Memory Stats
 	// See https://technet.microsoft.com/en-us/magazine/ff382715.aspx
@@ -104,6 +151,23 @@ type BlkioStats struct {
 	IoMergedRecursive       []BlkioStatEntry `json:"io_merged_recursive"`
 	IoTimeRecursive         []BlkioStatEntry `json:"io_time_recursive"`
 	SectorsRecursive        []BlkioStatEntry `json:"sectors_recursive"`
+
+	// IoStats are the stats of each device from the io.stat file, with
+	// cgroup v2 only.
+	IoStats []BlkioDeviceStats `json:"io_stats,omitempty"`
+	// Pressure stall information of I/O, with cgroup v2 only.
+	Pressure *PressureStats `json:"pressure,omitempty"`
+}
+
+// BlkioDeviceStats stores the I/O stats of a device of a container.
+// Not used on Windows.
+type BlkioDeviceStats struct {
+	Major uint64 `json:"major"`
+	Minor uint64 `json:"minor"`
+	// Stats are the numeric keys of the line of the device in io.stat, such
+	// as "rbytes", "wbytes", "rios", "wios", "dbytes" and "dios", and the
+	// latency stats when the io.latency controller is enabled.
+	Stats map[string]uint64 `json:"stats"`
 }
 
 // StorageStats is the disk I/O stats for read/write on Windows.
@@ -179,3 +243,13 @@ type StatsJSON struct {
 	// Networks request version >=1.21
 	Networks map[string]NetworkStats `json:"networks,omitempty"`
 }
+
+// StatsBatch is a batch of the stats of several containers, which were
+// sampled in the same iteration of the stats collector.
+type StatsBatch struct {
+	// Read is the time at which the batch was completed.
+	Read time.Time `json:"read"`
+	// Stats are the stats of the containers. The stats of containers that
+	// are not running are omitted.
+	Stats []StatsJSON `json:"stats"`
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/docker/docker/api/types/swarm"
 	"github.com/docker/docker/api/types/volume"
 	"github.com/docker/go-connections/nat"
+	"github.com/opencontainers/go-digest"
 )
 
 // RootFS returns Image's RootFS description including the layer IDs.
@@ -137,6 +138,10 @@ type ImageInspect struct {
 type ImageMetadata struct {
 	// LastTagTime is the date and time at which the image was last tagged.
 	LastTagTime time.Time `json:",omitempty"`
+
+	// Pinned indicates whether the image is pinned. Pinned images are not
+	// removed by prune, and can only be removed by forcing their removal.
+	Pinned bool `json:",omitempty"`
 }
 
 // Container contains response of Engine API:
@@ -178,6 +183,18 @@ type ContainerPathStat struct {
 	LinkTarget string      `json:"linkTarget"`
 }
 
+// ContainerPathManifestEntry describes a file in the manifest of a directory,
+// which is returned by GET "/containers/{name:.*}/archive/manifest".
+// "Path" is the path of the file relative to the directory.
+type ContainerPathManifestEntry struct {
+	Path       string        `json:"path"`
+	Size       int64         `json:"size"`
+	Mode       os.FileMode   `json:"mode"`
+	Mtime      time.Time     `json:"mtime"`
+	LinkTarget string        `json:"linkTarget,omitempty"`
+	Digest     digest.Digest `json:"digest,omitempty"`
+}
+
 // ContainerStats contains response of Engine API:
 // GET "/stats"
 type ContainerStats struct {
@@ -384,6 +401,23 @@ type ExecStartCheck struct {
 	Tty bool
 }
 
+// ExecSummary contains the information of an exec instance of a container,
+// for GET "/containers/{name:.*}/exec". Completed exec instances are kept in
+// the list after they are removed from the daemon, within a limit.
+type ExecSummary struct {
+	ID         string
+	Running    bool
+	ExitCode   *int
+	Pid        int
+	Cmd        []string
+	User       string
+	Tty        bool
+	Privileged bool
+	Created    time.Time // Created is the time the exec instance was created
+	StartedAt  time.Time // StartedAt is the time the process of the exec instance started
+	FinishedAt time.Time // FinishedAt is the time the process of the exec instance exited
+}
+
 // HealthcheckResult stores information about a single run of a healthcheck probe
 type HealthcheckResult struct {
 	Start    time.Time // Start is the time this check started
@@ -679,6 +713,10 @@ type DiskUsageOptions struct {
 	// Types specifies what object types to include in the response. If empty,
 	// all object types are returned.
 	Types []DiskUsageObject
+
+	// Dedup specifies whether to include a report of file content that is
+	// duplicated across image layers.
+	Dedup bool
 }
 
 // DiskUsage contains response of Engine API:
@@ -689,7 +727,50 @@ type DiskUsage struct {
 	Containers  []*Container
 	Volumes     []*volume.Volume
 	BuildCache  []*BuildCache
-	BuilderSize int64 `json:",omitempty"` // Deprecated: deprecated in API 1.38, and no longer used since API 1.40.
+	BuilderSize int64        `json:",omitempty"` // Deprecated: deprecated in API 1.38, and no longer used since API 1.40.
+	Dedup       *DedupReport `json:",omitempty"`
+}
+
+// DedupReport contains information about file content that is duplicated
+// across image layers. It is returned by GET "/system/df" if the "dedup"
+// parameter is set.
+type DedupReport struct {
+	// TotalFiles is the number of regular files in all layers.
+	TotalFiles int64
+
+	// TotalSize is the total size of the content of all regular files in
+	// all layers.
+	TotalSize int64
+
+	// DuplicatedSize is the number of bytes taken by additional copies of
+	// file content that is present more than once.
+	DuplicatedSize int64
+
+	// Files lists the duplicated file contents taking the most space,
+	// ordered by the number of duplicated bytes.
+	Files []*DuplicatedFile
+}
+
+// DuplicatedFile describes file content that is present more than once in
+// image layers.
+type DuplicatedFile struct {
+	// Digest is the digest of the file content.
+	Digest string
+
+	// Size is the size of a single copy of the file content.
+	Size int64
+
+	// Count is the number of copies of the file content.
+	Count int64
+
+	// Paths lists the paths at which the file content is present.
+	Paths []string
+
+	// Layers lists the chain IDs of the layers containing the file content.
+	Layers []string
+
+	// Images lists the IDs of the images sharing the file content.
+	Images []string
 }
 
 // ContainersPruneReport contains the response for Engine API:
@@ -764,6 +845,27 @@ type BuildResult struct {
 	ID string
 }
 
+// BuildStep contains the result of a step of a build by the classic builder.
+// It is sent as an aux message with the "moby.build.step" ID after each step.
+type BuildStep struct {
+	// Step is the 1-based index of the step in the build.
+	Step int
+	// Total is the total number of steps in the build.
+	Total int
+	// Instruction is the Dockerfile instruction of the step.
+	Instruction string
+	// Cached indicates whether the step's image was found in the build cache.
+	Cached bool
+	// ImageID is the ID of the image produced by the step.
+	ImageID string `json:",omitempty"`
+	// Duration is the time it took to run the step, in nanoseconds.
+	Duration time.Duration
+	// Size is the size of the layer added by the step, in bytes.
+	Size int64
+	// Error is set if the step failed.
+	Error string `json:",omitempty"`
+}
+
 // BuildCache contains information about a build cache record
 type BuildCache struct {
 	ID          string
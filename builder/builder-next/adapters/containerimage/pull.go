@@ -58,6 +58,12 @@ type SourceOpt struct {
 	LayerStore      layer.Store
 	LeaseManager    leases.Manager
 	GarbageCollect  func(ctx context.Context) (gc.Stats, error)
+	// VerifyImagePolicy verifies local images against the image signature
+	// policy of the daemon.
+	VerifyImagePolicy func(refOrID string, img *image.Image) error
+	// VerifyImageManifest verifies the manifests of images in registries
+	// against the image signature policy of the daemon.
+	VerifyImageManifest func(ctx context.Context, ref distreference.Named, dgst digest.Digest) error
 }
 
 // Source is the source implementation for accessing container images
@@ -194,6 +200,8 @@ type puller struct {
 	config           []byte
 	platform         ocispec.Platform
 	sm               *session.Manager
+	verifyOnce       sync.Once
+	verifyErr        error
 }
 
 func (p *puller) resolver(g session.Group) remotes.Resolver {
@@ -301,7 +309,49 @@ func (p *puller) resolve(ctx context.Context, g session.Group) error {
 	return err
 }
 
+// verifyPolicy verifies the image against the image signature policy of the
+// daemon, once it is resolved. Images that are resolved in a registry are
+// verified by the digest of their manifest, as pulls do, and local images are
+// verified as for creating containers.
+func (p *puller) verifyPolicy(ctx context.Context) error {
+	p.verifyOnce.Do(func() {
+		if p.desc.Digest != "" {
+			if p.is.VerifyImageManifest == nil {
+				return
+			}
+			ref, err := distreference.ParseNormalizedNamed(p.src.Reference.String())
+			if err != nil {
+				p.verifyErr = err
+				return
+			}
+			p.verifyErr = p.is.VerifyImageManifest(ctx, ref, p.desc.Digest)
+			return
+		}
+		if p.is.VerifyImagePolicy == nil || p.config == nil {
+			return
+		}
+		img, err := p.is.ImageStore.Get(image.ID(digest.FromBytes(p.config)))
+		if err != nil {
+			p.verifyErr = err
+			return
+		}
+		p.verifyErr = p.is.VerifyImagePolicy(p.src.Reference.String(), img)
+	})
+	return p.verifyErr
+}
+
 func (p *puller) CacheKey(ctx context.Context, g session.Group, index int) (string, string, solver.CacheOpts, bool, error) {
+	key, dgst, opts, done, err := p.cacheKey(ctx, g, index)
+	if err != nil {
+		return "", "", nil, false, err
+	}
+	if err := p.verifyPolicy(ctx); err != nil {
+		return "", "", nil, false, err
+	}
+	return key, dgst, opts, done, nil
+}
+
+func (p *puller) cacheKey(ctx context.Context, g session.Group, index int) (string, string, solver.CacheOpts, bool, error) {
 	p.resolveLocal()
 
 	if p.desc.Digest != "" && index == 0 {
@@ -116,16 +116,18 @@ func newController(rt http.RoundTripper, opt Opt) (*control.Controller, error) {
 	}
 
 	src, err := containerimage.NewSource(containerimage.SourceOpt{
-		CacheAccessor:   cm,
-		ContentStore:    store,
-		DownloadManager: dist.DownloadManager,
-		MetadataStore:   dist.V2MetadataService,
-		ImageStore:      dist.ImageStore,
-		ReferenceStore:  dist.ReferenceStore,
-		RegistryHosts:   opt.RegistryHosts,
-		LayerStore:      dist.LayerStore,
-		LeaseManager:    lm,
-		GarbageCollect:  mdb.GarbageCollect,
+		CacheAccessor:       cm,
+		ContentStore:        store,
+		DownloadManager:     dist.DownloadManager,
+		MetadataStore:       dist.V2MetadataService,
+		ImageStore:          dist.ImageStore,
+		ReferenceStore:      dist.ReferenceStore,
+		RegistryHosts:       opt.RegistryHosts,
+		LayerStore:          dist.LayerStore,
+		LeaseManager:        lm,
+		GarbageCollect:      mdb.GarbageCollect,
+		VerifyImagePolicy:   dist.VerifyImagePolicy,
+		VerifyImageManifest: dist.VerifyImageManifest,
 	})
 	if err != nil {
 		return nil, err
@@ -93,6 +93,20 @@ type imageExporterInstance struct {
 	buildInfoAttrs bool
 }
 
+// recordProvenance stores the provenance of the image with the given ID,
+// generated from the build info of the build.
+func (e *imageExporterInstance) recordProvenance(id image.ID, buildInfo []byte) error {
+	stmt, err := buildInfoProvenance(id.Digest(), buildInfo)
+	if err != nil {
+		return err
+	}
+	dt, err := json.Marshal(stmt)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode provenance")
+	}
+	return errors.Wrap(e.opt.ImageStore.SetProvenance(id, dt), "failed to store provenance")
+}
+
 func (e *imageExporterInstance) Name() string {
 	return "exporting to image"
 }
@@ -194,6 +208,11 @@ func (e *imageExporterInstance) Export(ctx context.Context, inp exporter.Source,
 	if err != nil {
 		return nil, configDone(err)
 	}
+	if buildInfo != nil {
+		if err := e.recordProvenance(id, buildInfo); err != nil {
+			return nil, configDone(err)
+		}
+	}
 	_ = configDone(nil)
 
 	if e.opt.ReferenceStore != nil {
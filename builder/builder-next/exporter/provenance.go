@@ -0,0 +1,55 @@
+package containerimage
+
+import (
+	"encoding/json"
+
+	"github.com/docker/docker/image/provenance"
+	binfotypes "github.com/moby/buildkit/util/buildinfo/types"
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+// buildInfoProvenance returns the provenance statement of the image with the
+// given ID from the JSON encoded build info of the build.
+func buildInfoProvenance(imageID digest.Digest, dt []byte) (*provenance.Statement, error) {
+	var bi binfotypes.BuildInfo
+	if err := json.Unmarshal(dt, &bi); err != nil {
+		return nil, errors.Wrap(err, "failed to parse build info")
+	}
+
+	stmt := provenance.New(imageID, provenance.BuildTypeBuildKit)
+
+	params := map[string]string{}
+	for k, v := range bi.Attrs {
+		if v != nil {
+			params[k] = *v
+		}
+	}
+	if bi.Frontend != "" {
+		params["frontend"] = bi.Frontend
+	}
+	stmt.Predicate.Invocation = provenance.Invocation{
+		ConfigSource: provenance.ConfigSource{
+			URI:        params["context"],
+			EntryPoint: params["filename"],
+		},
+		Parameters: params,
+	}
+
+	for _, src := range bi.Sources {
+		m := provenance.Material{URI: src.Ref}
+		switch src.Type {
+		case binfotypes.SourceTypeDockerImage:
+			m.URI = provenance.ImageURI(src.Ref, nil)
+			m.Digest = provenance.NewDigestSet(digest.Digest(src.Pin))
+		case binfotypes.SourceTypeGit:
+			if src.Pin != "" {
+				m.Digest = provenance.DigestSet{"sha1": src.Pin}
+			}
+		case binfotypes.SourceTypeHTTP:
+			m.Digest = provenance.NewDigestSet(digest.Digest(src.Pin))
+		}
+		stmt.Predicate.Materials = append(stmt.Predicate.Materials, m)
+	}
+	return stmt, nil
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/layer"
 	"github.com/docker/docker/pkg/containerfs"
+	"github.com/opencontainers/go-digest"
 )
 
 const (
@@ -51,6 +52,30 @@ type Backend interface {
 	ImageCacheBuilder
 }
 
+// ImageDiffSizer is implemented by backends that can report the size of the
+// layers added to an image by a build step.
+type ImageDiffSizer interface {
+	// ImageDiffSize returns the total size of the layers of the image that
+	// are not part of the parent image.
+	ImageDiffSize(imageID, parentID string) (int64, error)
+}
+
+// ImageProvenanceStore is implemented by backends that can store the
+// provenance of built images.
+type ImageProvenanceStore interface {
+	// SetImageProvenance stores the provenance document of the image.
+	SetImageProvenance(imageID string, provenance []byte) error
+}
+
+// ImageRepoDigester is implemented by backends that can report the digest of
+// the manifest of an image in a registry.
+type ImageRepoDigester interface {
+	// ImageRepoDigest returns the digest of the manifest of the image with the
+	// given ID in the repository of ref, or an empty digest if the image was
+	// not pulled from that repository.
+	ImageRepoDigest(ref, imageID string) digest.Digest
+}
+
 // ImageBackend are the interface methods required from an image component
 type ImageBackend interface {
 	GetImageAndReleasableLayer(ctx context.Context, refOrID string, opts backend.GetImageAndLayerOptions) (Image, ROLayer, error)
@@ -80,8 +105,9 @@ type Result struct {
 
 // ImageCacheBuilder represents a generator for stateful image cache.
 type ImageCacheBuilder interface {
-	// MakeImageCache creates a stateful image cache.
-	MakeImageCache(cacheFrom []string) ImageCache
+	// MakeImageCache creates a stateful image cache. Images in cacheFrom
+	// that are not available locally are looked up in the registry.
+	MakeImageCache(ctx context.Context, cacheFrom []string, opts backend.ImageCacheOptions) ImageCache
 }
 
 // ImageCache abstracts an image cache.
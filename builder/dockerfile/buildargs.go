@@ -3,8 +3,12 @@ package dockerfile // import "github.com/docker/docker/builder/dockerfile"
 import (
 	"fmt"
 	"io"
+	"strconv"
+	"time"
 
+	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/runconfig/opts"
+	"github.com/pkg/errors"
 )
 
 // builtinAllowedBuildArgs is list of built-in allowed build args
@@ -21,6 +25,27 @@ var builtinAllowedBuildArgs = map[string]bool{
 	"no_proxy":    true,
 	"ALL_PROXY":   true,
 	"all_proxy":   true,
+
+	sourceDateEpochArg: true,
+}
+
+// sourceDateEpochArg is the build arg that sets the time, in seconds since
+// the Unix epoch, that is used for the timestamps in the images that are built.
+const sourceDateEpochArg = "SOURCE_DATE_EPOCH"
+
+// parseSourceDateEpoch returns the time set by the SOURCE_DATE_EPOCH build arg
+// in args, or nil if it is not set.
+func parseSourceDateEpoch(args map[string]*string) (*time.Time, error) {
+	v, ok := args[sourceDateEpochArg]
+	if !ok || v == nil || *v == "" {
+		return nil, nil
+	}
+	sec, err := strconv.ParseInt(*v, 10, 64)
+	if err != nil || sec < 0 {
+		return nil, errdefs.InvalidParameter(errors.Errorf("invalid %s build arg %q: must be a non-negative number of seconds", sourceDateEpochArg, *v))
+	}
+	t := time.Unix(sec, 0).UTC()
+	return &t, nil
 }
 
 // BuildArgs manages arguments used by the builder
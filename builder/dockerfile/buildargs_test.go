@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"strings"
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
@@ -102,3 +103,19 @@ func TestIsUnreferencedBuiltin(t *testing.T) {
 	assert.Check(t, buildArgs.IsReferencedOrNotBuiltin("HTTPS_PROXY"))
 	assert.Check(t, !buildArgs.IsReferencedOrNotBuiltin("HTTP_PROXY"))
 }
+
+func TestParseSourceDateEpoch(t *testing.T) {
+	epoch, err := parseSourceDateEpoch(map[string]*string{})
+	assert.NilError(t, err)
+	assert.Check(t, is.Nil(epoch))
+
+	epoch, err = parseSourceDateEpoch(map[string]*string{"SOURCE_DATE_EPOCH": strPtr("1600000000")})
+	assert.NilError(t, err)
+	assert.Assert(t, epoch != nil)
+	assert.Check(t, epoch.Equal(time.Unix(1600000000, 0)))
+
+	for _, v := range []string{"yesterday", "-1", "1.5"} {
+		_, err = parseSourceDateEpoch(map[string]*string{"SOURCE_DATE_EPOCH": strPtr(v)})
+		assert.Check(t, is.ErrorContains(err, "invalid SOURCE_DATE_EPOCH"), v)
+	}
+}
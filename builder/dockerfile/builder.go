@@ -7,6 +7,7 @@ import (
 	"io"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/containerd/containerd/platforms"
 	"github.com/docker/docker/api/types"
@@ -50,14 +51,25 @@ type BuildManager struct {
 	idMapping idtools.IdentityMapping
 	backend   builder.Backend
 	pathCache pathCache // TODO: make this persistent
+	sessions  SessionGetter
+	cacheRoot string
 }
 
-// NewBuildManager creates a BuildManager
-func NewBuildManager(b builder.Backend, identityMapping idtools.IdentityMapping) (*BuildManager, error) {
+// NewBuildManager creates a BuildManager. The client sessions in sessions are
+// used to authenticate when cloning git contexts. Remote contexts that are
+// pinned by digest are cached in cacheRoot.
+func NewBuildManager(b builder.Backend, identityMapping idtools.IdentityMapping, sessions SessionGetter, cacheRoot string) (*BuildManager, error) {
 	bm := &BuildManager{
 		backend:   b,
 		pathCache: &syncmap.Map{},
 		idMapping: identityMapping,
+		sessions:  sessions,
+		cacheRoot: cacheRoot,
+	}
+	if cacheRoot != "" {
+		if err := remotecontext.PruneRemoteCache(cacheRoot); err != nil {
+			logrus.WithError(err).Warn("failed to prune cache of remote build contexts")
+		}
 	}
 	return bm, nil
 }
@@ -69,7 +81,12 @@ func (bm *BuildManager) Build(ctx context.Context, config backend.BuildConfig) (
 		config.Options.Dockerfile = builder.DefaultDockerfileName
 	}
 
-	source, dockerfile, err := remotecontext.Detect(config)
+	gitOpts, releaseGitOpts := bm.gitCloneOptions(ctx, config.Options.SessionID, config.Options.RemoteContext)
+	source, dockerfile, err := remotecontext.Detect(config, remotecontext.DetectOptions{
+		GitOptions:      gitOpts,
+		RemoteCacheRoot: bm.cacheRoot,
+	})
+	releaseGitOpts()
 	if err != nil {
 		return nil, err
 	}
@@ -81,15 +98,21 @@ func (bm *BuildManager) Build(ctx context.Context, config backend.BuildConfig) (
 		}
 	}()
 
+	sourceDateEpoch, err := parseSourceDateEpoch(config.Options.BuildArgs)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	builderOptions := builderOptions{
-		Options:        config.Options,
-		ProgressWriter: config.ProgressWriter,
-		Backend:        bm.backend,
-		PathCache:      bm.pathCache,
-		IDMapping:      bm.idMapping,
+		Options:         config.Options,
+		ProgressWriter:  config.ProgressWriter,
+		Backend:         bm.backend,
+		PathCache:       bm.pathCache,
+		IDMapping:       bm.idMapping,
+		SourceDateEpoch: sourceDateEpoch,
 	}
 	b, err := newBuilder(ctx, builderOptions)
 	if err != nil {
@@ -105,6 +128,9 @@ type builderOptions struct {
 	ProgressWriter backend.ProgressWriter
 	PathCache      pathCache
 	IDMapping      idtools.IdentityMapping
+	// SourceDateEpoch, if set, is the time that is used as creation time of
+	// the images, and to which the timestamps of files in layers are clamped.
+	SourceDateEpoch *time.Time
 }
 
 // Builder is a Dockerfile builder
@@ -127,6 +153,10 @@ type Builder struct {
 	containerManager *containerManager
 	imageProber      ImageProber
 	platform         *specs.Platform
+	sourceDateEpoch  *time.Time
+	// committedImages are the IDs of the images that were committed by the
+	// build, as opposed to base images and images found in the build cache.
+	committedImages map[string]struct{}
 }
 
 // newBuilder creates a new Dockerfile builder from an optional dockerfile and a Options.
@@ -147,8 +177,9 @@ func newBuilder(clientCtx context.Context, options builderOptions) (*Builder, er
 		idMapping:        options.IDMapping,
 		imageSources:     newImageSources(clientCtx, options),
 		pathCache:        options.PathCache,
-		imageProber:      newImageProber(options.Backend, config.CacheFrom, config.NoCache),
 		containerManager: newContainerManager(options.Backend),
+		sourceDateEpoch:  options.SourceDateEpoch,
+		committedImages:  make(map[string]struct{}),
 	}
 
 	// same as in Builder.Build in builder/builder-next/builder.go
@@ -161,6 +192,13 @@ func newBuilder(clientCtx context.Context, options builderOptions) (*Builder, er
 		b.platform = &sp
 	}
 
+	b.imageProber = newImageProber(clientCtx, options.Backend, config.CacheFrom, config.NoCache, backend.ImageCacheOptions{
+		AuthConfig:      config.AuthConfigs,
+		Output:          options.ProgressWriter.Output,
+		Platform:        b.platform,
+		SourceDateEpoch: options.SourceDateEpoch,
+	})
+
 	return b, nil
 }
 
@@ -181,8 +219,9 @@ func buildLabelOptions(labels map[string]string, stages []instructions.Stage) {
 
 // Build runs the Dockerfile builder by parsing the Dockerfile and executing
 // the instructions from the file.
-func (b *Builder) build(source builder.Source, dockerfile *parser.Result) (*builder.Result, error) {
+func (b *Builder) build(source builder.Source, dockerfile *remotecontext.Dockerfile) (*builder.Result, error) {
 	defer b.imageSources.Unmount()
+	started := time.Now()
 
 	stages, metaArgs, err := instructions.Parse(dockerfile.AST)
 	if err != nil {
@@ -213,6 +252,13 @@ func (b *Builder) build(source builder.Source, dockerfile *parser.Result) (*buil
 		buildsFailed.WithValues(metricsDockerfileEmptyError).Inc()
 		return nil, errors.New("No image was generated. Is your Dockerfile empty?")
 	}
+	// The provenance of base images and cached images is not known, as they
+	// were not built by this build.
+	if _, ok := b.committedImages[dispatchState.imageID]; ok {
+		if err := b.recordProvenance(dispatchState.imageID, dockerfile.Digest, started); err != nil {
+			return nil, err
+		}
+	}
 	return &builder.Result{ImageID: dispatchState.imageID, FromImage: dispatchState.baseImage}, nil
 }
 
@@ -254,10 +300,15 @@ func (b *Builder) dispatchDockerfileWithCancellation(parseResult []instructions.
 	}
 	shlex := shell.NewLex(escapeToken)
 	for i := range metaArgs {
+		start := time.Now()
 		currentCommandIndex = printCommand(b.Stdout, currentCommandIndex, totalCommands, &metaArgs[i])
 
 		err := processMetaArg(metaArgs[i], shlex, buildArgs)
 		if err != nil {
+			emitBuildStep(b, currentCommandIndex-1, totalCommands, &metaArgs[i], start, types.BuildStep{Error: err.Error()})
+			return nil, err
+		}
+		if err := emitBuildStep(b, currentCommandIndex-1, totalCommands, &metaArgs[i], start, types.BuildStep{}); err != nil {
 			return nil, err
 		}
 	}
@@ -271,12 +322,17 @@ func (b *Builder) dispatchDockerfileWithCancellation(parseResult []instructions.
 		}
 		dispatchRequest = newDispatchRequest(b, escapeToken, source, buildArgs, stagesResults)
 
+		start := time.Now()
 		currentCommandIndex = printCommand(b.Stdout, currentCommandIndex, totalCommands, stage.SourceCode)
 		if err := initializeStage(dispatchRequest, &stage); err != nil {
+			emitBuildStep(b, currentCommandIndex-1, totalCommands, stage.SourceCode, start, types.BuildStep{Error: err.Error()})
 			return nil, err
 		}
 		dispatchRequest.state.updateRunConfig()
 		fmt.Fprintf(b.Stdout, " ---> %s\n", stringid.TruncateID(dispatchRequest.state.imageID))
+		if err := emitBuildStep(b, currentCommandIndex-1, totalCommands, stage.SourceCode, start, types.BuildStep{ImageID: dispatchRequest.state.imageID}); err != nil {
+			return nil, err
+		}
 		for _, cmd := range stage.Commands {
 			select {
 			case <-b.clientCtx.Done():
@@ -290,7 +346,7 @@ func (b *Builder) dispatchDockerfileWithCancellation(parseResult []instructions.
 
 			currentCommandIndex = printCommand(b.Stdout, currentCommandIndex, totalCommands, cmd)
 
-			if err := dispatch(dispatchRequest, cmd); err != nil {
+			if err := dispatchStep(dispatchRequest, currentCommandIndex-1, totalCommands, cmd); err != nil {
 				return nil, err
 			}
 			dispatchRequest.state.updateRunConfig()
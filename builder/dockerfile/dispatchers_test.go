@@ -37,8 +37,9 @@ func newBuilderWithMockBackend() *Builder {
 			Options: opts,
 			Backend: mockBackend,
 		}),
-		imageProber:      newImageProber(mockBackend, nil, false),
+		imageProber:      newImageProber(ctx, mockBackend, nil, false, backend.ImageCacheOptions{}),
 		containerManager: newContainerManager(mockBackend),
+		committedImages:  make(map[string]struct{}),
 	}
 	return b
 }
@@ -463,7 +464,7 @@ func TestRunWithBuildArgs(t *testing.T) {
 	mockBackend.makeImageCacheFunc = func(_ []string) builder.ImageCache {
 		return imageCache
 	}
-	b.imageProber = newImageProber(mockBackend, nil, false)
+	b.imageProber = newImageProber(context.Background(), mockBackend, nil, false, backend.ImageCacheOptions{})
 	mockBackend.getImageFunc = func(_ string) (builder.Image, builder.ROLayer, error) {
 		return &mockImage{
 			id:     "abcdef",
@@ -529,7 +530,7 @@ func TestRunIgnoresHealthcheck(t *testing.T) {
 	mockBackend.makeImageCacheFunc = func(_ []string) builder.ImageCache {
 		return imageCache
 	}
-	b.imageProber = newImageProber(mockBackend, nil, false)
+	b.imageProber = newImageProber(context.Background(), mockBackend, nil, false, backend.ImageCacheOptions{})
 	mockBackend.getImageFunc = func(_ string) (builder.Image, builder.ROLayer, error) {
 		return &mockImage{
 			id:     "abcdef",
@@ -20,10 +20,13 @@
 package dockerfile // import "github.com/docker/docker/builder/dockerfile"
 
 import (
+	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/builder"
 	"github.com/docker/docker/errdefs"
@@ -102,6 +105,46 @@ func dispatch(d dispatchRequest, cmd instructions.Command) (err error) {
 	return errors.Errorf("unsupported command type: %v", reflect.TypeOf(cmd))
 }
 
+// buildStepAuxID is the ID of the aux messages that report the result of each
+// build step.
+const buildStepAuxID = "moby.build.step"
+
+// dispatchStep dispatches cmd as step n of total. The result of the step is
+// reported as a types.BuildStep aux message, in addition to the human
+// readable output of the step.
+func dispatchStep(d dispatchRequest, n, total int, cmd instructions.Command) error {
+	start := time.Now()
+	parentID := d.state.imageID
+	d.state.cacheHit = false
+
+	err := dispatch(d, cmd)
+	if err != nil {
+		emitBuildStep(d.builder, n, total, cmd, start, types.BuildStep{Error: err.Error()})
+		return err
+	}
+	step := types.BuildStep{
+		Cached:  d.state.cacheHit,
+		ImageID: d.state.imageID,
+	}
+	if d.state.imageID != parentID {
+		step.Size = d.builder.imageDiffSize(d.state.imageID, parentID)
+	}
+	return emitBuildStep(d.builder, n, total, cmd, start, step)
+}
+
+// emitBuildStep completes step with the position, instruction, and duration
+// of the step, and sends it as an aux message.
+func emitBuildStep(b *Builder, n, total int, cmd interface{}, start time.Time, step types.BuildStep) error {
+	if b.Aux == nil {
+		return nil
+	}
+	step.Step = n
+	step.Total = total
+	step.Instruction = fmt.Sprint(cmd)
+	step.Duration = time.Since(start)
+	return b.Aux.Emit(buildStepAuxID, step)
+}
+
 // dispatchState is a data object which is modified by dispatchers
 type dispatchState struct {
 	runConfig       *container.Config
@@ -112,6 +155,7 @@ type dispatchState struct {
 	stageName       string
 	buildArgs       *BuildArgs
 	operatingSystem string
+	cacheHit        bool // whether the image of the current step was found in the build cache
 }
 
 func newDispatchState(baseArgs *BuildArgs) *dispatchState {
@@ -1,14 +1,21 @@
 package dockerfile // import "github.com/docker/docker/builder/dockerfile"
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
 	"runtime"
+	"strings"
 	"testing"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/builder/remotecontext"
 	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/docker/pkg/reexec"
+	"github.com/docker/docker/pkg/streamformatter"
 	"github.com/moby/buildkit/frontend/dockerfile/instructions"
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
 	"gotest.tools/v3/skip"
@@ -134,3 +141,40 @@ func TestDispatch(t *testing.T) {
 		})
 	}
 }
+
+func TestDispatchStepEmitsBuildStep(t *testing.T) {
+	b := newBuilderWithMockBackend()
+	aux := new(bytes.Buffer)
+	b.Aux = &streamformatter.AuxFormatter{Writer: aux}
+	sb := newDispatchRequest(b, '\\', nil, NewBuildArgs(make(map[string]*string)), newStagesBuildResults())
+	sb.state.imageID = "sha256:parent"
+
+	result, err := parser.Parse(strings.NewReader("ENV var1=val1\nSTOPSIGNAL bogus\n"))
+	assert.NilError(t, err)
+	for i, node := range result.AST.Children {
+		cmd, err := instructions.ParseCommand(node)
+		assert.NilError(t, err)
+		err = dispatchStep(sb, i+2, 3, cmd)
+		if i == 0 {
+			assert.NilError(t, err)
+		} else {
+			assert.Check(t, is.ErrorContains(err, "bogus"))
+		}
+	}
+
+	var steps []types.BuildStep
+	dec := json.NewDecoder(aux)
+	for dec.More() {
+		var msg jsonmessage.JSONMessage
+		assert.NilError(t, dec.Decode(&msg))
+		assert.Check(t, is.Equal(msg.ID, buildStepAuxID))
+		var step types.BuildStep
+		assert.NilError(t, json.Unmarshal(*msg.Aux, &step))
+		step.Duration = 0
+		steps = append(steps, step)
+	}
+	assert.Check(t, is.DeepEqual(steps, []types.BuildStep{
+		{Step: 2, Total: 3, Instruction: "ENV var1=val1", ImageID: "sha256:parent"},
+		{Step: 3, Total: 3, Instruction: "STOPSIGNAL bogus", Error: "invalid signal: bogus"},
+	}))
+}
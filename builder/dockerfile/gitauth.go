@@ -0,0 +1,128 @@
+package dockerfile // import "github.com/docker/docker/builder/dockerfile"
+
+import (
+	"context"
+	"encoding/base64"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/docker/docker/builder/remotecontext/git"
+	"github.com/docker/docker/builder/remotecontext/urlutil"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/session/secrets"
+	"github.com/moby/buildkit/session/sshforward"
+	"github.com/sirupsen/logrus"
+)
+
+// SessionGetter returns the client session with the given ID.
+type SessionGetter interface {
+	Get(ctx context.Context, id string, noWait bool) (session.Caller, error)
+}
+
+const (
+	// gitAuthTokenSecret is the ID of the session secret that holds the token
+	// that is used to authenticate with the host of a git context. The name of
+	// the host can be appended to scope the token to that host.
+	gitAuthTokenSecret = "GIT_AUTH_TOKEN"
+
+	// gitKnownHostsSecret is the ID of the session secret that holds the
+	// known_hosts file that the keys of the SSH hosts of a git context are
+	// verified against, instead of the known_hosts files of the daemon.
+	gitKnownHostsSecret = "GIT_SSH_KNOWN_HOSTS"
+
+	// sessionTimeout is the time to wait for the client session to connect.
+	sessionTimeout = 5 * time.Second
+)
+
+// gitCloneOptions returns the options to clone the git context at remoteURL
+// with the credentials and SSH agent of the client session with the given ID.
+// The returned function releases the resources used by the options, and must
+// be called after the context is cloned.
+func (bm *BuildManager) gitCloneOptions(ctx context.Context, sessionID, remoteURL string) ([]git.CloneOption, func()) {
+	release := func() {}
+	if bm.sessions == nil || sessionID == "" || !urlutil.IsGitURL(remoteURL) {
+		return nil, release
+	}
+
+	sessionCtx, cancel := context.WithTimeout(ctx, sessionTimeout)
+	defer cancel()
+	caller, err := bm.sessions.Get(sessionCtx, sessionID, false)
+	if err != nil {
+		logrus.WithError(err).Debug("[BUILDER] client session not available for git context")
+		return nil, release
+	}
+
+	var opts []git.CloneOption
+	u, err := url.Parse(remoteURL)
+	if err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		if header := gitAuthHeader(ctx, caller, u.Host); header != "" {
+			opts = append(opts, git.WithAuthHeader(header))
+		}
+		return opts, release
+	}
+
+	if err := sshforward.CheckSSHID(ctx, caller, sshforward.DefaultID); err != nil {
+		logrus.WithError(err).Debug("[BUILDER] SSH agent not forwarded by client session")
+		return opts, release
+	}
+	sock, closer, err := sshforward.MountSSHSocket(ctx, caller, sshforward.SocketOpt{ID: sshforward.DefaultID, Mode: 0600})
+	if err != nil {
+		logrus.WithError(err).Warn("[BUILDER] failed to forward SSH agent of client session")
+		return opts, release
+	}
+	opts = append(opts, git.WithSSHAuthSock(sock))
+	knownHosts, err := writeKnownHosts(ctx, caller)
+	if err != nil {
+		logrus.WithError(err).Warn("[BUILDER] failed to write known hosts of client session")
+	}
+	if knownHosts != "" {
+		opts = append(opts, git.WithKnownHosts(knownHosts))
+	}
+	release = func() {
+		if err := closer(); err != nil {
+			logrus.WithError(err).Debug("[BUILDER] failed to close SSH agent socket")
+		}
+		if knownHosts != "" {
+			_ = os.Remove(knownHosts)
+		}
+	}
+	return opts, release
+}
+
+// writeKnownHosts writes the known_hosts file of the client session secrets
+// to a temporary file, and returns its path, or an empty string if the client
+// session has no known_hosts file.
+func writeKnownHosts(ctx context.Context, caller session.Caller) (string, error) {
+	content, err := secrets.GetSecret(ctx, caller, gitKnownHostsSecret)
+	if err != nil || len(content) == 0 {
+		return "", nil
+	}
+	f, err := os.CreateTemp("", "docker-build-git-known-hosts")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.Write(content); err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// gitAuthHeader returns the Authorization header for host from the token in
+// the client session secrets. The registry credentials of the client are never
+// used, as they are not meant for the git host.
+func gitAuthHeader(ctx context.Context, caller session.Caller, host string) string {
+	for _, id := range []string{gitAuthTokenSecret + "." + host, gitAuthTokenSecret} {
+		token, err := secrets.GetSecret(ctx, caller, id)
+		if err == nil && len(token) > 0 {
+			return basicAuthHeader("x-access-token", string(token))
+		}
+	}
+	return ""
+}
+
+func basicAuthHeader(username, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+}
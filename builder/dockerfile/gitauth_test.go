@@ -0,0 +1,44 @@
+package dockerfile // import "github.com/docker/docker/builder/dockerfile"
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/moby/buildkit/session"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+type noSessions struct {
+	calls int
+}
+
+func (s *noSessions) Get(context.Context, string, bool) (session.Caller, error) {
+	s.calls++
+	return nil, errors.New("no active session")
+}
+
+func TestGitCloneOptionsWithoutSession(t *testing.T) {
+	sessions := &noSessions{}
+	bm := &BuildManager{sessions: sessions}
+
+	opts, release := bm.gitCloneOptions(context.Background(), "", "https://github.com/moby/moby.git")
+	release()
+	assert.Check(t, is.Len(opts, 0))
+	assert.Check(t, is.Equal(sessions.calls, 0))
+
+	opts, release = bm.gitCloneOptions(context.Background(), "session", "https://example.com/context.tar")
+	release()
+	assert.Check(t, is.Len(opts, 0))
+	assert.Check(t, is.Equal(sessions.calls, 0))
+
+	opts, release = bm.gitCloneOptions(context.Background(), "session", "https://github.com/moby/moby.git")
+	release()
+	assert.Check(t, is.Len(opts, 0))
+	assert.Check(t, is.Equal(sessions.calls, 1))
+}
+
+func TestBasicAuthHeader(t *testing.T) {
+	assert.Check(t, is.Equal(basicAuthHeader("x-access-token", "secret"), "Basic eC1hY2Nlc3MtdG9rZW46c2VjcmV0"))
+}
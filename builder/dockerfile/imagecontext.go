@@ -8,6 +8,8 @@ import (
 	"github.com/docker/docker/api/types/backend"
 	"github.com/docker/docker/builder"
 	dockerimage "github.com/docker/docker/image"
+	"github.com/docker/docker/image/provenance"
+	"github.com/opencontainers/go-digest"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -21,6 +23,11 @@ type imageSources struct {
 	byImageID map[string]*imageMount
 	mounts    []*imageMount
 	getImage  getAndMountFunc
+	// repoDigest returns the digest of the manifest of an image in the
+	// repository of a reference, if the backend can report it.
+	repoDigest func(ref, imageID string) digest.Digest
+	// materials are the external images that were used by the build.
+	materials []provenance.Material
 }
 
 func newImageSources(ctx context.Context, options builderOptions) *imageSources {
@@ -34,17 +41,22 @@ func newImageSources(ctx context.Context, options builderOptions) *imageSources
 			}
 		}
 		return options.Backend.GetImageAndReleasableLayer(ctx, idOrRef, backend.GetImageAndLayerOptions{
-			PullOption: pullOption,
-			AuthConfig: options.Options.AuthConfigs,
-			Output:     options.ProgressWriter.Output,
-			Platform:   platform,
+			PullOption:      pullOption,
+			AuthConfig:      options.Options.AuthConfigs,
+			Output:          options.ProgressWriter.Output,
+			Platform:        platform,
+			SourceDateEpoch: options.SourceDateEpoch,
 		})
 	}
 
-	return &imageSources{
+	m := &imageSources{
 		byImageID: make(map[string]*imageMount),
 		getImage:  getAndMount,
 	}
+	if digester, ok := options.Backend.(builder.ImageRepoDigester); ok {
+		m.repoDigest = digester.ImageRepoDigest
+	}
+	return m
 }
 
 func (m *imageSources) Get(idOrRef string, localOnly bool, platform *specs.Platform) (*imageMount, error) {
@@ -56,6 +68,17 @@ func (m *imageSources) Get(idOrRef string, localOnly bool, platform *specs.Platf
 	if err != nil {
 		return nil, err
 	}
+	// Base images are only recorded as materials if the digest of their
+	// manifest is known, as the ID of the image cannot be resolved in the
+	// registry.
+	if !localOnly && image != nil && m.repoDigest != nil {
+		if dgst := m.repoDigest(idOrRef, image.ImageID()); dgst != "" {
+			m.materials = append(m.materials, provenance.Material{
+				URI:    provenance.ImageURI(idOrRef, platform),
+				Digest: provenance.NewDigestSet(dgst),
+			})
+		}
+	}
 	im := newImageMount(image, layer)
 	m.Add(im, platform)
 	return im, nil
@@ -8,6 +8,8 @@ import (
 	"github.com/containerd/containerd/platforms"
 	"github.com/docker/docker/builder"
 	"github.com/docker/docker/image"
+	"github.com/docker/docker/image/provenance"
+	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"gotest.tools/v3/assert"
 )
@@ -104,3 +106,22 @@ func TestImageSourceGetAddsToMounts(t *testing.T) {
 	assert.NilError(t, err)
 	assert.Equal(t, len(is.mounts), 1)
 }
+
+func TestImageSourceGetRecordsMaterials(t *testing.T) {
+	is := getMockImageSource(&mockImage{id: "sha256:abc"}, nil, nil)
+	is.repoDigest = func(ref, imageID string) digest.Digest {
+		if ref == "busybox" {
+			return "sha256:def"
+		}
+		return ""
+	}
+
+	_, err := is.Get("busybox", false, nil)
+	assert.NilError(t, err)
+	// Images without a known manifest digest are not recorded.
+	_, err = is.Get("local-only", false, nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, is.materials, []provenance.Material{
+		{URI: "pkg:docker/busybox@latest", Digest: provenance.NewDigestSet("sha256:def")},
+	})
+}
@@ -100,11 +100,16 @@ func (b *Builder) commitContainer(dispatchState *dispatchState, id string, conta
 		Config:          copyRunConfig(dispatchState.runConfig),
 		ContainerConfig: containerConfig,
 		ContainerID:     id,
+		SourceDateEpoch: b.sourceDateEpoch,
 	}
 
 	imageID, err := b.docker.CommitBuildStep(commitCfg)
 	dispatchState.imageID = string(imageID)
-	return err
+	if err != nil {
+		return err
+	}
+	b.committedImages[dispatchState.imageID] = struct{}{}
+	return nil
 }
 
 func (b *Builder) exportImage(state *dispatchState, layer builder.RWLayer, parent builder.Image, runConfig *container.Config) error {
@@ -128,12 +133,16 @@ func (b *Builder) exportImage(state *dispatchState, layer builder.RWLayer, paren
 	// if there is an error before we can add the full mount with image
 	b.imageSources.Add(newImageMount(nil, newLayer), platform)
 
-	newImage := image.NewChildImage(parentImage, image.ChildConfig{
+	childConfig := image.ChildConfig{
 		Author:          state.maintainer,
 		ContainerConfig: runConfig,
 		DiffID:          newLayer.DiffID(),
 		Config:          copyRunConfig(state.runConfig),
-	}, parentImage.OS)
+	}
+	if b.sourceDateEpoch != nil {
+		childConfig.Created = *b.sourceDateEpoch
+	}
+	newImage := image.NewChildImage(parentImage, childConfig, parentImage.OS)
 
 	// TODO: it seems strange to marshal this here instead of just passing in the
 	// image struct
@@ -148,6 +157,7 @@ func (b *Builder) exportImage(state *dispatchState, layer builder.RWLayer, paren
 	}
 
 	state.imageID = exportedImage.ImageID()
+	b.committedImages[state.imageID] = struct{}{}
 	b.imageSources.Add(newImageMount(exportedImage, newLayer), platform)
 	return nil
 }
@@ -371,9 +381,25 @@ func (b *Builder) probeCache(dispatchState *dispatchState, runConfig *container.
 	fmt.Fprint(b.Stdout, " ---> Using cache\n")
 
 	dispatchState.imageID = cachedID
+	dispatchState.cacheHit = true
 	return true, nil
 }
 
+// imageDiffSize returns the size of the layers added by imageID to parentID,
+// or 0 if the backend cannot report it.
+func (b *Builder) imageDiffSize(imageID, parentID string) int64 {
+	sizer, ok := b.docker.(builder.ImageDiffSizer)
+	if !ok || imageID == "" {
+		return 0
+	}
+	size, err := sizer.ImageDiffSize(imageID, parentID)
+	if err != nil {
+		logrus.WithError(err).Debugf("failed to get size of layers added by image %s", imageID)
+		return 0
+	}
+	return size
+}
+
 var defaultLogConfig = container.LogConfig{Type: "none"}
 
 func (b *Builder) probeAndCreate(dispatchState *dispatchState, runConfig *container.Config) (string, error) {
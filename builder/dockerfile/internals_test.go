@@ -88,7 +88,7 @@ func readAndCheckDockerfile(t *testing.T, testName, contextDir, dockerfilePath,
 		Options: &types.ImageBuildOptions{Dockerfile: dockerfilePath},
 		Source:  tarStream,
 	}
-	_, _, err = remotecontext.Detect(config)
+	_, _, err = remotecontext.Detect(config, remotecontext.DetectOptions{})
 	assert.Check(t, is.ErrorContains(err, expectedError))
 }
 
@@ -216,8 +216,9 @@ func TestExportImage(t *testing.T) {
 	runConfig := &container.Config{}
 
 	b := &Builder{
-		imageSources: getMockImageSource(nil, nil, nil),
-		docker:       getMockBuildBackend(),
+		imageSources:    getMockImageSource(nil, nil, nil),
+		docker:          getMockBuildBackend(),
+		committedImages: make(map[string]struct{}),
 	}
 	err := b.exportImage(ds, layer, parentImage, runConfig)
 	assert.NilError(t, err)
@@ -74,7 +74,7 @@ func (m *MockBackend) GetImageAndReleasableLayer(ctx context.Context, refOrID st
 	return &mockImage{id: "theid"}, &mockLayer{}, nil
 }
 
-func (m *MockBackend) MakeImageCache(cacheFrom []string) builder.ImageCache {
+func (m *MockBackend) MakeImageCache(ctx context.Context, cacheFrom []string, opts backend.ImageCacheOptions) builder.ImageCache {
 	if m.makeImageCacheFunc != nil {
 		return m.makeImageCacheFunc(cacheFrom)
 	}
@@ -0,0 +1,74 @@
+package dockerfile // import "github.com/docker/docker/builder/dockerfile"
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/docker/docker/builder"
+	"github.com/docker/docker/image/provenance"
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+// recordProvenance stores the provenance of the image with the given ID, if
+// the backend supports it.
+func (b *Builder) recordProvenance(imageID string, dockerfileDigest digest.Digest, started time.Time) error {
+	store, ok := b.docker.(builder.ImageProvenanceStore)
+	if !ok {
+		return nil
+	}
+	stmt := b.provenance(digest.Digest(imageID), dockerfileDigest, started, time.Now())
+	dt, err := json.Marshal(stmt)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode provenance")
+	}
+	return errors.Wrap(store.SetImageProvenance(imageID, dt), "failed to store provenance")
+}
+
+// provenance returns the provenance statement of the image with the given ID.
+func (b *Builder) provenance(imageID, dockerfileDigest digest.Digest, started, finished time.Time) *provenance.Statement {
+	stmt := provenance.New(imageID, provenance.BuildTypeClassic)
+
+	params := map[string]string{}
+	for k, v := range b.options.BuildArgs {
+		// Proxy settings may contain credentials, and are excluded from
+		// the image history for the same reason.
+		if v == nil || (builtinAllowedBuildArgs[k] && k != sourceDateEpochArg) {
+			continue
+		}
+		params["build-arg:"+k] = *v
+	}
+	for k, v := range b.options.Labels {
+		params["label:"+k] = v
+	}
+	if b.options.Target != "" {
+		params["target"] = b.options.Target
+	}
+	if b.options.Platform != "" {
+		params["platform"] = b.options.Platform
+	}
+	if b.options.RemoteContext != "" {
+		params["context"] = b.options.RemoteContext
+	}
+	params["filename"] = b.options.Dockerfile
+
+	stmt.Predicate.Invocation = provenance.Invocation{
+		ConfigSource: provenance.ConfigSource{
+			URI:        b.options.RemoteContext,
+			EntryPoint: b.options.Dockerfile,
+		},
+		Parameters: params,
+	}
+	if dockerfileDigest != "" {
+		stmt.Predicate.Invocation.ConfigSource.Digest = provenance.NewDigestSet(dockerfileDigest)
+	}
+	stmt.Predicate.Metadata = &provenance.Metadata{
+		BuildStartedOn:  &started,
+		BuildFinishedOn: &finished,
+	}
+	stmt.Predicate.Materials = b.imageSources.materials
+	if b.options.RemoteContext != "" {
+		stmt.Predicate.Materials = append(stmt.Predicate.Materials, provenance.Material{URI: b.options.RemoteContext})
+	}
+	return stmt
+}
@@ -11,11 +11,13 @@ import (
 	"github.com/containerd/continuity/driver"
 	"github.com/docker/docker/api/types/backend"
 	"github.com/docker/docker/builder"
+	"github.com/docker/docker/builder/remotecontext/git"
 	"github.com/docker/docker/builder/remotecontext/urlutil"
 	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/pkg/fileutils"
 	"github.com/moby/buildkit/frontend/dockerfile/dockerignore"
 	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/opencontainers/go-digest"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -23,9 +25,25 @@ import (
 // ClientSessionRemote is identifier for client-session context transport
 const ClientSessionRemote = "client-session"
 
+// DetectOptions are the options used to fetch a remote context.
+type DetectOptions struct {
+	// GitOptions are used to clone the context if it is a git repository.
+	GitOptions []git.CloneOption
+	// RemoteCacheRoot is the directory in which remote contexts that are
+	// pinned by digest are cached. They are not cached if it is empty.
+	RemoteCacheRoot string
+}
+
+// Dockerfile is a parsed Dockerfile.
+type Dockerfile struct {
+	*parser.Result
+	// Digest is the digest of the content of the Dockerfile.
+	Digest digest.Digest
+}
+
 // Detect returns a context and dockerfile from remote location or local
 // archive.
-func Detect(config backend.BuildConfig) (remote builder.Source, dockerfile *parser.Result, err error) {
+func Detect(config backend.BuildConfig, opts DetectOptions) (remote builder.Source, dockerfile *Dockerfile, err error) {
 	remoteURL := config.Options.RemoteContext
 	dockerfilePath := config.Options.Dockerfile
 
@@ -35,16 +53,16 @@ func Detect(config backend.BuildConfig) (remote builder.Source, dockerfile *pars
 	case remoteURL == ClientSessionRemote:
 		return nil, nil, errdefs.InvalidParameter(errors.New("experimental session with v1 builder is no longer supported, use builder version v2 (BuildKit) instead"))
 	case urlutil.IsGitURL(remoteURL):
-		remote, dockerfile, err = newGitRemote(remoteURL, dockerfilePath)
+		remote, dockerfile, err = newGitRemote(remoteURL, dockerfilePath, opts.GitOptions...)
 	case urlutil.IsURL(remoteURL):
-		remote, dockerfile, err = newURLRemote(remoteURL, dockerfilePath, config.ProgressWriter.ProgressReaderFunc)
+		remote, dockerfile, err = newURLRemote(remoteURL, dockerfilePath, config.ProgressWriter.ProgressReaderFunc, opts.RemoteCacheRoot)
 	default:
 		err = fmt.Errorf("remoteURL (%s) could not be recognized as URL", remoteURL)
 	}
 	return
 }
 
-func newArchiveRemote(rc io.ReadCloser, dockerfilePath string) (builder.Source, *parser.Result, error) {
+func newArchiveRemote(rc io.ReadCloser, dockerfilePath string) (builder.Source, *Dockerfile, error) {
 	defer rc.Close()
 	c, err := FromArchive(rc)
 	if err != nil {
@@ -54,7 +72,7 @@ func newArchiveRemote(rc io.ReadCloser, dockerfilePath string) (builder.Source,
 	return withDockerfileFromContext(c.(modifiableContext), dockerfilePath)
 }
 
-func withDockerfileFromContext(c modifiableContext, dockerfilePath string) (builder.Source, *parser.Result, error) {
+func withDockerfileFromContext(c modifiableContext, dockerfilePath string) (builder.Source, *Dockerfile, error) {
 	df, err := openAt(c, dockerfilePath)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -85,16 +103,16 @@ func withDockerfileFromContext(c modifiableContext, dockerfilePath string) (buil
 	return c, res, nil
 }
 
-func newGitRemote(gitURL string, dockerfilePath string) (builder.Source, *parser.Result, error) {
-	c, err := MakeGitContext(gitURL) // TODO: change this to NewLazySource
+func newGitRemote(gitURL string, dockerfilePath string, opts ...git.CloneOption) (builder.Source, *Dockerfile, error) {
+	c, err := MakeGitContext(gitURL, opts...) // TODO: change this to NewLazySource
 	if err != nil {
 		return nil, nil, err
 	}
 	return withDockerfileFromContext(c.(modifiableContext), dockerfilePath)
 }
 
-func newURLRemote(url string, dockerfilePath string, progressReader func(in io.ReadCloser) io.ReadCloser) (builder.Source, *parser.Result, error) {
-	contentType, content, err := downloadRemote(url)
+func newURLRemote(url string, dockerfilePath string, progressReader func(in io.ReadCloser) io.ReadCloser, cacheRoot string) (builder.Source, *Dockerfile, error) {
+	contentType, content, err := downloadRemote(url, cacheRoot)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -102,8 +120,12 @@ func newURLRemote(url string, dockerfilePath string, progressReader func(in io.R
 
 	switch contentType {
 	case mimeTypes.TextPlain:
-		res, err := parser.Parse(progressReader(content))
-		return nil, res, errdefs.InvalidParameter(err)
+		digester := digest.Canonical.Digester()
+		res, err := parser.Parse(io.TeeReader(progressReader(content), digester.Hash()))
+		if err != nil {
+			return nil, nil, errdefs.InvalidParameter(err)
+		}
+		return nil, &Dockerfile{Result: res, Digest: digester.Digest()}, nil
 	default:
 		source, err := FromArchive(progressReader(content))
 		if err != nil {
@@ -139,8 +161,9 @@ func removeDockerfile(c modifiableContext, filesToRemove ...string) error {
 	return nil
 }
 
-func readAndParseDockerfile(name string, rc io.Reader) (*parser.Result, error) {
-	br := bufio.NewReader(rc)
+func readAndParseDockerfile(name string, rc io.Reader) (*Dockerfile, error) {
+	digester := digest.Canonical.Digester()
+	br := bufio.NewReader(io.TeeReader(rc, digester.Hash()))
 	if _, err := br.Peek(1); err != nil {
 		if err == io.EOF {
 			return nil, errdefs.InvalidParameter(errors.Errorf("the Dockerfile (%s) cannot be empty", name))
@@ -153,7 +176,7 @@ func readAndParseDockerfile(name string, rc io.Reader) (*parser.Result, error) {
 		return nil, errdefs.InvalidParameter(errors.Wrapf(err, "failed to parse %s", name))
 	}
 
-	return dockerfile, nil
+	return &Dockerfile{Result: dockerfile, Digest: digester.Digest()}, nil
 }
 
 func openAt(remote builder.Source, path string) (driver.File, error) {
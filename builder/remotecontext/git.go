@@ -10,8 +10,8 @@ import (
 )
 
 // MakeGitContext returns a Context from gitURL that is cloned in a temporary directory.
-func MakeGitContext(gitURL string) (builder.Source, error) {
-	root, err := git.Clone(gitURL)
+func MakeGitContext(gitURL string, opts ...git.CloneOption) (builder.Source, error) {
+	root, err := git.Clone(gitURL, opts...)
 	if err != nil {
 		return nil, err
 	}
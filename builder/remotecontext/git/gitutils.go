@@ -16,17 +16,54 @@ type gitRepo struct {
 	remote string
 	ref    string
 	subdir string
+
+	authHeader  string
+	sshAuthSock string
+	knownHosts  string
+}
+
+// CloneOption changes the behaviour of Clone().
+type CloneOption func(*gitRepo)
+
+// WithAuthHeader sets the value of the Authorization header that is sent to
+// the host of the remote, and of any submodules on the same host, when they
+// are fetched over HTTP(S).
+func WithAuthHeader(header string) CloneOption {
+	return func(repo *gitRepo) {
+		repo.authHeader = header
+	}
+}
+
+// WithSSHAuthSock sets the socket of the SSH agent that is used to
+// authenticate when fetching over SSH.
+func WithSSHAuthSock(sock string) CloneOption {
+	return func(repo *gitRepo) {
+		repo.sshAuthSock = sock
+	}
+}
+
+// WithKnownHosts sets the known_hosts file that the keys of the SSH hosts are
+// verified against. The known_hosts files of the daemon are used if unset.
+func WithKnownHosts(path string) CloneOption {
+	return func(repo *gitRepo) {
+		repo.knownHosts = path
+	}
 }
 
 // Clone clones a repository into a newly created directory which
-// will be under "docker-build-git"
-func Clone(remoteURL string) (string, error) {
+// will be under "docker-build-git". If the remote URL has a subdirectory
+// fragment, only that subdirectory is checked out.
+func Clone(remoteURL string, opts ...CloneOption) (string, error) {
 	repo, err := parseRemoteURL(remoteURL)
 
 	if err != nil {
 		return "", err
 	}
 
+	for _, opt := range opts {
+		opt(&repo)
+	}
+
 	return cloneGitRepo(repo)
 }
 
@@ -44,29 +81,35 @@ func cloneGitRepo(repo gitRepo) (checkoutDir string, err error) {
 		}
 	}()
 
-	if out, err := gitWithinDir(root, "init"); err != nil {
+	if out, err := repo.gitWithinDir(root, "init"); err != nil {
 		return "", errors.Wrapf(err, "failed to init repo at %s: %s", root, out)
 	}
 
 	// Add origin remote for compatibility with previous implementation that
 	// used "git clone" and also to make sure local refs are created for branches
-	if out, err := gitWithinDir(root, "remote", "add", "origin", repo.remote); err != nil {
+	if out, err := repo.gitWithinDir(root, "remote", "add", "origin", repo.remote); err != nil {
 		return "", errors.Wrapf(err, "failed add origin repo at %s: %s", repo.remote, out)
 	}
 
-	if output, err := gitWithinDir(root, fetch...); err != nil {
+	if output, err := repo.gitWithinDir(root, fetch...); err != nil {
 		return "", errors.Wrapf(err, "error fetching: %s", output)
 	}
 
-	checkoutDir, err = checkoutGit(root, repo.ref, repo.subdir)
+	checkoutDir, err = repo.checkoutGit(root, repo.ref, repo.subdir)
 	if err != nil {
 		return "", err
 	}
 
-	cmd := exec.Command("git", "submodule", "update", "--init", "--recursive", "--depth=1")
-	cmd.Dir = root
-	output, err := cmd.CombinedOutput()
-	if err != nil {
+	// Only initialize the submodules within the checked out subdirectory.
+	submodule := []string{"-C", root, "submodule", "update", "--init", "--recursive", "--depth=1"}
+	if checkoutDir != root {
+		rel, err := filepath.Rel(root, checkoutDir)
+		if err != nil {
+			return "", err
+		}
+		submodule = append(submodule, "--", rel)
+	}
+	if output, err := repo.git(submodule...); err != nil {
 		return "", errors.Wrapf(err, "error initializing submodules: %s", output)
 	}
 
@@ -162,20 +205,30 @@ func supportsShallowClone(remoteURL string) bool {
 	return true
 }
 
-func checkoutGit(root, ref, subdir string) (string, error) {
+func (repo gitRepo) checkoutGit(root, ref, subdir string) (string, error) {
+	if subdir != "" {
+		// Only check out the subdirectory that is used as build context.
+		if output, err := repo.gitWithinDir(root, "config", "core.sparseCheckout", "true"); err != nil {
+			return "", errors.Wrapf(err, "error enabling sparse checkout: %s", output)
+		}
+		if err := addSparseCheckoutPath(root, subdir); err != nil {
+			return "", err
+		}
+	}
+
 	// Try checking out by ref name first. This will work on branches and sets
 	// .git/HEAD to the current branch name
-	if output, err := gitWithinDir(root, "checkout", ref); err != nil {
+	if output, err := repo.gitWithinDir(root, "checkout", ref); err != nil {
 		// If checking out by branch name fails check out the last fetched ref
-		if _, err2 := gitWithinDir(root, "checkout", "FETCH_HEAD"); err2 != nil {
+		if _, err2 := repo.gitWithinDir(root, "checkout", "FETCH_HEAD"); err2 != nil {
 			return "", errors.Wrapf(err, "error checking out %s: %s", ref, output)
 		}
 	}
 
 	if subdir != "" {
-		newCtx, err := symlink.FollowSymlinkInScope(filepath.Join(root, subdir), root)
+		newCtx, err := repo.resolveSparseSubdir(root, subdir)
 		if err != nil {
-			return "", errors.Wrapf(err, "error setting git context, %q not within git root", subdir)
+			return "", err
 		}
 
 		fi, err := os.Stat(newCtx)
@@ -191,13 +244,108 @@ func checkoutGit(root, ref, subdir string) (string, error) {
 	return root, nil
 }
 
+// maxSparseSymlinks is the maximum number of symlinks that are followed to
+// resolve the subdirectory of a sparse checkout.
+const maxSparseSymlinks = 16
+
+// resolveSparseSubdir resolves subdir within root. If subdir is, or is
+// within, a symlink, the target of the symlink is added to the sparse
+// checkout.
+func (repo gitRepo) resolveSparseSubdir(root, subdir string) (string, error) {
+	for i := 0; ; i++ {
+		newCtx, err := symlink.FollowSymlinkInScope(filepath.Join(root, subdir), root)
+		if err != nil {
+			return "", errors.Wrapf(err, "error setting git context, %q not within git root", subdir)
+		}
+		if _, err := os.Lstat(newCtx); !os.IsNotExist(err) || i == maxSparseSymlinks {
+			return newCtx, nil
+		}
+		rel, err := filepath.Rel(root, newCtx)
+		if err != nil {
+			return "", err
+		}
+		if err := addSparseCheckoutPath(root, rel); err != nil {
+			return "", err
+		}
+		if output, err := repo.gitWithinDir(root, "read-tree", "-mu", "HEAD"); err != nil {
+			return "", errors.Wrapf(err, "error updating sparse checkout: %s", output)
+		}
+	}
+}
+
+// addSparseCheckoutPath adds path to the sparse checkout patterns of the
+// repository at root.
+func addSparseCheckoutPath(root, path string) error {
+	pattern := "/" + strings.Trim(filepath.ToSlash(filepath.Clean(path)), "/") + "\n"
+	f, err := os.OpenFile(filepath.Join(root, ".git", "info", "sparse-checkout"), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.Wrap(err, "error configuring sparse checkout")
+	}
+	defer f.Close()
+	_, err = f.WriteString(pattern)
+	return errors.Wrap(err, "error configuring sparse checkout")
+}
+
 func gitWithinDir(dir string, args ...string) ([]byte, error) {
-	a := []string{"--work-tree", dir, "--git-dir", filepath.Join(dir, ".git")}
-	return git(append(a, args...)...)
+	return gitRepo{}.gitWithinDir(dir, args...)
 }
 
 func git(args ...string) ([]byte, error) {
-	return exec.Command("git", args...).CombinedOutput()
+	return gitRepo{}.git(args...)
+}
+
+func (repo gitRepo) gitWithinDir(dir string, args ...string) ([]byte, error) {
+	a := []string{"--work-tree", dir, "--git-dir", filepath.Join(dir, ".git")}
+	return repo.git(append(a, args...)...)
+}
+
+// git runs git with the credentials of the repository.
+func (repo gitRepo) git(args ...string) ([]byte, error) {
+	return repo.gitCmd(args...).CombinedOutput()
+}
+
+// gitCmd returns the command that runs git with the credentials of the
+// repository. The credentials are passed in the environment of git rather
+// than its arguments, which are visible to other users of the host.
+func (repo gitRepo) gitCmd(args ...string) *exec.Cmd {
+	cmd := exec.Command("git", args...)
+	// Never prompt for credentials, as there is no terminal to prompt on.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if repo.authHeader != "" {
+		if scope := authScope(repo.remote); scope != "" {
+			// GIT_CONFIG_COUNT requires git 2.31 or later. It replaces any
+			// configuration that is set in the environment of the daemon.
+			cmd.Env = append(cmd.Env,
+				"GIT_CONFIG_COUNT=1",
+				"GIT_CONFIG_KEY_0=http."+scope+".extraheader",
+				"GIT_CONFIG_VALUE_0=Authorization: "+repo.authHeader,
+			)
+		}
+	}
+	if repo.sshAuthSock != "" {
+		cmd.Env = append(cmd.Env, "SSH_AUTH_SOCK="+repo.sshAuthSock, "GIT_SSH_COMMAND="+repo.sshCommand())
+	}
+	return cmd
+}
+
+// sshCommand returns the SSH command that git fetches over SSH with. The
+// clone fails if the key of the host is not in the known hosts.
+func (repo gitRepo) sshCommand() string {
+	cmd := "ssh -F /dev/null -o BatchMode=yes -o StrictHostKeyChecking=yes"
+	if repo.knownHosts != "" {
+		cmd += " -o UserKnownHostsFile='" + strings.ReplaceAll(repo.knownHosts, "'", `'\''`) + "'"
+	}
+	return cmd
+}
+
+// authScope returns the URL prefix that the Authorization header of remoteURL
+// is sent to, or an empty string if remoteURL is not fetched over HTTP(S).
+func authScope(remoteURL string) string {
+	u, err := url.Parse(remoteURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host + "/"
 }
 
 // isGitTransport returns true if the provided str is a git transport by inspecting
@@ -170,6 +170,12 @@ func gitGetConfig(name string) string {
 }
 
 func TestCheckoutGit(t *testing.T) {
+	// Newer versions of git do not allow submodules to be cloned from the
+	// local filesystem by default.
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "protocol.file.allow")
+	t.Setenv("GIT_CONFIG_VALUE_0", "always")
+
 	root, err := os.MkdirTemp("", "docker-build-git-checkout")
 	assert.NilError(t, err)
 	defer os.RemoveAll(root)
@@ -321,6 +327,58 @@ func TestCheckoutGit(t *testing.T) {
 		b, err := os.ReadFile(filepath.Join(r, "Dockerfile"))
 		assert.NilError(t, err)
 		assert.Check(t, is.Equal(c.exp, string(b)))
+
+		if subdir != "" {
+			// Files outside of the subdirectory are not checked out.
+			_, err := os.Stat(filepath.Join(filepath.Dir(r), "Dockerfile"))
+			assert.Check(t, os.IsNotExist(err), "expected sparse checkout of %s", subdir)
+		}
+	}
+}
+
+func TestCloneOptions(t *testing.T) {
+	repo := gitRepo{remote: "https://github.com/moby/moby.git"}
+	for _, opt := range []CloneOption{WithAuthHeader("Basic Zm9vOmJhcg=="), WithSSHAuthSock("/run/ssh.sock")} {
+		opt(&repo)
+	}
+	assert.Check(t, is.Equal(repo.authHeader, "Basic Zm9vOmJhcg=="))
+	assert.Check(t, is.Equal(repo.sshAuthSock, "/run/ssh.sock"))
+}
+
+func TestGitCmdAuthHeader(t *testing.T) {
+	repo := gitRepo{remote: "https://github.com/moby/moby.git"}
+	WithAuthHeader("Basic Zm9vOmJhcg==")(&repo)
+
+	cmd := repo.gitCmd("config", "--get", "http.https://github.com/.extraheader")
+	for _, arg := range cmd.Args {
+		assert.Check(t, !strings.Contains(arg, "Zm9vOmJhcg=="), "expected credentials not to be in the arguments of git: %v", cmd.Args)
+	}
+
+	out, err := repo.git("config", "--get", "http.https://github.com/.extraheader")
+	assert.NilError(t, err, string(out))
+	assert.Check(t, is.Equal(strings.TrimSpace(string(out)), "Authorization: Basic Zm9vOmJhcg=="))
+}
+
+func TestSSHCommand(t *testing.T) {
+	repo := gitRepo{remote: "git@github.com:moby/moby.git"}
+	assert.Check(t, is.Equal(repo.sshCommand(), "ssh -F /dev/null -o BatchMode=yes -o StrictHostKeyChecking=yes"))
+
+	WithKnownHosts("/tmp/known hosts")(&repo)
+	assert.Check(t, is.Equal(repo.sshCommand(), "ssh -F /dev/null -o BatchMode=yes -o StrictHostKeyChecking=yes -o UserKnownHostsFile='/tmp/known hosts'"))
+}
+
+func TestAuthScope(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{url: "https://github.com/moby/moby.git", expected: "https://github.com/"},
+		{url: "http://example.com:8080/repo.git", expected: "http://example.com:8080/"},
+		{url: "git://github.com/moby/moby.git", expected: ""},
+		{url: "git@github.com:moby/moby.git", expected: ""},
+	}
+	for _, tc := range tests {
+		assert.Check(t, is.Equal(authScope(tc.url), tc.expected), tc.url)
 	}
 }
 
@@ -7,23 +7,48 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
+	"time"
 
 	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/ioutils"
+	"github.com/opencontainers/go-digest"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
 // When downloading remote contexts, limit the amount (in bytes)
 // to be read from the response body in order to detect its Content-Type
 const maxPreambleLength = 100
 
-const acceptableRemoteMIME = `(?:application/(?:(?:x\-)?tar|octet\-stream|((?:x\-)?(?:gzip|bzip2?|xz)))|(?:text/plain))`
+const acceptableRemoteMIME = `(?:application/(?:(?:x\-)?tar|octet\-stream|((?:x\-)?(?:gzip|bzip2?|xz|zstd)))|(?:text/plain))`
 
 var mimeRe = regexp.MustCompile(acceptableRemoteMIME)
 
-// downloadRemote context from a url and returns it, along with the parsed content type
-func downloadRemote(remoteURL string) (string, io.ReadCloser, error) {
+// remoteDigestPrefix is the prefix of the URL fragment that pins the digest
+// of a remote context, for example "https://example.com/context.tar.gz#sha256=<digest>".
+const remoteDigestPrefix = "sha256="
+
+// remoteCacheMaxAge is the time after which remote contexts that were not
+// used are removed by PruneRemoteCache.
+const remoteCacheMaxAge = 7 * 24 * time.Hour
+
+// downloadRemote context from a url and returns it, along with the parsed content type.
+// If the digest of the context is pinned in the URL fragment, the context is
+// verified before it is returned, and cached in cacheRoot if set.
+func downloadRemote(remoteURL string, cacheRoot string) (string, io.ReadCloser, error) {
+	remoteURL, dgst, err := parseRemoteDigest(remoteURL)
+	if err != nil {
+		return "", nil, err
+	}
+	if dgst != "" {
+		return downloadVerifiedRemote(remoteURL, dgst, cacheRoot)
+	}
+
 	response, err := GetWithStatusError(remoteURL)
 	if err != nil {
 		return "", nil, errors.Wrapf(err, "error downloading remote context %s", remoteURL)
@@ -41,6 +66,116 @@ func downloadRemote(remoteURL string) (string, io.ReadCloser, error) {
 	return contentType, ioutils.NewReadCloserWrapper(contextReader, response.Body.Close), nil
 }
 
+// parseRemoteDigest returns remoteURL without the fragment that pins the
+// digest of the remote context, and the pinned digest. The digest is empty if
+// it is not pinned.
+func parseRemoteDigest(remoteURL string) (string, digest.Digest, error) {
+	u, err := url.Parse(remoteURL)
+	if err != nil || !strings.HasPrefix(u.Fragment, remoteDigestPrefix) {
+		return remoteURL, "", nil
+	}
+	dgst := digest.NewDigestFromEncoded(digest.SHA256, strings.TrimPrefix(u.Fragment, remoteDigestPrefix))
+	if err := dgst.Validate(); err != nil {
+		return "", "", errdefs.InvalidParameter(errors.Wrapf(err, "invalid digest for remote context %s", remoteURL))
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String(), dgst, nil
+}
+
+// downloadVerifiedRemote returns the remote context at remoteURL, along with
+// its detected content type, after verifying it against dgst. If cacheRoot is
+// set, the context is looked up in, and added to, the cache in cacheRoot.
+func downloadVerifiedRemote(remoteURL string, dgst digest.Digest, cacheRoot string) (string, io.ReadCloser, error) {
+	f, err := fetchVerifiedRemote(remoteURL, dgst, cacheRoot)
+	if err != nil {
+		return "", nil, err
+	}
+	contentType, contextReader, err := inspectResponse("", f, -1)
+	if err != nil {
+		f.Close()
+		return "", nil, errors.Wrapf(err, "error detecting content type for remote %s", remoteURL)
+	}
+	return contentType, ioutils.NewReadCloserWrapper(contextReader, f.Close), nil
+}
+
+func fetchVerifiedRemote(remoteURL string, dgst digest.Digest, cacheRoot string) (_ io.ReadCloser, retErr error) {
+	var cachePath, dir string
+	if cacheRoot != "" {
+		cachePath = filepath.Join(cacheRoot, dgst.Algorithm().String(), dgst.Encoded())
+		if f, err := os.Open(cachePath); err == nil {
+			logrus.Debugf("using cached remote context %s for %s", dgst, remoteURL)
+			now := time.Now()
+			_ = os.Chtimes(cachePath, now, now)
+			return f, nil
+		}
+		dir = filepath.Dir(cachePath)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, errdefs.System(err)
+		}
+	}
+
+	f, err := os.CreateTemp(dir, "remote-context")
+	if err != nil {
+		return nil, errdefs.System(err)
+	}
+	defer func() {
+		if retErr != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	response, err := GetWithStatusError(remoteURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error downloading remote context %s", remoteURL)
+	}
+	defer response.Body.Close()
+
+	verifier := dgst.Verifier()
+	if _, err := io.Copy(io.MultiWriter(f, verifier), response.Body); err != nil {
+		return nil, errors.Wrapf(err, "error downloading remote context %s", remoteURL)
+	}
+	if !verifier.Verified() {
+		return nil, errdefs.InvalidParameter(errors.Errorf("remote context %s does not match digest %s", remoteURL, dgst))
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, errdefs.System(err)
+	}
+
+	if cachePath == "" {
+		return ioutils.NewReadCloserWrapper(f, func() error {
+			err := f.Close()
+			os.Remove(f.Name())
+			return err
+		}), nil
+	}
+	if err := os.Rename(f.Name(), cachePath); err != nil {
+		return nil, errdefs.System(err)
+	}
+	return f, nil
+}
+
+// PruneRemoteCache removes remote contexts from the cache in root that have
+// not been used for longer than a week.
+func PruneRemoteCache(root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || time.Since(info.ModTime()) < remoteCacheMaxAge {
+			return nil
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	})
+}
+
 // GetWithStatusError does an http.Get() and returns an error if the
 // status code is 4xx or 5xx.
 func GetWithStatusError(address string) (resp *http.Response, err error) {
@@ -101,6 +236,11 @@ func inspectResponse(ct string, r io.Reader, clen int64) (string, io.Reader, err
 
 	preambleR := bytes.NewReader(preamble[:rlen])
 	bodyReader := io.MultiReader(preambleR, r)
+	// Compressed archives are accepted regardless of the content type that is
+	// reported for them, as it is often generic or missing for zstd and xz.
+	if archive.DetectCompression(preamble[:rlen]) != archive.Uncompressed {
+		return mimeTypes.OctetStream, bodyReader, nil
+	}
 	// Some web servers will use application/octet-stream as the default
 	// content type for files without an extension (e.g. 'Dockerfile')
 	// so if we receive this value we better check for text content
@@ -6,9 +6,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/docker/docker/builder"
+	"github.com/opencontainers/go-digest"
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
 	"gotest.tools/v3/fs"
@@ -16,6 +20,8 @@ import (
 
 var binaryContext = []byte{0xFD, 0x37, 0x7A, 0x58, 0x5A, 0x00} // xz magic
 
+var zstdContext = []byte{0x28, 0xB5, 0x2F, 0xFD, 0x00, 0x00} // zstd magic
+
 func TestSelectAcceptableMIME(t *testing.T) {
 	validMimeStrings := []string{
 		"application/x-bzip2",
@@ -24,6 +30,7 @@ func TestSelectAcceptableMIME(t *testing.T) {
 		"application/x-gzip",
 		"application/x-xz",
 		"application/xz",
+		"application/zstd",
 		"application/tar",
 		"application/x-tar",
 		"application/octet-stream",
@@ -186,7 +193,7 @@ func TestDownloadRemote(t *testing.T) {
 
 	mux.Handle("/", http.FileServer(http.Dir(contextDir.Path())))
 
-	contentType, content, err := downloadRemote(remoteURL)
+	contentType, content, err := downloadRemote(remoteURL, "")
 	assert.NilError(t, err)
 
 	assert.Check(t, is.Equal(mimeTypes.TextPlain, contentType))
@@ -195,6 +202,61 @@ func TestDownloadRemote(t *testing.T) {
 	assert.Check(t, is.Equal(dockerfileContents, string(raw)))
 }
 
+func TestDownloadRemoteVerified(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/zstd")
+		w.Write(zstdContext)
+	}))
+	defer server.Close()
+
+	cacheRoot := t.TempDir()
+	dgst := digest.FromBytes(zstdContext)
+	remoteURL := server.URL + "/context.tar.zst#sha256=" + dgst.Encoded()
+
+	for i := 0; i < 2; i++ {
+		contentType, content, err := downloadRemote(remoteURL, cacheRoot)
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(mimeTypes.OctetStream, contentType))
+		raw, err := readBody(content)
+		assert.NilError(t, err)
+		assert.Check(t, bytes.Equal(zstdContext, raw))
+	}
+	assert.Check(t, is.Equal(requests, 1), "expected cached context to be used")
+	_, err := os.Stat(filepath.Join(cacheRoot, "sha256", dgst.Encoded()))
+	assert.Check(t, err)
+
+	_, _, err = downloadRemote(server.URL+"/context.tar.zst#sha256="+digest.FromString("other").Encoded(), cacheRoot)
+	assert.Check(t, is.ErrorContains(err, "does not match digest"))
+
+	_, _, err = downloadRemote(server.URL+"/context.tar.zst#sha256=invalid", cacheRoot)
+	assert.Check(t, is.ErrorContains(err, "invalid digest"))
+
+	entries, err := os.ReadDir(filepath.Join(cacheRoot, "sha256"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(entries, 1), "expected unverified context not to be cached")
+}
+
+func TestPruneRemoteCache(t *testing.T) {
+	root := t.TempDir()
+	stale := filepath.Join(root, "sha256", digest.FromString("stale").Encoded())
+	recent := filepath.Join(root, "sha256", digest.FromString("recent").Encoded())
+	assert.NilError(t, os.MkdirAll(filepath.Join(root, "sha256"), 0700))
+	for _, p := range []string{stale, recent} {
+		assert.NilError(t, os.WriteFile(p, nil, 0600))
+	}
+	old := time.Now().Add(-2 * remoteCacheMaxAge)
+	assert.NilError(t, os.Chtimes(stale, old, old))
+
+	assert.NilError(t, PruneRemoteCache(root))
+
+	_, err := os.Stat(stale)
+	assert.Check(t, os.IsNotExist(err))
+	_, err = os.Stat(recent)
+	assert.Check(t, err)
+}
+
 func TestGetWithStatusError(t *testing.T) {
 	var testcases = []struct {
 		err          error
@@ -42,6 +42,13 @@ func (cli *Client) CopyToContainer(ctx context.Context, containerID, dstPath str
 		query.Set("copyUIDGID", "true")
 	}
 
+	if options.Sync {
+		if err := cli.NewVersionError("1.42", "copy sync"); err != nil {
+			return err
+		}
+		query.Set("sync", "true")
+	}
+
 	apiPath := "/containers/" + containerID + "/archive"
 
 	response, err := cli.putRaw(ctx, apiPath, query, content, nil)
@@ -53,6 +60,60 @@ func (cli *Client) CopyToContainer(ctx context.Context, containerID, dstPath str
 	return nil
 }
 
+// ContainerArchiveManifest returns the manifest of the files in a directory
+// inside the container filesystem. Compare it with local files using
+// archive.ChangesManifest, and copy the changes using CopyToContainer with
+// the Sync option.
+func (cli *Client) ContainerArchiveManifest(ctx context.Context, containerID, path string) ([]types.ContainerPathManifestEntry, error) {
+	if err := cli.NewVersionError("1.42", "archive manifest"); err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("path", filepath.ToSlash(path)) // Normalize the paths used in the API.
+
+	var manifest []types.ContainerPathManifestEntry
+	response, err := cli.get(ctx, "/containers/"+containerID+"/archive/manifest", query, nil)
+	defer ensureReaderClosed(response)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.NewDecoder(response.body).Decode(&manifest)
+	return manifest, err
+}
+
+// CopyBetweenContainers copies content from the filesystem of the container
+// srcContainer into the filesystem of the container. The content is not sent
+// to the client.
+func (cli *Client) CopyBetweenContainers(ctx context.Context, containerID, dstPath, srcContainer, srcPath string, options types.CopyBetweenContainersOptions) error {
+	if err := cli.NewVersionError("1.42", "copy between containers"); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("path", filepath.ToSlash(dstPath)) // Normalize the paths used in the API.
+	query.Set("source", srcContainer)
+	query.Set("sourcePath", filepath.ToSlash(srcPath))
+	// Do not allow for an existing directory to be overwritten by a non-directory and vice versa.
+	if !options.AllowOverwriteDirWithFile {
+		query.Set("noOverwriteDirNonDir", "true")
+	}
+	if options.CopyUIDGID {
+		query.Set("copyUIDGID", "true")
+	}
+	if options.Sync {
+		query.Set("sync", "true")
+	}
+	if options.Delete {
+		query.Set("delete", "true")
+	}
+
+	response, err := cli.post(ctx, "/containers/"+containerID+"/archive/copy", query, nil, nil)
+	ensureReaderClosed(response)
+	return err
+}
+
 // CopyFromContainer gets the content from the container and returns it as a Reader
 // for a TAR archive to manipulate it in the host. It's up to the caller to close the reader.
 func (cli *Client) CopyFromContainer(ctx context.Context, containerID, srcPath string) (io.ReadCloser, types.ContainerPathStat, error) {
@@ -291,3 +291,110 @@ func TestCopyFromContainer(t *testing.T) {
 		t.Fatalf("expected content to be 'content', got %s", string(content))
 	}
 }
+
+func TestContainerArchiveManifest(t *testing.T) {
+	expectedURL := "/containers/container_id/archive/manifest"
+	expectedPath := "path/to/dir"
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != expectedURL {
+				return nil, fmt.Errorf("Expected URL '%s', got '%s'", expectedURL, req.URL)
+			}
+			if req.Method != http.MethodGet {
+				return nil, fmt.Errorf("expected GET method, got %s", req.Method)
+			}
+			if path := req.URL.Query().Get("path"); path != expectedPath {
+				return nil, fmt.Errorf("path not set in URL query properly, expected '%s', got %s", expectedPath, path)
+			}
+			b, err := json.Marshal([]types.ContainerPathManifestEntry{
+				{Path: "file", Size: 5, Mode: 0644, Digest: "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+			})
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(b)),
+			}, nil
+		}),
+	}
+	manifest, err := client.ContainerArchiveManifest(context.Background(), "container_id", expectedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(manifest))
+	}
+	if manifest[0].Path != "file" || manifest[0].Size != 5 {
+		t.Fatalf("unexpected entry: %+v", manifest[0])
+	}
+}
+
+func TestCopyToContainerSync(t *testing.T) {
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if sync := req.URL.Query().Get("sync"); sync != "true" {
+				return nil, fmt.Errorf("sync not set in URL query properly, expected true, got %s", sync)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader([]byte(""))),
+			}, nil
+		}),
+	}
+	err := client.CopyToContainer(context.Background(), "container_id", "path/to/dir", bytes.NewReader([]byte("content")), types.CopyToContainerOptions{
+		Sync: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCopyBetweenContainersError(t *testing.T) {
+	client := &Client{
+		client: newMockClient(errorMock(http.StatusInternalServerError, "Server error")),
+	}
+	err := client.CopyBetweenContainers(context.Background(), "container_id", "path/to/dir", "source_id", "path/to/file", types.CopyBetweenContainersOptions{})
+	if !errdefs.IsSystem(err) {
+		t.Fatalf("expected a Server Error, got %[1]T: %[1]v", err)
+	}
+}
+
+func TestCopyBetweenContainers(t *testing.T) {
+	expectedURL := "/containers/container_id/archive/copy"
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != expectedURL {
+				return nil, fmt.Errorf("Expected URL '%s', got '%s'", expectedURL, req.URL)
+			}
+			if req.Method != http.MethodPost {
+				return nil, fmt.Errorf("expected POST method, got %s", req.Method)
+			}
+			query := req.URL.Query()
+			for key, expected := range map[string]string{
+				"path":                 "path/to/dir",
+				"source":               "source_id",
+				"sourcePath":           "path/to/src",
+				"noOverwriteDirNonDir": "true",
+				"sync":                 "true",
+				"delete":               "true",
+				"copyUIDGID":           "",
+			} {
+				if actual := query.Get(key); actual != expected {
+					return nil, fmt.Errorf("%s not set in URL query properly, expected '%s', got '%s'", key, expected, actual)
+				}
+			}
+			return &http.Response{
+				StatusCode: http.StatusNoContent,
+				Body:       io.NopCloser(bytes.NewReader([]byte(""))),
+			}, nil
+		}),
+	}
+	err := client.CopyBetweenContainers(context.Background(), "container_id", "path/to/dir", "source_id", "path/to/src", types.CopyBetweenContainersOptions{
+		Sync:   true,
+		Delete: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
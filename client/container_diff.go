@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/url"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 )
 
@@ -21,3 +22,64 @@ func (cli *Client) ContainerDiff(ctx context.Context, containerID string) ([]con
 	err = json.NewDecoder(serverResp.body).Decode(&changes)
 	return changes, err
 }
+
+// ContainerDiffDetails returns a stream of the changes of a container
+// filesystem, with the details of the files before and after each change.
+// Once the stream has been completely read an io.EOF error will be sent over
+// the error channel. If an error is sent all processing will be stopped.
+func (cli *Client) ContainerDiffDetails(ctx context.Context, containerID string, options types.ContainerDiffOptions) (<-chan container.FilesystemChange, <-chan error) {
+	changes := make(chan container.FilesystemChange)
+	errs := make(chan error, 1)
+
+	started := make(chan struct{})
+	go func() {
+		defer close(errs)
+
+		if err := cli.NewVersionError("1.42", "diff details"); err != nil {
+			close(started)
+			errs <- err
+			return
+		}
+
+		query := url.Values{}
+		query.Set("details", "1")
+		if options.Image != "" {
+			query.Set("image", options.Image)
+		}
+		for _, p := range options.Paths {
+			query.Add("path", p)
+		}
+		if options.Digests {
+			query.Set("digests", "1")
+		}
+
+		resp, err := cli.get(ctx, "/containers/"+containerID+"/changes", query, nil)
+		if err != nil {
+			close(started)
+			errs <- err
+			return
+		}
+		defer resp.body.Close()
+
+		decoder := json.NewDecoder(resp.body)
+
+		close(started)
+		for {
+			var change container.FilesystemChange
+			if err := decoder.Decode(&change); err != nil {
+				errs <- err
+				return
+			}
+
+			select {
+			case changes <- change:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+	<-started
+
+	return changes, errs
+}
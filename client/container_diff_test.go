@@ -10,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/errdefs"
 )
@@ -59,3 +60,77 @@ func TestContainerDiff(t *testing.T) {
 		t.Fatalf("expected an array of 2 changes, got %v", changes)
 	}
 }
+
+func TestContainerDiffDetailsError(t *testing.T) {
+	client := &Client{
+		client: newMockClient(errorMock(http.StatusInternalServerError, "Server error")),
+	}
+	_, errs := client.ContainerDiffDetails(context.Background(), "nothing", types.ContainerDiffOptions{})
+	if err := <-errs; !errdefs.IsSystem(err) {
+		t.Fatalf("expected a Server Error, got %[1]T: %[1]v", err)
+	}
+}
+
+func TestContainerDiffDetails(t *testing.T) {
+	expectedURL := "/containers/container_id/changes"
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if !strings.HasPrefix(req.URL.Path, expectedURL) {
+				return nil, fmt.Errorf("Expected URL '%s', got '%s'", expectedURL, req.URL)
+			}
+			query := req.URL.Query()
+			if details := query.Get("details"); details != "1" {
+				return nil, fmt.Errorf("details not set in URL query properly, expected '1', got '%s'", details)
+			}
+			if image := query.Get("image"); image != "busybox" {
+				return nil, fmt.Errorf("image not set in URL query properly, expected 'busybox', got '%s'", image)
+			}
+			if paths := query["path"]; len(paths) != 2 || paths[0] != "/etc" || paths[1] != "/usr" {
+				return nil, fmt.Errorf("path not set in URL query properly, got %v", paths)
+			}
+			if digests := query.Get("digests"); digests != "1" {
+				return nil, fmt.Errorf("digests not set in URL query properly, expected '1', got '%s'", digests)
+			}
+
+			var buf bytes.Buffer
+			enc := json.NewEncoder(&buf)
+			for _, change := range []container.FilesystemChange{
+				{Path: "/etc/passwd", Kind: 0, Old: &container.FilesystemChangeFile{UID: 0}, New: &container.FilesystemChangeFile{UID: 1000}},
+				{Path: "/usr/bin/nc", Kind: 1, New: &container.FilesystemChangeFile{Size: 10}},
+			} {
+				if err := enc.Encode(change); err != nil {
+					return nil, err
+				}
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(&buf),
+			}, nil
+		}),
+	}
+
+	changes, errs := client.ContainerDiffDetails(context.Background(), "container_id", types.ContainerDiffOptions{
+		Image:   "busybox",
+		Paths:   []string{"/etc", "/usr"},
+		Digests: true,
+	})
+	var paths []string
+loop:
+	for {
+		select {
+		case change := <-changes:
+			paths = append(paths, change.Path)
+			if change.Path == "/etc/passwd" && (change.Old == nil || change.New == nil || change.New.UID != 1000) {
+				t.Fatalf("unexpected change: %+v", change)
+			}
+		case err := <-errs:
+			if err != io.EOF {
+				t.Fatal(err)
+			}
+			break loop
+		}
+	}
+	if len(paths) != 2 {
+		t.Fatalf("expected 2 changes, got %v", paths)
+	}
+}
@@ -3,6 +3,7 @@ package client // import "github.com/docker/docker/client"
 import (
 	"context"
 	"encoding/json"
+	"net/url"
 
 	"github.com/docker/docker/api/types"
 )
@@ -52,3 +53,38 @@ func (cli *Client) ContainerExecInspect(ctx context.Context, execID string) (typ
 	ensureReaderClosed(resp)
 	return response, err
 }
+
+// ContainerExecList returns the exec processes of a container, including
+// recently completed exec processes.
+func (cli *Client) ContainerExecList(ctx context.Context, container string) ([]types.ExecSummary, error) {
+	if err := cli.NewVersionError("1.42", "exec list"); err != nil {
+		return nil, err
+	}
+
+	var execs []types.ExecSummary
+	resp, err := cli.get(ctx, "/containers/"+container+"/exec", nil, nil)
+	defer ensureReaderClosed(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.NewDecoder(resp.body).Decode(&execs)
+	return execs, err
+}
+
+// ContainerExecKill sends a signal to a running exec process. The process is
+// killed if no signal is given.
+func (cli *Client) ContainerExecKill(ctx context.Context, execID, signal string) error {
+	if err := cli.NewVersionError("1.42", "exec kill"); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	if signal != "" {
+		query.Set("signal", signal)
+	}
+
+	resp, err := cli.post(ctx, "/exec/"+execID+"/kill", query, nil, nil)
+	ensureReaderClosed(resp)
+	return err
+}
@@ -155,3 +155,62 @@ func TestContainerExecInspect(t *testing.T) {
 		t.Fatalf("expected ContainerID `container_id`, got %s", inspect.ContainerID)
 	}
 }
+
+func TestContainerExecList(t *testing.T) {
+	expectedURL := "/containers/container_id/exec"
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != expectedURL {
+				return nil, fmt.Errorf("Expected URL '%s', got '%s'", expectedURL, req.URL)
+			}
+			exitCode := 1
+			b, err := json.Marshal([]types.ExecSummary{
+				{ID: "exec_id", ExitCode: &exitCode},
+				{ID: "running_id", Running: true},
+			})
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(b)),
+			}, nil
+		}),
+	}
+
+	execs, err := client.ContainerExecList(context.Background(), "container_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(execs) != 2 {
+		t.Fatalf("expected 2 execs, got %d", len(execs))
+	}
+	if execs[0].ExitCode == nil || *execs[0].ExitCode != 1 {
+		t.Fatalf("expected exit code 1, got %v", execs[0].ExitCode)
+	}
+	if !execs[1].Running {
+		t.Fatalf("expected exec %s to be running", execs[1].ID)
+	}
+}
+
+func TestContainerExecKill(t *testing.T) {
+	expectedURL := "/exec/exec_id/kill"
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != expectedURL {
+				return nil, fmt.Errorf("Expected URL '%s', got '%s'", expectedURL, req.URL)
+			}
+			if signal := req.URL.Query().Get("signal"); signal != "SIGTERM" {
+				return nil, fmt.Errorf("signal not set in URL query properly. Expected 'SIGTERM', got %s", signal)
+			}
+			return &http.Response{
+				StatusCode: http.StatusNoContent,
+				Body:       io.NopCloser(bytes.NewReader(nil)),
+			}, nil
+		}),
+	}
+
+	if err := client.ContainerExecKill(context.Background(), "exec_id", "SIGTERM"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -2,9 +2,11 @@ package client // import "github.com/docker/docker/client"
 
 import (
 	"context"
+	"encoding/json"
 	"net/url"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
 )
 
 // ContainerStats returns near realtime stats for a given container.
@@ -40,3 +42,68 @@ func (cli *Client) ContainerStatsOneShot(ctx context.Context, containerID string
 	osType := getDockerOS(resp.header.Get("Server"))
 	return types.ContainerStats{Body: resp.body, OSType: osType}, err
 }
+
+// ContainerStatsAll returns a stream of the stats of the running containers
+// that match the filters in the options, as batches of the stats that were
+// sampled at the same time. Once the stream has been completely read an
+// io.EOF error will be sent over the error channel. If an error is sent all
+// processing will be stopped.
+func (cli *Client) ContainerStatsAll(ctx context.Context, options types.ContainerStatsAllOptions) (<-chan types.StatsBatch, <-chan error) {
+	batches := make(chan types.StatsBatch)
+	errs := make(chan error, 1)
+
+	started := make(chan struct{})
+	go func() {
+		defer close(errs)
+
+		if err := cli.NewVersionError("1.42", "stats of all containers"); err != nil {
+			close(started)
+			errs <- err
+			return
+		}
+
+		query := url.Values{}
+		query.Set("stream", "0")
+		if options.Stream {
+			query.Set("stream", "1")
+		}
+		if options.Filters.Len() > 0 {
+			filterJSON, err := filters.ToJSON(options.Filters)
+			if err != nil {
+				close(started)
+				errs <- err
+				return
+			}
+			query.Set("filters", filterJSON)
+		}
+
+		resp, err := cli.get(ctx, "/containers/stats", query, nil)
+		if err != nil {
+			close(started)
+			errs <- err
+			return
+		}
+		defer resp.body.Close()
+
+		decoder := json.NewDecoder(resp.body)
+
+		close(started)
+		for {
+			var batch types.StatsBatch
+			if err := decoder.Decode(&batch); err != nil {
+				errs <- err
+				return
+			}
+
+			select {
+			case batches <- batch:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+	<-started
+
+	return batches, errs
+}
@@ -3,12 +3,15 @@ package client // import "github.com/docker/docker/client"
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 	"testing"
 
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/errdefs"
 )
 
@@ -69,3 +72,67 @@ func TestContainerStats(t *testing.T) {
 		}
 	}
 }
+
+func TestContainerStatsAllError(t *testing.T) {
+	client := &Client{
+		client: newMockClient(errorMock(http.StatusInternalServerError, "Server error")),
+	}
+	_, errs := client.ContainerStatsAll(context.Background(), types.ContainerStatsAllOptions{})
+	if err := <-errs; !errdefs.IsSystem(err) {
+		t.Fatalf("expected a Server Error, got %[1]T: %[1]v", err)
+	}
+}
+
+func TestContainerStatsAll(t *testing.T) {
+	expectedURL := "/containers/stats"
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if !strings.HasPrefix(req.URL.Path, expectedURL) {
+				return nil, fmt.Errorf("Expected URL '%s', got '%s'", expectedURL, req.URL)
+			}
+			query := req.URL.Query()
+			if stream := query.Get("stream"); stream != "1" {
+				return nil, fmt.Errorf("stream not set in URL query properly, expected '1', got '%s'", stream)
+			}
+			if f := query.Get("filters"); f != `{"label":{"app=web":true}}` {
+				return nil, fmt.Errorf("filters not set in URL query properly, got '%s'", f)
+			}
+
+			var buf bytes.Buffer
+			enc := json.NewEncoder(&buf)
+			for _, batch := range []types.StatsBatch{
+				{Stats: []types.StatsJSON{{ID: "a"}, {ID: "b"}}},
+				{Stats: []types.StatsJSON{{ID: "a"}, {ID: "b"}, {ID: "c"}}},
+			} {
+				if err := enc.Encode(batch); err != nil {
+					return nil, err
+				}
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(&buf),
+			}, nil
+		}),
+	}
+
+	batches, errs := client.ContainerStatsAll(context.Background(), types.ContainerStatsAllOptions{
+		Filters: filters.NewArgs(filters.Arg("label", "app=web")),
+		Stream:  true,
+	})
+	var sizes []int
+loop:
+	for {
+		select {
+		case batch := <-batches:
+			sizes = append(sizes, len(batch.Stats))
+		case err := <-errs:
+			if err != io.EOF {
+				t.Fatal(err)
+			}
+			break loop
+		}
+	}
+	if len(sizes) != 2 || sizes[0] != 2 || sizes[1] != 3 {
+		t.Fatalf("expected batches of 2 and 3 containers, got %v", sizes)
+	}
+}
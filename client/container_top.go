@@ -26,3 +26,23 @@ func (cli *Client) ContainerTop(ctx context.Context, containerID string, argumen
 	err = json.NewDecoder(resp.body).Decode(&response)
 	return response, err
 }
+
+// ContainerTopProcesses lists the processes of a container with structured
+// fields, without running ps.
+func (cli *Client) ContainerTopProcesses(ctx context.Context, containerID string) (container.TopProcessList, error) {
+	var response container.TopProcessList
+	if err := cli.NewVersionError("1.42", "top processes"); err != nil {
+		return response, err
+	}
+	query := url.Values{}
+	query.Set("format", container.TopFormatProcesses)
+
+	resp, err := cli.get(ctx, "/containers/"+containerID+"/top", query, nil)
+	defer ensureReaderClosed(resp)
+	if err != nil {
+		return response, err
+	}
+
+	err = json.NewDecoder(resp.body).Decode(&response)
+	return response, err
+}
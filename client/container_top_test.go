@@ -73,3 +73,38 @@ func TestContainerTop(t *testing.T) {
 		t.Fatalf("Titles: expected %v, got %v", expectedTitles, processList.Titles)
 	}
 }
+
+func TestContainerTopProcesses(t *testing.T) {
+	expectedURL := "/containers/container_id/top"
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != expectedURL {
+				return nil, fmt.Errorf("Expected URL '%s', got '%s'", expectedURL, req.URL)
+			}
+			if format := req.URL.Query().Get("format"); format != "processes" {
+				return nil, fmt.Errorf("format not set in URL query properly. Expected 'processes', got %v", format)
+			}
+
+			b, err := json.Marshal(container.TopProcessList{
+				Processes: []container.TopProcess{{Pid: 42, User: "root", Cmd: []string{"sleep", "10"}}},
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(b)),
+			}, nil
+		}),
+	}
+
+	processList, err := client.ContainerTopProcesses(context.Background(), "container_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []container.TopProcess{{Pid: 42, User: "root", Cmd: []string{"sleep", "10"}}}
+	if !reflect.DeepEqual(expected, processList.Processes) {
+		t.Fatalf("Processes: expected %v, got %v", expected, processList.Processes)
+	}
+}
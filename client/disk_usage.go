@@ -11,12 +11,12 @@ import (
 
 // DiskUsage requests the current data usage from the daemon
 func (cli *Client) DiskUsage(ctx context.Context, options types.DiskUsageOptions) (types.DiskUsage, error) {
-	var query url.Values
-	if len(options.Types) > 0 {
-		query = url.Values{}
-		for _, t := range options.Types {
-			query.Add("type", string(t))
-		}
+	query := url.Values{}
+	for _, t := range options.Types {
+		query.Add("type", string(t))
+	}
+	if options.Dedup {
+		query.Set("dedup", "1")
 	}
 
 	serverResp, err := cli.get(ctx, "/system/df", query, nil)
@@ -0,0 +1,20 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"context"
+)
+
+// ImagePin pins an image in the docker host, protecting it against pruning
+// and non-forced removal.
+func (cli *Client) ImagePin(ctx context.Context, imageID string) error {
+	resp, err := cli.post(ctx, "/images/"+imageID+"/pin", nil, nil, nil)
+	ensureReaderClosed(resp)
+	return err
+}
+
+// ImageUnpin removes the pin from an image in the docker host.
+func (cli *Client) ImageUnpin(ctx context.Context, imageID string) error {
+	resp, err := cli.post(ctx, "/images/"+imageID+"/unpin", nil, nil, nil)
+	ensureReaderClosed(resp)
+	return err
+}
@@ -0,0 +1,62 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/errdefs"
+)
+
+func TestImagePinError(t *testing.T) {
+	client := &Client{
+		client: newMockClient(errorMock(http.StatusInternalServerError, "Server error")),
+	}
+	err := client.ImagePin(context.Background(), "image_id")
+	if !errdefs.IsSystem(err) {
+		t.Fatalf("expected a Server Error, got %[1]T: %[1]v", err)
+	}
+}
+
+func TestImagePin(t *testing.T) {
+	for _, tc := range []struct {
+		expectedURL string
+		pin         func(*Client) error
+	}{
+		{
+			expectedURL: "/images/image_id/pin",
+			pin: func(cli *Client) error {
+				return cli.ImagePin(context.Background(), "image_id")
+			},
+		},
+		{
+			expectedURL: "/images/image_id/unpin",
+			pin: func(cli *Client) error {
+				return cli.ImageUnpin(context.Background(), "image_id")
+			},
+		},
+	} {
+		expectedURL := tc.expectedURL
+		client := &Client{
+			client: newMockClient(func(req *http.Request) (*http.Response, error) {
+				if !strings.HasPrefix(req.URL.Path, expectedURL) {
+					return nil, fmt.Errorf("Expected URL '%s', got '%s'", expectedURL, req.URL)
+				}
+				if req.Method != http.MethodPost {
+					return nil, fmt.Errorf("expected POST method, got %s", req.Method)
+				}
+				return &http.Response{
+					StatusCode: http.StatusNoContent,
+					Body:       io.NopCloser(bytes.NewReader([]byte(""))),
+				}, nil
+			}),
+		}
+		if err := tc.pin(client); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
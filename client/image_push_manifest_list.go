@@ -0,0 +1,55 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/errdefs"
+)
+
+// ImagePushManifestList requests the docker host to push a manifest list of
+// local images to a remote registry, with the tag of ref.
+// It executes the privileged function if the operation is unauthorized
+// and it tries one more time.
+// It's up to the caller to handle the io.ReadCloser and close it properly.
+func (cli *Client) ImagePushManifestList(ctx context.Context, ref string, list image.ManifestList, options types.ImagePushOptions) (io.ReadCloser, error) {
+	if err := cli.NewVersionError("1.42", "manifest list push"); err != nil {
+		return nil, err
+	}
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return nil, err
+	}
+	if _, isCanonical := named.(reference.Canonical); isCanonical {
+		return nil, errors.New("cannot push a manifest list to a digest reference")
+	}
+
+	name := reference.FamiliarName(named)
+	query := url.Values{}
+	if tagged, ok := reference.TagNameOnly(named).(reference.Tagged); ok {
+		query.Set("tag", tagged.Tag())
+	}
+
+	resp, err := cli.tryImagePushManifestList(ctx, name, query, list, options.RegistryAuth)
+	if errdefs.IsUnauthorized(err) && options.PrivilegeFunc != nil {
+		newAuthHeader, privilegeErr := options.PrivilegeFunc()
+		if privilegeErr != nil {
+			return nil, privilegeErr
+		}
+		resp, err = cli.tryImagePushManifestList(ctx, name, query, list, newAuthHeader)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp.body, nil
+}
+
+func (cli *Client) tryImagePushManifestList(ctx context.Context, name string, query url.Values, list image.ManifestList, registryAuth string) (serverResponse, error) {
+	headers := map[string][]string{"X-Registry-Auth": {registryAuth}}
+	return cli.post(ctx, "/images/"+name+"/manifestlist", query, list, headers)
+}
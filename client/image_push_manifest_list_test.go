@@ -0,0 +1,80 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/errdefs"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestImagePushManifestListError(t *testing.T) {
+	client := &Client{
+		client: newMockClient(errorMock(http.StatusInternalServerError, "Server error")),
+	}
+	_, err := client.ImagePushManifestList(context.Background(), "myimage", image.ManifestList{}, types.ImagePushOptions{})
+	if !errdefs.IsSystem(err) {
+		t.Fatalf("expected a Server Error, got %[1]T: %[1]v", err)
+	}
+
+	_, err = client.ImagePushManifestList(context.Background(), "repo@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", image.ManifestList{}, types.ImagePushOptions{})
+	if err == nil || err.Error() != "cannot push a manifest list to a digest reference" {
+		t.Fatalf("expected an error, got %v", err)
+	}
+}
+
+func TestImagePushManifestList(t *testing.T) {
+	expectedURL := "/images/myimage/manifestlist"
+	list := image.ManifestList{
+		MediaType: specs.MediaTypeImageIndex,
+		Manifests: []image.ManifestListEntry{
+			{Image: "myimage:amd64"},
+			{Image: "myimage:arm64", Platform: &specs.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}},
+		},
+	}
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if !strings.HasPrefix(req.URL.Path, expectedURL) {
+				return nil, fmt.Errorf("Expected URL '%s', got '%s'", expectedURL, req.URL)
+			}
+			if tag := req.URL.Query().Get("tag"); tag != "tag" {
+				return nil, fmt.Errorf("tag not set in URL query properly. Expected '%s', got %s", "tag", tag)
+			}
+			if auth := req.Header.Get("X-Registry-Auth"); auth != "IAmValid" {
+				return nil, fmt.Errorf("Invalid auth header : expected %s, got %s", "IAmValid", auth)
+			}
+			var actual image.ManifestList
+			if err := json.NewDecoder(req.Body).Decode(&actual); err != nil {
+				return nil, err
+			}
+			if len(actual.Manifests) != 2 || actual.MediaType != list.MediaType || actual.Manifests[1].Platform.Variant != "v8" {
+				return nil, fmt.Errorf("unexpected manifest list %+v", actual)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader([]byte("hello world"))),
+			}, nil
+		}),
+	}
+	resp, err := client.ImagePushManifestList(context.Background(), "myimage:tag", list, types.ImagePushOptions{
+		RegistryAuth: "IAmValid",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "hello world" {
+		t.Fatalf("expected 'hello world', got %s", string(body))
+	}
+}
@@ -74,6 +74,8 @@ func installCommonConfigFlags(conf *config.Config, flags *pflag.FlagSet) error {
 	flags.IntVar(&maxConcurrentDownloads, "max-concurrent-downloads", config.DefaultMaxConcurrentDownloads, "Set the max concurrent downloads for each pull")
 	flags.IntVar(&maxConcurrentUploads, "max-concurrent-uploads", config.DefaultMaxConcurrentUploads, "Set the max concurrent uploads for each push")
 	flags.IntVar(&maxDownloadAttempts, "max-download-attempts", config.DefaultDownloadAttempts, "Set the max download attempts for each pull")
+	flags.StringVar(&conf.LazyPullLayerStore, "lazy-pull-layer-store", "", "Path of an additional layer store filesystem used to lazily pull eStargz layers")
+	flags.StringVar(&conf.ImagePolicy, "image-policy", "", "Path of the image signature policy that images must satisfy to be pulled and run")
 	flags.IntVar(&conf.ShutdownTimeout, "shutdown-timeout", config.DefaultShutdownTimeout, "Set the default shutdown timeout")
 	flags.IntVar(&conf.NetworkDiagnosticPort, "network-diagnostic-port", 0, "TCP port number of the network diagnostic server")
 	_ = flags.MarkHidden("network-diagnostic-port")
@@ -110,4 +112,10 @@ func installRegistryServiceFlags(options *registry.ServiceOptions, flags *pflag.
 	flags.Var(ana, "allow-nondistributable-artifacts", "Allow push of nondistributable artifacts to registry")
 	flags.Var(mirrors, "registry-mirror", "Preferred Docker registry mirror")
 	flags.Var(insecureRegistries, "insecure-registry", "Enable insecure registry communication")
+
+	if options.CredentialHelpers == nil {
+		options.CredentialHelpers = make(map[string]string)
+	}
+	flags.Var(opts.NewNamedMapOpts("credential-helpers", options.CredentialHelpers, nil), "credential-helper", "Credential helper of a registry, as registry=helper")
+	flags.StringVar(&options.CredentialsStore, "credentials-store", "", "Default credential helper of registries")
 }
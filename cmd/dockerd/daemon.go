@@ -64,9 +64,12 @@ type DaemonCli struct {
 	configFile *string
 	flags      *pflag.FlagSet
 
-	api             *apiserver.Server
-	d               *daemon.Daemon
-	authzMiddleware *authorization.Middleware // authzMiddleware enables to dynamically reload the authorization plugins
+	api                 *apiserver.Server
+	d                   *daemon.Daemon
+	authzMiddleware     *authorization.Middleware       // authzMiddleware enables to dynamically reload the authorization plugins
+	rbacMiddleware      *middleware.RBACMiddleware      // rbacMiddleware enables to dynamically reload the role-based access control
+	rateLimitMiddleware *middleware.RateLimitMiddleware // rateLimitMiddleware enables to dynamically reload the rate limits
+	auditMiddleware     *middleware.AuditMiddleware     // auditMiddleware enables to dynamically reload the audit log
 }
 
 // NewDaemonCli returns a daemon CLI
@@ -206,6 +209,7 @@ func (cli *DaemonCli) start(opts *daemonOptions) (err error) {
 	}
 
 	d.StoreHosts(hosts)
+	cli.rbacMiddleware.SetResourceLabels(d.ResourceLabels)
 
 	// validate after NewDaemon has restored enabled plugins. Don't change order.
 	if err := validateAuthzPlugins(cli.Config.AuthorizationPlugins, pluginStore); err != nil {
@@ -261,6 +265,10 @@ func (cli *DaemonCli) start(opts *daemonOptions) (err error) {
 	notifyStopping()
 	shutdownDaemon(d)
 
+	if err := cli.auditMiddleware.Close(); err != nil {
+		logrus.WithError(err).Warn("failed to close the audit log")
+	}
+
 	// Stop notification processing and any background processes
 	cancel()
 
@@ -289,7 +297,7 @@ func newRouterOptions(config *config.Config, d *daemon.Daemon) (routerOptions, e
 		return opts, errors.Wrap(err, "failed to create sessionmanager")
 	}
 
-	manager, err := dockerfile.NewBuildManager(d.BuilderBackend(), d.IdentityMapping())
+	manager, err := dockerfile.NewBuildManager(d.BuilderBackend(), d.IdentityMapping(), sm, filepath.Join(config.Root, "builder", "remote-contexts"))
 	if err != nil {
 		return opts, err
 	}
@@ -333,6 +341,11 @@ func (cli *DaemonCli) reloadConfig() {
 			return
 		}
 		cli.authzMiddleware.SetPlugins(c.AuthorizationPlugins)
+		cli.rbacMiddleware.SetConfig(c.RBAC)
+		cli.rateLimitMiddleware.SetConfig(c.RateLimits)
+		if err := cli.auditMiddleware.SetConfig(c.Audit); err != nil {
+			logrus.Errorf("Error reconfiguring the audit log: %v", err)
+		}
 
 		if err := cli.d.Reload(c); err != nil {
 			logrus.Errorf("Error reconfiguring the daemon: %v", err)
@@ -545,6 +558,23 @@ func (cli *DaemonCli) initMiddlewares(s *apiserver.Server, cfg *apiserver.Config
 	cli.authzMiddleware = authorization.NewMiddleware(cli.Config.AuthorizationPlugins, pluginStore)
 	cli.Config.AuthzMiddleware = cli.authzMiddleware
 	s.UseMiddleware(cli.authzMiddleware)
+
+	cli.rbacMiddleware = middleware.NewRBACMiddleware(cli.Config.RBAC)
+	s.UseMiddleware(cli.rbacMiddleware)
+
+	// The rate limits are evaluated before the authorization middlewares, so
+	// that requests that exceed them are rejected without further work.
+	cli.rateLimitMiddleware = middleware.NewRateLimitMiddleware(cli.Config.RateLimits)
+	s.UseMiddleware(cli.rateLimitMiddleware)
+
+	// The audit middleware is evaluated before the other middlewares, so
+	// that requests that they reject are recorded as well.
+	auditMiddleware, err := middleware.NewAuditMiddleware(cli.Config.Audit)
+	if err != nil {
+		return err
+	}
+	cli.auditMiddleware = auditMiddleware
+	s.UseMiddleware(cli.auditMiddleware)
 	return nil
 }
 
@@ -207,6 +207,28 @@ func (daemon *Daemon) containerArchivePath(container *container.Container, path
 		return nil, nil, err
 	}
 
+	data, stat, err := archiveContainerPath(container, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content = ioutils.NewReadCloserWrapper(data, func() error {
+		err := data.Close()
+		container.DetachAndUnmount(daemon.LogVolumeEvent)
+		daemon.Unmount(container)
+		container.Unlock()
+		return err
+	})
+
+	daemon.LogContainerEvent(container, "archive-path")
+
+	return content, stat, nil
+}
+
+// archiveContainerPath creates an archive of the filesystem resource at the
+// specified path in the container, which must be mounted. Returns a tar
+// archive of the resource and stat info about the resource.
+func archiveContainerPath(container *container.Container, path string) (io.ReadCloser, *types.ContainerPathStat, error) {
 	// Normalize path before sending to rootfs
 	path = container.BaseFS.FromSlash(path)
 
@@ -215,7 +237,7 @@ func (daemon *Daemon) containerArchivePath(container *container.Container, path
 		return nil, nil, err
 	}
 
-	stat, err = container.StatPath(resolvedPath, absPath)
+	stat, err := container.StatPath(resolvedPath, absPath)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -250,17 +272,7 @@ func (daemon *Daemon) containerArchivePath(container *container.Container, path
 		return nil, nil, err
 	}
 
-	content = ioutils.NewReadCloserWrapper(data, func() error {
-		err := data.Close()
-		container.DetachAndUnmount(daemon.LogVolumeEvent)
-		daemon.Unmount(container)
-		container.Unlock()
-		return err
-	})
-
-	daemon.LogContainerEvent(container, "archive-path")
-
-	return content, stat, nil
+	return data, stat, nil
 }
 
 // containerExtractToDir extracts the given tar archive to the specified location in the
@@ -284,14 +296,38 @@ func (daemon *Daemon) containerExtractToDir(container *container.Container, path
 		return err
 	}
 
+	resolvedPath, err := resolveExtractPath(container, path)
+	if err != nil {
+		return err
+	}
+
+	options, err := daemon.extractCopyOptions(container, copyUIDGID, noOverwriteDirNonDir)
+	if err != nil {
+		return err
+	}
+
+	if err := extractArchive(container.BaseFS, content, resolvedPath, options, container.BaseFS.Path()); err != nil {
+		return err
+	}
+
+	daemon.LogContainerEvent(container, "extract-to-dir")
+
+	return nil
+}
+
+// resolveExtractPath resolves the given path of a directory in the filesystem
+// of the container, which must be mounted, to a host path to which files can
+// be extracted. If the path is not of a directory, the error will be
+// ErrExtractPointNotDirectory.
+func resolveExtractPath(container *container.Container, path string) (string, error) {
 	// Normalize path before sending to rootfs'
 	path = container.BaseFS.FromSlash(path)
 	driver := container.BaseFS
 
 	// Check if a drive letter supplied, it must be the system drive. No-op except on Windows
-	path, err = system.CheckSystemDriveAndRemoveDriveLetter(path, driver)
+	path, err := system.CheckSystemDriveAndRemoveDriveLetter(path, driver)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// The destination path needs to be resolved to a host path, with all
@@ -309,16 +345,16 @@ func (daemon *Daemon) containerExtractToDir(container *container.Container, path
 	// This will evaluate the last path element if it is a symlink.
 	resolvedPath, err := container.GetResourcePath(absPath)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	stat, err := driver.Lstat(resolvedPath)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	if !stat.IsDir() {
-		return ErrExtractPointNotDirectory
+		return "", ErrExtractPointNotDirectory
 	}
 
 	// Need to check if the path is in a volume. If it is, it cannot be in a
@@ -345,7 +381,7 @@ func (daemon *Daemon) containerExtractToDir(container *container.Container, path
 		baseRel, err = driver.Rel(driver.Path(), resolvedPath)
 	}
 	if err != nil {
-		return err
+		return "", err
 	}
 	// Make it an absolute path.
 	absPath = driver.Join(string(driver.Separator()), baseRel)
@@ -355,32 +391,14 @@ func (daemon *Daemon) containerExtractToDir(container *container.Container, path
 	// But eventually, it should be made driver aware.
 	toVolume, err := checkIfPathIsInAVolume(container, absPath)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	if !toVolume && container.HostConfig.ReadonlyRootfs {
-		return ErrRootFSReadOnly
+		return "", ErrRootFSReadOnly
 	}
 
-	options := daemon.defaultTarCopyOptions(noOverwriteDirNonDir)
-
-	if copyUIDGID {
-		var err error
-		// tarCopyOptions will appropriately pull in the right uid/gid for the
-		// user/group and will set the options.
-		options, err = daemon.tarCopyOptions(container, noOverwriteDirNonDir)
-		if err != nil {
-			return err
-		}
-	}
-
-	if err := extractArchive(driver, content, resolvedPath, options, container.BaseFS.Path()); err != nil {
-		return err
-	}
-
-	daemon.LogContainerEvent(container, "extract-to-dir")
-
-	return nil
+	return resolvedPath, nil
 }
 
 func (daemon *Daemon) containerCopy(container *container.Container, resource string) (rc io.ReadCloser, err error) {
@@ -0,0 +1,332 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"io"
+	"os"
+	"path"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/chrootarchive"
+	"github.com/docker/docker/pkg/system"
+	"github.com/pkg/errors"
+)
+
+// ContainerArchiveManifest returns the manifest of the files in the directory
+// at the specified path in the container identified by the given name, which
+// clients compare with their files to only copy the files that changed.
+func (daemon *Daemon) ContainerArchiveManifest(name, path string) ([]types.ContainerPathManifestEntry, error) {
+	ctr, err := daemon.GetContainer(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make sure an online file-system operation is permitted.
+	if err := daemon.isOnlineFSOperationPermitted(ctr); err != nil {
+		return nil, errdefs.System(err)
+	}
+
+	manifest, err := daemon.containerArchiveManifest(ctr, path)
+	if err == nil {
+		return manifest, nil
+	}
+
+	if os.IsNotExist(err) {
+		return nil, containerFileNotFound{path, name}
+	}
+	if errdefs.IsInvalidParameter(err) {
+		return nil, err
+	}
+	return nil, errdefs.System(err)
+}
+
+// ContainerApplyChangesToDir applies the given changes to the directory at
+// the specified path in the filesystem of the container identified by the
+// given name. The changes are a tar archive in the format of image layers, in
+// which deleted files are whiteouts, such as the archives of
+// archive.ExportChanges. If the path is not of a directory, the error will be
+// ErrExtractPointNotDirectory.
+func (daemon *Daemon) ContainerApplyChangesToDir(name, path string, copyUIDGID bool, content io.Reader) error {
+	ctr, err := daemon.GetContainer(name)
+	if err != nil {
+		return err
+	}
+
+	// Make sure an online file-system operation is permitted.
+	if err := daemon.isOnlineFSOperationPermitted(ctr); err != nil {
+		return errdefs.System(err)
+	}
+
+	err = daemon.containerApplyChangesToDir(ctr, path, copyUIDGID, content)
+	if err == nil {
+		return nil
+	}
+
+	if os.IsNotExist(err) {
+		return containerFileNotFound{path, name}
+	}
+	return errdefs.System(err)
+}
+
+// ContainerCopyFromContainer copies the filesystem resource at sourcePath in
+// the container identified by source to the directory at path in the
+// container identified by name, without sending the files to the client. If
+// options.Sync is true, the directory at sourcePath is synchronized with the
+// directory at path instead, which only copies the files that differ.
+func (daemon *Daemon) ContainerCopyFromContainer(name, path, source, sourcePath string, options types.CopyBetweenContainersOptions) error {
+	dst, err := daemon.GetContainer(name)
+	if err != nil {
+		return err
+	}
+	src, err := daemon.GetContainer(source)
+	if err != nil {
+		return err
+	}
+
+	// Make sure online file-system operations are permitted.
+	if err := daemon.isOnlineFSOperationPermitted(dst); err != nil {
+		return errdefs.System(err)
+	}
+	if err := daemon.isOnlineFSOperationPermitted(src); err != nil {
+		return errdefs.System(err)
+	}
+
+	err = daemon.containerCopyFromContainer(dst, path, src, sourcePath, options)
+	if err == nil {
+		return nil
+	}
+
+	if os.IsNotExist(err) {
+		return errdefs.NotFound(errors.Wrapf(err, "could not copy %s of container %s to %s of container %s", sourcePath, source, path, name))
+	}
+	if errdefs.IsInvalidParameter(err) {
+		return err
+	}
+	return errdefs.System(err)
+}
+
+// mountContainerFS locks the container and mounts its rootfs and volumes for a
+// file-system operation. The returned function unmounts and unlocks it.
+func (daemon *Daemon) mountContainerFS(container *container.Container) (release func(), err error) {
+	container.Lock()
+	if err := daemon.Mount(container); err != nil {
+		container.Unlock()
+		return nil, err
+	}
+	release = func() {
+		// unmount any volumes
+		container.DetachAndUnmount(daemon.LogVolumeEvent)
+		// unmount the container's rootfs
+		daemon.Unmount(container)
+		container.Unlock()
+	}
+	if err := daemon.mountVolumes(container); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// resolveDirPath resolves the given path of a directory in the filesystem of
+// the container, which must be mounted, to a host path. As with extracting,
+// the last path element is evaluated if it is a symlink.
+func resolveDirPath(container *container.Container, path string) (string, error) {
+	driver := container.BaseFS
+	path, err := system.CheckSystemDriveAndRemoveDriveLetter(driver.FromSlash(path), driver)
+	if err != nil {
+		return "", err
+	}
+
+	resolvedPath, err := container.GetResourcePath(driver.Join(string(driver.Separator()), path))
+	if err != nil {
+		return "", err
+	}
+
+	stat, err := driver.Lstat(resolvedPath)
+	if err != nil {
+		return "", err
+	}
+	if !stat.IsDir() {
+		return "", errdefs.InvalidParameter(errors.Errorf("%s is not a directory", path))
+	}
+	return resolvedPath, nil
+}
+
+func (daemon *Daemon) containerArchiveManifest(container *container.Container, path string) ([]types.ContainerPathManifestEntry, error) {
+	release, err := daemon.mountContainerFS(container)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	resolvedPath, err := resolveDirPath(container, path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := chrootarchive.Manifest(resolvedPath, container.BaseFS.Path())
+	if err != nil {
+		return nil, err
+	}
+	manifest := make([]types.ContainerPathManifestEntry, 0, len(entries))
+	for _, e := range entries {
+		manifest = append(manifest, types.ContainerPathManifestEntry(e))
+	}
+
+	daemon.LogContainerEvent(container, "archive-manifest")
+
+	return manifest, nil
+}
+
+func (daemon *Daemon) containerApplyChangesToDir(container *container.Container, path string, copyUIDGID bool, content io.Reader) error {
+	release, err := daemon.mountContainerFS(container)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	resolvedPath, err := resolveExtractPath(container, path)
+	if err != nil {
+		return err
+	}
+
+	options, err := daemon.extractCopyOptions(container, copyUIDGID, false)
+	if err != nil {
+		return err
+	}
+
+	layer, err := archive.DecompressStream(content)
+	if err != nil {
+		return err
+	}
+	defer layer.Close()
+
+	if _, err := chrootarchive.ApplyUncompressedLayer(resolvedPath, layer, options); err != nil {
+		return err
+	}
+
+	daemon.LogContainerEvent(container, "extract-to-dir")
+
+	return nil
+}
+
+func (daemon *Daemon) containerCopyFromContainer(dst *container.Container, path string, src *container.Container, sourcePath string, options types.CopyBetweenContainersOptions) error {
+	if options.Delete && !options.Sync {
+		return errdefs.InvalidParameter(errors.New("files can only be deleted when syncing"))
+	}
+
+	// The containers are locked in the order of their IDs, so that concurrent
+	// copies between them in both directions don't deadlock.
+	first, second := src, dst
+	if second.ID < first.ID {
+		first, second = second, first
+	}
+	release, err := daemon.mountContainerFS(first)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if second != first {
+		release, err := daemon.mountContainerFS(second)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+
+	dstPath, err := resolveExtractPath(dst, path)
+	if err != nil {
+		return err
+	}
+
+	if !options.Sync {
+		extractOptions, err := daemon.extractCopyOptions(dst, options.CopyUIDGID, !options.AllowOverwriteDirWithFile)
+		if err != nil {
+			return err
+		}
+		data, _, err := archiveContainerPath(src, sourcePath)
+		if err != nil {
+			return err
+		}
+		defer data.Close()
+		if err := extractArchive(dst.BaseFS, data, dstPath, extractOptions, dst.BaseFS.Path()); err != nil {
+			return err
+		}
+	} else {
+		srcPath, err := resolveDirPath(src, sourcePath)
+		if err != nil {
+			return err
+		}
+		// The directories are walked while chrooted to the filesystems of
+		// the containers, which may be running, so that symlinks that are
+		// swapped in during the walk can't reach files of the host.
+		srcManifest, err := chrootarchive.Manifest(srcPath, src.BaseFS.Path())
+		if err != nil {
+			return err
+		}
+		dstManifest, err := chrootarchive.Manifest(dstPath, dst.BaseFS.Path())
+		if err != nil {
+			return err
+		}
+		if !options.AllowOverwriteDirWithFile {
+			if err := checkOverwriteDirNonDir(dstManifest, srcManifest, path); err != nil {
+				return err
+			}
+		}
+		changes := archive.ManifestChanges(dstManifest, srcManifest)
+		if !options.Delete {
+			n := 0
+			for _, c := range changes {
+				if c.Kind != archive.ChangeDelete {
+					changes[n] = c
+					n++
+				}
+			}
+			changes = changes[:n]
+		}
+		if len(changes) > 0 {
+			extractOptions, err := daemon.extractCopyOptions(dst, options.CopyUIDGID, !options.AllowOverwriteDirWithFile)
+			if err != nil {
+				return err
+			}
+			// The IDs of the files are mapped to the IDs in the containers,
+			// which are mapped back to the IDs on the host when applying.
+			layer, err := chrootarchive.ExportChanges(srcPath, changes, daemon.idMapping, src.BaseFS.Path())
+			if err != nil {
+				return err
+			}
+			defer layer.Close()
+			if _, err := chrootarchive.ApplyUncompressedLayer(dstPath, layer, extractOptions); err != nil {
+				return err
+			}
+		}
+	}
+
+	daemon.LogContainerEvent(src, "archive-path")
+	daemon.LogContainerEvent(dst, "extract-to-dir")
+
+	return nil
+}
+
+// checkOverwriteDirNonDir returns an error if syncing the directory described
+// by newManifest to the directory dir described by oldManifest replaces
+// a directory with a non-directory, or a non-directory with a directory.
+func checkOverwriteDirNonDir(oldManifest, newManifest []archive.ManifestEntry, dir string) error {
+	isDir := make(map[string]bool, len(oldManifest))
+	for _, e := range oldManifest {
+		isDir[e.Path] = e.Mode.IsDir()
+	}
+	for _, e := range newManifest {
+		wasDir, ok := isDir[e.Path]
+		if !ok || wasDir == e.Mode.IsDir() {
+			continue
+		}
+		if wasDir {
+			return errdefs.InvalidParameter(errors.Errorf("cannot overwrite directory %q with non-directory", path.Join(dir, e.Path)))
+		}
+		return errdefs.InvalidParameter(errors.Errorf("cannot overwrite non-directory %q with directory", path.Join(dir, e.Path)))
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package daemon // import "github.com/docker/docker/daemon"
 
 import (
+	"github.com/docker/docker/container"
 	"github.com/docker/docker/pkg/archive"
 )
 
@@ -12,3 +13,15 @@ func (daemon *Daemon) defaultTarCopyOptions(noOverwriteDirNonDir bool) *archive.
 		IDMap:                daemon.idMapping,
 	}
 }
+
+// extractCopyOptions returns the options that are used to unpack an archive
+// to the container for a copy API event. If copyUIDGID is true, the files are
+// owned by the user of the container.
+func (daemon *Daemon) extractCopyOptions(container *container.Container, copyUIDGID, noOverwriteDirNonDir bool) (*archive.TarOptions, error) {
+	if copyUIDGID {
+		// tarCopyOptions will appropriately pull in the right uid/gid for the
+		// user/group and will set the options.
+		return daemon.tarCopyOptions(container, noOverwriteDirNonDir)
+	}
+	return daemon.defaultTarCopyOptions(noOverwriteDirNonDir), nil
+}
@@ -0,0 +1,279 @@
+package cdi // import "github.com/docker/docker/daemon/cdi"
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	coci "github.com/containerd/containerd/oci"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/pkg/errors"
+)
+
+// DefaultSpecDirs are the default directories of the CDI spec files, from
+// the lowest to the highest priority.
+var DefaultSpecDirs = []string{"/etc/cdi", "/var/run/cdi"}
+
+// Registry is a registry of the devices of the CDI spec files of a list of
+// directories.
+type Registry struct {
+	devices map[string]*registryDevice
+	// conflicts are the devices that are described by several spec files
+	// of the same directory, which cannot be resolved.
+	conflicts map[string]bool
+}
+
+type registryDevice struct {
+	spec   *Spec
+	device *Device
+	dir    int
+	path   string
+}
+
+// Load loads the CDI spec files of the given directories, from the lowest to
+// the highest priority, so that a device described by the spec files of
+// several directories is the device of the last directory. Missing
+// directories are skipped. The errors of the spec files that cannot be loaded
+// are returned along with the registry of the other spec files. Only JSON
+// spec files of the SupportedVersions are supported.
+func Load(dirs []string) (*Registry, []error) {
+	r := &Registry{
+		devices:   make(map[string]*registryDevice),
+		conflicts: make(map[string]bool),
+	}
+	var errs []error
+	for i, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				errs = append(errs, errors.Wrap(err, "failed to read CDI spec directory"))
+			}
+			continue
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			path := filepath.Join(dir, e.Name())
+			switch filepath.Ext(e.Name()) {
+			case ".json":
+			case ".yaml", ".yml":
+				errs = append(errs, errors.Errorf("failed to load CDI spec %s: YAML spec files are not supported", path))
+				continue
+			default:
+				continue
+			}
+			spec, err := readSpec(path)
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "failed to load CDI spec %s", path))
+				continue
+			}
+			r.add(spec, i, path)
+		}
+	}
+	return r, errs
+}
+
+func readSpec(path string) (*Spec, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var spec Spec
+	if err := json.Unmarshal(content, &spec); err != nil {
+		return nil, err
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+func (r *Registry) add(spec *Spec, dir int, path string) {
+	for i := range spec.Devices {
+		name := spec.Kind + "=" + spec.Devices[i].Name
+		if other, ok := r.devices[name]; ok && other.dir == dir {
+			r.conflicts[name] = true
+			continue
+		}
+		delete(r.conflicts, name)
+		r.devices[name] = &registryDevice{spec: spec, device: &spec.Devices[i], dir: dir, path: path}
+	}
+}
+
+// DeviceNames returns the sorted fully-qualified names of the devices that can
+// be resolved.
+func (r *Registry) DeviceNames() []string {
+	names := make([]string, 0, len(r.devices))
+	for name := range r.devices {
+		if !r.conflicts[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// InjectDevices applies the edits of the devices with the given
+// fully-qualified names to the OCI spec s. The edits of the spec files of the
+// devices are applied first, once for each spec file.
+func (r *Registry) InjectDevices(s *specs.Spec, names ...string) error {
+	var (
+		devices    []*registryDevice
+		unresolved []string
+		applied    = make(map[*Spec]bool)
+		edits      []*ContainerEdits
+	)
+	for _, name := range names {
+		if _, _, err := ParseQualifiedName(name); err != nil {
+			return err
+		}
+		d, ok := r.devices[name]
+		if !ok || r.conflicts[name] {
+			unresolved = append(unresolved, name)
+			continue
+		}
+		devices = append(devices, d)
+		if !applied[d.spec] {
+			applied[d.spec] = true
+			edits = append(edits, &d.spec.ContainerEdits)
+		}
+	}
+	if len(unresolved) > 0 {
+		return errors.Errorf("unresolvable CDI devices %s", strings.Join(unresolved, ", "))
+	}
+	for _, d := range devices {
+		edits = append(edits, &d.device.ContainerEdits)
+	}
+
+	for _, e := range edits {
+		if err := e.apply(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply applies the edits to the OCI spec s.
+func (e *ContainerEdits) apply(s *specs.Spec) error {
+	if len(e.Env) > 0 {
+		if s.Process == nil {
+			s.Process = &specs.Process{}
+		}
+		s.Process.Env = append(s.Process.Env, e.Env...)
+	}
+
+	for _, d := range e.DeviceNodes {
+		dev, err := d.linuxDevice()
+		if err != nil {
+			return err
+		}
+		if s.Linux == nil {
+			s.Linux = &specs.Linux{}
+		}
+		devices := s.Linux.Devices[:0]
+		for _, other := range s.Linux.Devices {
+			if other.Path != dev.Path {
+				devices = append(devices, other)
+			}
+		}
+		s.Linux.Devices = append(devices, *dev)
+
+		if dev.Type == "p" {
+			continue
+		}
+		if s.Linux.Resources == nil {
+			s.Linux.Resources = &specs.LinuxResources{}
+		}
+		access := d.Permissions
+		if access == "" {
+			access = "rwm"
+		}
+		// Unbuffered character devices are character devices for cgroups.
+		cgroupType := dev.Type
+		if cgroupType == "u" {
+			cgroupType = "c"
+		}
+		major, minor := dev.Major, dev.Minor
+		s.Linux.Resources.Devices = append(s.Linux.Resources.Devices, specs.LinuxDeviceCgroup{
+			Allow:  true,
+			Type:   cgroupType,
+			Major:  &major,
+			Minor:  &minor,
+			Access: access,
+		})
+	}
+
+	for _, m := range e.Mounts {
+		mounts := s.Mounts[:0]
+		for _, other := range s.Mounts {
+			if other.Destination != m.ContainerPath {
+				mounts = append(mounts, other)
+			}
+		}
+		s.Mounts = append(mounts, specs.Mount{
+			Destination: m.ContainerPath,
+			Source:      m.HostPath,
+			Type:        m.Type,
+			Options:     m.Options,
+		})
+	}
+
+	for _, h := range e.Hooks {
+		if s.Hooks == nil {
+			s.Hooks = &specs.Hooks{}
+		}
+		hook := specs.Hook{Path: h.Path, Args: h.Args, Env: h.Env, Timeout: h.Timeout}
+		switch h.HookName {
+		case "prestart":
+			s.Hooks.Prestart = append(s.Hooks.Prestart, hook)
+		case "createRuntime":
+			s.Hooks.CreateRuntime = append(s.Hooks.CreateRuntime, hook)
+		case "createContainer":
+			s.Hooks.CreateContainer = append(s.Hooks.CreateContainer, hook)
+		case "startContainer":
+			s.Hooks.StartContainer = append(s.Hooks.StartContainer, hook)
+		case "poststart":
+			s.Hooks.Poststart = append(s.Hooks.Poststart, hook)
+		case "poststop":
+			s.Hooks.Poststop = append(s.Hooks.Poststop, hook)
+		}
+	}
+	return nil
+}
+
+// linuxDevice returns the device of the device node in the OCI spec. The
+// unset fields are those of the device node on the host.
+func (d *DeviceNode) linuxDevice() (*specs.LinuxDevice, error) {
+	dev := &specs.LinuxDevice{
+		Path:     d.Path,
+		Type:     d.Type,
+		Major:    d.Major,
+		Minor:    d.Minor,
+		FileMode: d.FileMode,
+		UID:      d.UID,
+		GID:      d.GID,
+	}
+	if dev.Type == "" || (dev.Type != "p" && dev.Major == 0 && dev.Minor == 0) {
+		hostPath := d.HostPath
+		if hostPath == "" {
+			hostPath = d.Path
+		}
+		hostDev, err := coci.DeviceFromPath(hostPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get CDI device node %s", hostPath)
+		}
+		if dev.Type == "" {
+			dev.Type = hostDev.Type
+		}
+		if dev.Major == 0 && dev.Minor == 0 {
+			dev.Major, dev.Minor = hostDev.Major, hostDev.Minor
+		}
+		if dev.FileMode == nil {
+			dev.FileMode = hostDev.FileMode
+		}
+	}
+	return dev, nil
+}
@@ -0,0 +1,142 @@
+package cdi // import "github.com/docker/docker/daemon/cdi"
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/skip"
+)
+
+func TestParseQualifiedName(t *testing.T) {
+	kind, name, err := ParseQualifiedName("example.com/fpga=fpga0")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(kind, "example.com/fpga"))
+	assert.Check(t, is.Equal(name, "fpga0"))
+
+	for _, invalid := range []string{
+		"/dev/fpga0",
+		"example.com=fpga0",
+		"example.com/fpga=",
+		"example.com/fp/ga=fpga0",
+		"-example.com/fpga=fpga0",
+		"example.com/fpga=fpga/0",
+	} {
+		assert.Check(t, !IsQualifiedName(invalid), invalid)
+	}
+}
+
+func writeSpec(t *testing.T, dir, name, content string) {
+	t.Helper()
+	assert.NilError(t, os.MkdirAll(dir, 0o755))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+}
+
+func TestLoad(t *testing.T) {
+	tmp := t.TempDir()
+	etc := filepath.Join(tmp, "etc")
+	run := filepath.Join(tmp, "run")
+
+	writeSpec(t, etc, "fpga.json", `{
+		"cdiVersion": "0.5.0",
+		"kind": "example.com/fpga",
+		"devices": [
+			{"name": "fpga0", "containerEdits": {"env": ["FPGA=0"]}},
+			{"name": "fpga1", "containerEdits": {"env": ["FPGA=1"]}}
+		]
+	}`)
+	// The devices of the directories with a higher priority replace the
+	// devices of the other directories.
+	writeSpec(t, run, "fpga.json", `{
+		"cdiVersion": "0.5.0",
+		"kind": "example.com/fpga",
+		"devices": [{"name": "fpga1", "containerEdits": {"env": ["FPGA=override"]}}]
+	}`)
+	// The devices of several spec files of the same directory conflict.
+	writeSpec(t, run, "a.json", `{"cdiVersion": "0.5.0", "kind": "example.com/net", "devices": [{"name": "nic", "containerEdits": {}}]}`)
+	writeSpec(t, run, "b.json", `{"cdiVersion": "0.5.0", "kind": "example.com/net", "devices": [{"name": "nic", "containerEdits": {}}]}`)
+	writeSpec(t, run, "invalid.json", `{"cdiVersion": "0.5.0", "kind": "example.com/bad", "devices": [{"name": "bad", "containerEdits": {"hooks": [{"hookName": "never", "path": "/bin/true"}]}}]}`)
+	writeSpec(t, run, "spec.yaml", "cdiVersion: 0.5.0\n")
+	writeSpec(t, run, "future.json", `{"cdiVersion": "9.9.9", "kind": "example.com/future", "devices": [{"name": "dev", "containerEdits": {}}]}`)
+	writeSpec(t, run, "README", "not a spec")
+
+	r, errs := Load([]string{etc, run, filepath.Join(tmp, "missing")})
+	assert.Assert(t, is.Len(errs, 3))
+	assert.Check(t, is.ErrorContains(errs[0], `future.json: unsupported cdiVersion "9.9.9": supported versions are 0.3.0, 0.4.0, 0.5.0`))
+	assert.Check(t, is.ErrorContains(errs[1], `invalid.json: invalid device "bad": invalid hook name "never"`))
+	assert.Check(t, is.ErrorContains(errs[2], "spec.yaml: YAML spec files are not supported"))
+	assert.Check(t, is.DeepEqual(r.DeviceNames(), []string{"example.com/fpga=fpga0", "example.com/fpga=fpga1"}))
+
+	s := &specs.Spec{Process: &specs.Process{Env: []string{"PATH=/bin"}}}
+	assert.NilError(t, r.InjectDevices(s, "example.com/fpga=fpga1"))
+	assert.Check(t, is.DeepEqual(s.Process.Env, []string{"PATH=/bin", "FPGA=override"}))
+
+	err := r.InjectDevices(s, "example.com/net=nic", "example.com/fpga=fpga2")
+	assert.Check(t, is.Error(err, "unresolvable CDI devices example.com/net=nic, example.com/fpga=fpga2"))
+}
+
+func TestInjectDevices(t *testing.T) {
+	skip.If(t, runtime.GOOS == "windows", "device nodes are not supported on Windows")
+
+	dir := t.TempDir()
+	writeSpec(t, dir, "fpga.json", `{
+		"cdiVersion": "0.5.0",
+		"kind": "example.com/fpga",
+		"containerEdits": {
+			"env": ["FPGA_RUNTIME=1"],
+			"mounts": [{"hostPath": "/opt/fpga/lib", "containerPath": "/usr/lib/fpga", "options": ["ro", "bind"]}],
+			"hooks": [{"hookName": "createContainer", "path": "/usr/bin/fpga-hook", "args": ["fpga-hook", "create"]}]
+		},
+		"devices": [
+			{
+				"name": "fpga0",
+				"containerEdits": {
+					"env": ["FPGA_VISIBLE=0"],
+					"deviceNodes": [{"path": "/dev/fpga0", "hostPath": "/dev/null", "permissions": "rw"}]
+				}
+			},
+			{
+				"name": "fpga1",
+				"containerEdits": {
+					"env": ["FPGA_VISIBLE=1"],
+					"deviceNodes": [{"path": "/dev/fpga1", "type": "c", "major": 240, "minor": 1}]
+				}
+			}
+		]
+	}`)
+	r, errs := Load([]string{dir})
+	assert.Assert(t, is.Len(errs, 0))
+
+	s := &specs.Spec{
+		Process: &specs.Process{},
+		Linux:   &specs.Linux{Resources: &specs.LinuxResources{}},
+		Mounts:  []specs.Mount{{Destination: "/usr/lib/fpga", Source: "/tmp", Type: "bind"}},
+	}
+	assert.NilError(t, r.InjectDevices(s, "example.com/fpga=fpga0", "example.com/fpga=fpga1"))
+
+	// The edits of the spec file are only applied once.
+	assert.Check(t, is.DeepEqual(s.Process.Env, []string{"FPGA_RUNTIME=1", "FPGA_VISIBLE=0", "FPGA_VISIBLE=1"}))
+	assert.Check(t, is.DeepEqual(s.Mounts, []specs.Mount{{Destination: "/usr/lib/fpga", Source: "/opt/fpga/lib", Options: []string{"ro", "bind"}}}))
+	assert.Assert(t, is.Len(s.Hooks.CreateContainer, 1))
+	assert.Check(t, is.Equal(s.Hooks.CreateContainer[0].Path, "/usr/bin/fpga-hook"))
+
+	// The device node of fpga0 is the device node of /dev/null (1:3).
+	assert.Assert(t, is.Len(s.Linux.Devices, 2))
+	assert.Check(t, is.Equal(s.Linux.Devices[0].Path, "/dev/fpga0"))
+	assert.Check(t, is.Equal(s.Linux.Devices[0].Type, "c"))
+	assert.Check(t, is.Equal(s.Linux.Devices[0].Major, int64(1)))
+	assert.Check(t, is.Equal(s.Linux.Devices[0].Minor, int64(3)))
+	assert.Check(t, s.Linux.Devices[0].FileMode != nil)
+	assert.Check(t, is.Equal(s.Linux.Devices[1].Path, "/dev/fpga1"))
+	assert.Check(t, is.Equal(s.Linux.Devices[1].Major, int64(240)))
+
+	assert.Assert(t, is.Len(s.Linux.Resources.Devices, 2))
+	assert.Check(t, is.Equal(s.Linux.Resources.Devices[0].Access, "rw"))
+	assert.Check(t, is.Equal(*s.Linux.Resources.Devices[0].Major, int64(1)))
+	assert.Check(t, is.Equal(s.Linux.Resources.Devices[1].Access, "rwm"))
+	assert.Check(t, is.Equal(*s.Linux.Resources.Devices[1].Minor, int64(1)))
+}
@@ -0,0 +1,217 @@
+// Package cdi implements the Container Device Interface (CDI), which describes
+// devices with spec files that list the edits of the OCI spec of containers
+// that give them access to the devices.
+//
+// See https://github.com/container-orchestrated-devices/container-device-interface
+package cdi // import "github.com/docker/docker/daemon/cdi"
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Spec is a CDI spec file, which describes the devices of a kind.
+type Spec struct {
+	Version string `json:"cdiVersion"`
+	// Kind is the kind of the devices, as "vendor/class".
+	Kind    string   `json:"kind"`
+	Devices []Device `json:"devices"`
+	// ContainerEdits are applied once to containers that use any of the
+	// devices of the spec.
+	ContainerEdits ContainerEdits `json:"containerEdits,omitempty"`
+}
+
+// Device is a device of a CDI spec.
+type Device struct {
+	Name           string         `json:"name"`
+	ContainerEdits ContainerEdits `json:"containerEdits"`
+}
+
+// ContainerEdits are the edits of the OCI spec of a container for a device.
+type ContainerEdits struct {
+	Env         []string      `json:"env,omitempty"`
+	DeviceNodes []*DeviceNode `json:"deviceNodes,omitempty"`
+	Hooks       []*Hook       `json:"hooks,omitempty"`
+	Mounts      []*Mount      `json:"mounts,omitempty"`
+}
+
+// DeviceNode is a device node to create in a container. The type and the
+// major and minor numbers are those of the device node at HostPath if unset.
+type DeviceNode struct {
+	// Path is the path of the device node in the container.
+	Path string `json:"path"`
+	// HostPath is the path of the device node on the host, which is Path if
+	// unset.
+	HostPath string       `json:"hostPath,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Major    int64        `json:"major,omitempty"`
+	Minor    int64        `json:"minor,omitempty"`
+	FileMode *os.FileMode `json:"fileMode,omitempty"`
+	// Permissions are the cgroup permissions of the device, which are "rwm"
+	// if unset.
+	Permissions string  `json:"permissions,omitempty"`
+	UID         *uint32 `json:"uid,omitempty"`
+	GID         *uint32 `json:"gid,omitempty"`
+}
+
+// Hook is an OCI hook to run for a container.
+type Hook struct {
+	// HookName is the name of the OCI hook, such as "prestart" or
+	// "createContainer".
+	HookName string   `json:"hookName"`
+	Path     string   `json:"path"`
+	Args     []string `json:"args,omitempty"`
+	Env      []string `json:"env,omitempty"`
+	Timeout  *int     `json:"timeout,omitempty"`
+}
+
+// Mount is a mount to add to a container.
+type Mount struct {
+	HostPath      string   `json:"hostPath"`
+	ContainerPath string   `json:"containerPath"`
+	Type          string   `json:"type,omitempty"`
+	Options       []string `json:"options,omitempty"`
+}
+
+var (
+	// vendorRegexp matches the vendor of a kind, which is a domain name.
+	vendorRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9_.-]*[a-zA-Z0-9])?$`)
+	// classRegexp matches the class of a kind.
+	classRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9_-]*[a-zA-Z0-9])?$`)
+	// deviceNameRegexp matches the name of a device.
+	deviceNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9_.:-]*[a-zA-Z0-9])?$`)
+)
+
+// hookNames are the names of the OCI hooks.
+var hookNames = map[string]bool{
+	"prestart":        true,
+	"createRuntime":   true,
+	"createContainer": true,
+	"startContainer":  true,
+	"poststart":       true,
+	"poststop":        true,
+}
+
+// IsQualifiedName returns whether name is a fully-qualified device name, as
+// "vendor/class=name".
+func IsQualifiedName(name string) bool {
+	_, _, err := ParseQualifiedName(name)
+	return err == nil
+}
+
+// ParseQualifiedName parses a fully-qualified device name, as
+// "vendor/class=name", into the kind of the device and its name.
+func ParseQualifiedName(name string) (kind, device string, err error) {
+	parts := strings.SplitN(name, "=", 2)
+	if len(parts) != 2 {
+		return "", "", errors.Errorf("invalid CDI device name %q: must be vendor/class=name", name)
+	}
+	kind, device = parts[0], parts[1]
+	if err := validateKind(kind); err != nil {
+		return "", "", errors.Wrapf(err, "invalid CDI device name %q", name)
+	}
+	if !deviceNameRegexp.MatchString(device) {
+		return "", "", errors.Errorf("invalid CDI device name %q: invalid name %q", name, device)
+	}
+	return kind, device, nil
+}
+
+func validateKind(kind string) error {
+	parts := strings.SplitN(kind, "/", 2)
+	if len(parts) != 2 {
+		return errors.Errorf("invalid kind %q: must be vendor/class", kind)
+	}
+	if !vendorRegexp.MatchString(parts[0]) {
+		return errors.Errorf("invalid kind %q: invalid vendor %q", kind, parts[0])
+	}
+	if !classRegexp.MatchString(parts[1]) {
+		return errors.Errorf("invalid kind %q: invalid class %q", kind, parts[1])
+	}
+	return nil
+}
+
+// SupportedVersions are the versions of the CDI spec files that are
+// supported, which have the container edits of ContainerEdits.
+var SupportedVersions = []string{"0.3.0", "0.4.0", "0.5.0"}
+
+// Validate validates the spec.
+func (s *Spec) Validate() error {
+	if s.Version == "" {
+		return errors.New("cdiVersion is required")
+	}
+	if !isSupportedVersion(s.Version) {
+		return errors.Errorf("unsupported cdiVersion %q: supported versions are %s", s.Version, strings.Join(SupportedVersions, ", "))
+	}
+	if err := validateKind(s.Kind); err != nil {
+		return err
+	}
+	if len(s.Devices) == 0 {
+		return errors.New("no devices")
+	}
+	if err := s.ContainerEdits.validate(); err != nil {
+		return err
+	}
+	names := make(map[string]bool, len(s.Devices))
+	for _, d := range s.Devices {
+		if !deviceNameRegexp.MatchString(d.Name) {
+			return errors.Errorf("invalid device name %q", d.Name)
+		}
+		if names[d.Name] {
+			return errors.Errorf("duplicate device %q", d.Name)
+		}
+		names[d.Name] = true
+		if err := d.ContainerEdits.validate(); err != nil {
+			return errors.Wrapf(err, "invalid device %q", d.Name)
+		}
+	}
+	return nil
+}
+
+func isSupportedVersion(version string) bool {
+	for _, v := range SupportedVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *ContainerEdits) validate() error {
+	for _, env := range e.Env {
+		if !strings.Contains(env, "=") {
+			return errors.Errorf("invalid environment variable %q", env)
+		}
+	}
+	for _, d := range e.DeviceNodes {
+		if d.Path == "" {
+			return errors.New("device node without path")
+		}
+		switch d.Type {
+		case "", "b", "c", "u", "p":
+		default:
+			return errors.Errorf("invalid type %q of device node %s", d.Type, d.Path)
+		}
+		for _, p := range d.Permissions {
+			if !strings.ContainsRune("rwm", p) {
+				return errors.Errorf("invalid permissions %q of device node %s", d.Permissions, d.Path)
+			}
+		}
+	}
+	for _, h := range e.Hooks {
+		if !hookNames[h.HookName] {
+			return errors.Errorf("invalid hook name %q", h.HookName)
+		}
+		if h.Path == "" {
+			return errors.Errorf("%s hook without path", h.HookName)
+		}
+	}
+	for _, m := range e.Mounts {
+		if m.HostPath == "" || m.ContainerPath == "" {
+			return errors.New("mount without host path or container path")
+		}
+	}
+	return nil
+}
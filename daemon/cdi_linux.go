@@ -0,0 +1,52 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"github.com/docker/docker/daemon/cdi"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/capabilities"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// cdiDeviceDriver returns the "cdi" device driver of the daemon, which
+// injects the CDI devices with the fully-qualified names of the DeviceIDs of
+// device requests.
+func (daemon *Daemon) cdiDeviceDriver() *deviceDriver {
+	return &deviceDriver{
+		capset: capabilities.Set{"cdi": struct{}{}},
+		updateSpec: func(s *specs.Spec, dev *deviceInstance) error {
+			if dev.req.Count != 0 {
+				return errdefs.InvalidParameter(errors.New("cannot set Count on CDI device request"))
+			}
+			return daemon.injectCDIDevices(s, dev.req.DeviceIDs...)
+		},
+		allowNoCapabilities: true,
+	}
+}
+
+// cdiSpecDirs returns the directories of the CDI spec files.
+func (daemon *Daemon) cdiSpecDirs() []string {
+	if dirs := daemon.configStore.CDISpecDirs; dirs != nil {
+		return dirs
+	}
+	return cdi.DefaultSpecDirs
+}
+
+// injectCDIDevices applies the edits of the CDI devices with the given
+// fully-qualified names to the OCI spec s. The CDI spec files are loaded
+// every time, so that the devices that are added or removed while the daemon
+// is running are taken into account.
+func (daemon *Daemon) injectCDIDevices(s *specs.Spec, names ...string) error {
+	if len(names) == 0 {
+		return errdefs.InvalidParameter(errors.New("no CDI devices requested"))
+	}
+	registry, errs := cdi.Load(daemon.cdiSpecDirs())
+	for _, err := range errs {
+		logrus.WithError(err).Warn("Ignoring invalid CDI spec")
+	}
+	if err := registry.InjectDevices(s, names...); err != nil {
+		return errdefs.InvalidParameter(err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	coci "github.com/containerd/containerd/oci"
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/config"
+	"github.com/docker/docker/errdefs"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func setupCDIDaemon(t *testing.T) *Daemon {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "fpga.json"), []byte(`{
+		"cdiVersion": "0.5.0",
+		"kind": "example.com/fpga",
+		"devices": [
+			{"name": "fpga0", "containerEdits": {"env": ["FPGA=0"], "deviceNodes": [{"path": "/dev/fpga0", "type": "c", "major": 240, "minor": 0}]}},
+			{"name": "fpga1", "containerEdits": {"env": ["FPGA=1"], "deviceNodes": [{"path": "/dev/fpga1", "type": "c", "major": 240, "minor": 1}]}}
+		]
+	}`), 0o644)
+	assert.NilError(t, err)
+
+	d := &Daemon{configStore: &config.Config{}}
+	d.configStore.CDISpecDirs = []string{dir}
+	return d
+}
+
+func TestCDIDeviceRequest(t *testing.T) {
+	d := setupCDIDaemon(t)
+
+	s := &specs.Spec{Process: &specs.Process{}, Linux: &specs.Linux{Resources: &specs.LinuxResources{}}}
+	err := d.handleDevice(containertypes.DeviceRequest{Driver: "cdi", DeviceIDs: []string{"example.com/fpga=fpga1"}}, s)
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(s.Process.Env, []string{"FPGA=1"}))
+	assert.Assert(t, is.Len(s.Linux.Devices, 1))
+	assert.Check(t, is.Equal(s.Linux.Devices[0].Path, "/dev/fpga1"))
+
+	err = d.handleDevice(containertypes.DeviceRequest{Driver: "cdi", DeviceIDs: []string{"example.com/fpga=fpga2"}}, s)
+	assert.Check(t, errdefs.IsInvalidParameter(err))
+	assert.Check(t, is.ErrorContains(err, "unresolvable CDI devices example.com/fpga=fpga2"))
+
+	err = d.handleDevice(containertypes.DeviceRequest{Driver: "cdi", Count: -1}, s)
+	assert.Check(t, errdefs.IsInvalidParameter(err))
+
+	// Requests that do not name the driver must request its capability.
+	err = d.handleDevice(containertypes.DeviceRequest{Capabilities: [][]string{{"cdi"}}, DeviceIDs: []string{"example.com/fpga=fpga0"}}, s)
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(s.Process.Env, []string{"FPGA=1", "FPGA=0"}))
+}
+
+func TestCDIDevices(t *testing.T) {
+	d := setupCDIDaemon(t)
+
+	c := &container.Container{
+		HostConfig: &containertypes.HostConfig{
+			Resources: containertypes.Resources{
+				Devices: []containertypes.DeviceMapping{
+					{PathOnHost: "example.com/fpga=fpga0", CgroupPermissions: "rwm"},
+					{PathOnHost: "example.com/fpga=fpga1", CgroupPermissions: "rwm"},
+				},
+			},
+		},
+	}
+	s := &coci.Spec{Process: &specs.Process{}, Linux: &specs.Linux{Resources: &specs.LinuxResources{}}}
+	err := WithDevices(d, c)(context.Background(), nil, nil, s)
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(s.Process.Env, []string{"FPGA=0", "FPGA=1"}))
+	assert.Assert(t, is.Len(s.Linux.Devices, 2))
+	assert.Assert(t, is.Len(s.Linux.Resources.Devices, 2))
+	assert.Check(t, is.Equal(*s.Linux.Resources.Devices[1].Minor, int64(1)))
+}
+
+func TestVerifyCDIDevices(t *testing.T) {
+	testCases := []struct {
+		doc     string
+		devices []containertypes.DeviceMapping
+		reqs    []containertypes.DeviceRequest
+		err     string
+	}{
+		{
+			doc: "valid devices",
+			devices: []containertypes.DeviceMapping{
+				{PathOnHost: "example.com/fpga=fpga0"},
+				{PathOnHost: "example.com/fpga=fpga1", PathInContainer: "example.com/fpga=fpga1", CgroupPermissions: "rwm"},
+				{PathOnHost: "/dev/fpga=0", PathInContainer: "/dev/fpga", CgroupPermissions: "r"},
+			},
+			reqs: []containertypes.DeviceRequest{
+				{Driver: "cdi", DeviceIDs: []string{"example.com/fpga=fpga0"}},
+				{Driver: "nvidia", Count: -1},
+			},
+		},
+		{
+			doc:     "invalid device name",
+			devices: []containertypes.DeviceMapping{{PathOnHost: "fpga=fpga0"}},
+			err:     `invalid CDI device name "fpga=fpga0"`,
+		},
+		{
+			doc:     "path in container",
+			devices: []containertypes.DeviceMapping{{PathOnHost: "example.com/fpga=fpga0", PathInContainer: "/dev/fpga"}},
+			err:     "cannot set the path in the container of CDI device example.com/fpga=fpga0",
+		},
+		{
+			doc:     "cgroup permissions",
+			devices: []containertypes.DeviceMapping{{PathOnHost: "example.com/fpga=fpga0", CgroupPermissions: "r"}},
+			err:     "cannot set the cgroup permissions of CDI device example.com/fpga=fpga0",
+		},
+		{
+			doc:  "invalid device request name",
+			reqs: []containertypes.DeviceRequest{{Capabilities: [][]string{{"cdi"}}, DeviceIDs: []string{"fpga0"}}},
+			err:  `invalid CDI device name "fpga0"`,
+		},
+		{
+			doc:  "device request count",
+			reqs: []containertypes.DeviceRequest{{Driver: "cdi", Count: -1}},
+			err:  "cannot set Count on CDI device request",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.doc, func(t *testing.T) {
+			err := verifyCDIDevices(&containertypes.HostConfig{
+				Resources: containertypes.Resources{Devices: tc.devices, DeviceRequests: tc.reqs},
+			})
+			if tc.err == "" {
+				assert.Check(t, err)
+			} else {
+				assert.Check(t, is.ErrorContains(err, tc.err))
+			}
+		})
+	}
+}
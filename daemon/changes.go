@@ -1,10 +1,24 @@
 package daemon // import "github.com/docker/docker/daemon"
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/docker/docker/api/types/backend"
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/builder"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/layer"
 	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/sirupsen/logrus"
 )
 
 // ContainerChanges returns a list of container fs changes
@@ -31,3 +45,175 @@ func (daemon *Daemon) ContainerChanges(name string) ([]archive.Change, error) {
 	containerActions.WithValues("changes").UpdateSince(start)
 	return c, nil
 }
+
+// ContainerChangesDetails writes the changes of the filesystem of a container
+// to config.OutStream as a stream of JSON objects, with the details of the
+// files before and after each change. The container is compared with its
+// image, or with config.Image if it is set.
+func (daemon *Daemon) ContainerChangesDetails(ctx context.Context, name string, config *backend.ContainerChangesConfig) error {
+	start := time.Now()
+	ctr, err := daemon.GetContainer(name)
+	if err != nil {
+		return err
+	}
+
+	if isWindows {
+		return errdefs.NotImplemented(errors.New("Windows does not support detailed diff of containers"))
+	}
+
+	imageRef := config.Image
+	if imageRef == "" {
+		imageRef = ctr.ImageID.String()
+	}
+	_, roLayer, err := daemon.imageService.GetImageAndReleasableLayer(ctx, imageRef, backend.GetImageAndLayerOptions{
+		PullOption: backend.PullOptionNoPull,
+	})
+	if err != nil {
+		return err
+	}
+	defer roLayer.Release()
+
+	outStream := ioutils.NewWriteFlusher(config.OutStream)
+	defer outStream.Close()
+	// Write an empty chunk of data so that the client receives the headers
+	// before the changes, which may take long to compute.
+	outStream.Flush()
+
+	enc := json.NewEncoder(outStream)
+	if err := daemon.containerChangesDetails(ctx, ctr, roLayer, config, func(change containertypes.FilesystemChange) error {
+		return enc.Encode(change)
+	}); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	containerActions.WithValues("changes").UpdateSince(start)
+	return nil
+}
+
+// containerChangesDetails calls fn with each change of the filesystem of the
+// container, with the details of the files before and after the change. The
+// container is only locked while it is mounted and its changes are listed,
+// so that it is not held while the files are hashed and the changes are sent
+// to the client. The image is only mounted if it is compared with the
+// container, or if files of the image were changed.
+func (daemon *Daemon) containerChangesDetails(ctx context.Context, ctr *container.Container, roLayer builder.ROLayer, config *backend.ContainerChangesConfig, fn func(containertypes.FilesystemChange) error) error {
+	rwLayer, root, changes, err := mountContainerChanges(ctr, config.Image == "")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := rwLayer.Unmount(); err != nil {
+			logrus.WithField("container", ctr.ID).WithError(err).Error("error unmounting container")
+		}
+	}()
+
+	needImage := config.Image != ""
+	if !needImage {
+		changes = filterChanges(changes, config.Paths)
+		for _, c := range changes {
+			if c.Kind != archive.ChangeAdd {
+				needImage = true
+				break
+			}
+		}
+	}
+
+	var imageRoot string
+	if needImage {
+		imageLayer, err := roLayer.NewRWLayer()
+		if err != nil {
+			return errdefs.System(err)
+		}
+		defer imageLayer.Release()
+		imageRoot = imageLayer.Root().Path()
+
+		if config.Image != "" {
+			changes, err = archive.ChangesDirs(root, imageRoot)
+			if err != nil {
+				return errdefs.System(err)
+			}
+			changes = filterChanges(changes, config.Paths)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+
+	for _, c := range changes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		change := containertypes.FilesystemChange{Path: c.Path, Kind: uint8(c.Kind)}
+		if c.Kind != archive.ChangeAdd {
+			change.Old, err = daemon.changedFileInfo(imageRoot, c.Path, config.Digests)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if c.Kind != archive.ChangeDelete {
+			change.New, err = daemon.changedFileInfo(root, c.Path, config.Digests)
+			if err != nil {
+				if !os.IsNotExist(err) {
+					return err
+				}
+				// The file was removed after the changes were computed.
+				logrus.WithField("container", ctr.ID).Debugf("skipping removed file %s in changes", c.Path)
+				continue
+			}
+		}
+		if err := fn(change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mountContainerChanges mounts the RW layer of the container under the lock
+// of the container, and lists its changes if listChanges is true. The layer
+// must be unmounted by the caller. It stays mounted even if the container is
+// stopped while its changes are read, as mounts of layers are counted.
+func mountContainerChanges(ctr *container.Container, listChanges bool) (layer.RWLayer, string, []archive.Change, error) {
+	ctr.Lock()
+	defer ctr.Unlock()
+	rwLayer := ctr.RWLayer
+	if rwLayer == nil {
+		return nil, "", nil, errors.New("RWLayer of container " + ctr.ID + " is unexpectedly nil")
+	}
+	dir, err := rwLayer.Mount(ctr.GetMountLabel())
+	if err != nil {
+		return nil, "", nil, errdefs.System(err)
+	}
+	var changes []archive.Change
+	if listChanges {
+		changes, err = rwLayer.Changes()
+		if err != nil {
+			rwLayer.Unmount()
+			return nil, "", nil, errdefs.System(err)
+		}
+	}
+	return rwLayer, dir.Path(), changes, nil
+}
+
+// filterChanges returns the changes of files in the given paths, or all
+// changes if no paths are given.
+func filterChanges(changes []archive.Change, paths []string) []archive.Change {
+	if len(paths) == 0 {
+		return changes
+	}
+	prefixes := make([]string, 0, len(paths))
+	for _, p := range paths {
+		prefixes = append(prefixes, filepath.Clean(string(filepath.Separator)+p))
+	}
+
+	var filtered []archive.Change
+	for _, c := range changes {
+		for _, prefix := range prefixes {
+			if prefix == string(filepath.Separator) || c.Path == prefix || strings.HasPrefix(c.Path, prefix+string(filepath.Separator)) {
+				filtered = append(filtered, c)
+				break
+			}
+		}
+	}
+	return filtered
+}
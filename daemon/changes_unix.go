@@ -0,0 +1,83 @@
+//go:build !windows
+// +build !windows
+
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/moby/sys/symlink"
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// changedFileInfo returns the details of the file at the given path in the
+// filesystem at root, with the ownership of the file in the container.
+func (daemon *Daemon) changedFileInfo(root, path string, digests bool) (*containertypes.FilesystemChangeFile, error) {
+	// The parent directories are resolved in the scope of the root, as they
+	// may have been replaced by symlinks since the changes were computed.
+	dir, err := symlink.FollowSymlinkInScope(filepath.Join(root, filepath.Dir(path)), root)
+	if err != nil {
+		return nil, err
+	}
+	fullPath := filepath.Join(dir, filepath.Base(path))
+
+	fi, err := os.Lstat(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil, errors.Errorf("unexpected stat of %s", path)
+	}
+	uid, gid, err := daemon.idMapping.ToContainer(idtools.Identity{UID: int(st.Uid), GID: int(st.Gid)})
+	if err != nil {
+		// Files that are owned by IDs outside of the mapping are reported
+		// with their IDs on the host.
+		uid, gid = int(st.Uid), int(st.Gid)
+	}
+
+	file := &containertypes.FilesystemChangeFile{
+		Size:  fi.Size(),
+		Mode:  fi.Mode(),
+		UID:   uid,
+		GID:   gid,
+		Mtime: fi.ModTime(),
+	}
+	switch {
+	case fi.IsDir():
+		file.Size = 0
+	case fi.Mode()&os.ModeSymlink != 0:
+		if file.LinkTarget, err = os.Readlink(fullPath); err != nil {
+			return nil, err
+		}
+	case fi.Mode().IsRegular() && digests:
+		if file.Digest, err = regularFileDigest(fullPath); err != nil {
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+// regularFileDigest returns the digest of the content of a regular file,
+// without following symlinks or blocking on special files that replaced it.
+func regularFileDigest(path string) (digest.Digest, error) {
+	f, err := os.OpenFile(path, os.O_RDONLY|unix.O_NOFOLLOW|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if !fi.Mode().IsRegular() {
+		return "", errors.Errorf("%s is not a regular file", path)
+	}
+	return digest.FromReader(f)
+}
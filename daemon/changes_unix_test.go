@@ -0,0 +1,69 @@
+//go:build !windows
+// +build !windows
+
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/pkg/archive"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestFilterChanges(t *testing.T) {
+	changes := []archive.Change{
+		{Path: "/etc", Kind: archive.ChangeModify},
+		{Path: "/etc/passwd", Kind: archive.ChangeModify},
+		{Path: "/etcetera", Kind: archive.ChangeAdd},
+		{Path: "/tmp/foo", Kind: archive.ChangeDelete},
+	}
+
+	assert.Check(t, is.DeepEqual(filterChanges(changes, nil), changes))
+	assert.Check(t, is.DeepEqual(filterChanges(changes, []string{"/"}), changes))
+	assert.Check(t, is.DeepEqual(filterChanges(changes, []string{"etc/"}), changes[:2]))
+	assert.Check(t, is.DeepEqual(filterChanges(changes, []string{"/etc/passwd", "/tmp"}), []archive.Change{changes[1], changes[3]}))
+	assert.Check(t, is.Len(filterChanges(changes, []string{"/var"}), 0))
+}
+
+func TestChangedFileInfo(t *testing.T) {
+	root := t.TempDir()
+	assert.NilError(t, os.MkdirAll(filepath.Join(root, "etc"), 0755))
+	assert.NilError(t, os.WriteFile(filepath.Join(root, "etc", "hostname"), []byte("hello"), 0640))
+	assert.NilError(t, os.Symlink("hostname", filepath.Join(root, "etc", "link")))
+	// A parent directory that is replaced by a symlink is resolved in the
+	// scope of the root.
+	assert.NilError(t, os.Symlink("/etc", filepath.Join(root, "escape")))
+
+	d := &Daemon{}
+
+	file, err := d.changedFileInfo(root, "/etc/hostname", true)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(file.Size, int64(5)))
+	assert.Check(t, is.Equal(file.Mode, os.FileMode(0640)))
+	assert.Check(t, is.Equal(file.UID, os.Getuid()))
+	assert.Check(t, is.Equal(file.Digest.String(), "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"))
+
+	file, err = d.changedFileInfo(root, "/etc/hostname", false)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(file.Digest.String(), ""))
+
+	file, err = d.changedFileInfo(root, "/etc/link", true)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(file.LinkTarget, "hostname"))
+	assert.Check(t, is.Equal(file.Digest.String(), ""))
+
+	file, err = d.changedFileInfo(root, "/etc", true)
+	assert.NilError(t, err)
+	assert.Check(t, file.Mode.IsDir())
+	assert.Check(t, is.Equal(file.Size, int64(0)))
+
+	file, err = d.changedFileInfo(root, "/escape/hostname", true)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(file.Size, int64(5)))
+
+	_, err = d.changedFileInfo(root, "/etc/missing", true)
+	assert.Check(t, os.IsNotExist(err))
+}
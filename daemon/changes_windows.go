@@ -0,0 +1,11 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+)
+
+func (daemon *Daemon) changedFileInfo(root, path string, digests bool) (*containertypes.FilesystemChangeFile, error) {
+	return nil, errdefs.NotImplemented(errors.New("Windows does not support detailed diff of containers"))
+}
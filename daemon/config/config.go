@@ -10,6 +10,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/docker/docker/api/server/middleware"
 	"github.com/docker/docker/opts"
 	"github.com/docker/docker/pkg/authorization"
 	"github.com/docker/docker/registry"
@@ -82,14 +83,23 @@ var flatOptions = map[string]bool{
 	"default-ulimits":    true,
 	"features":           true,
 	"builder":            true,
+	"credential-helpers": true,
+	"rbac":               true,
+	"audit":              true,
+	"rate-limits":        true,
+	"container-metrics":  true,
 }
 
 // skipValidateOptions contains configuration keys
 // that will be skipped from findConfigurationConflicts
 // for unknown flag validation.
 var skipValidateOptions = map[string]bool{
-	"features": true,
-	"builder":  true,
+	"features":          true,
+	"builder":           true,
+	"rbac":              true,
+	"audit":             true,
+	"rate-limits":       true,
+	"container-metrics": true,
 	// Corresponding flag has been removed because it was already unusable
 	"deprecated-key-path": true,
 }
@@ -210,6 +220,35 @@ type CommonConfig struct {
 	// may take place at a time for each push.
 	MaxDownloadAttempts *int `json:"max-download-attempts,omitempty"`
 
+	// LazyPullLayerStore is the path at which an additional layer store
+	// filesystem (such as stargz-store) is mounted. If set, eStargz layers
+	// are pulled lazily through this filesystem instead of being downloaded
+	// in full.
+	LazyPullLayerStore string `json:"lazy-pull-layer-store,omitempty"`
+
+	// ImagePolicy is the path of the image signature policy. If set, images
+	// are only pulled, and containers are only created from images, that
+	// satisfy the requirements of the policy.
+	ImagePolicy string `json:"image-policy,omitempty"`
+
+	// RBAC is the configuration of the role-based access control of the
+	// API. If set, requests are only allowed if they are allowed by the
+	// roles of their clients.
+	RBAC *middleware.RBACConfig `json:"rbac,omitempty"`
+
+	// Audit is the configuration of the audit log of the API. If set,
+	// requests to the API are recorded in the audit log.
+	Audit *middleware.AuditConfig `json:"audit,omitempty"`
+
+	// RateLimits is the configuration of the rate limits of the API. If
+	// set, requests of clients that exceed the limits are rejected.
+	RateLimits *middleware.RateLimitConfig `json:"rate-limits,omitempty"`
+
+	// ContainerMetrics is the configuration of the per-container metrics of
+	// the metrics API. If set, the resource usage of the running containers
+	// is exported along with the metrics of the daemon.
+	ContainerMetrics *ContainerMetricsConfig `json:"container-metrics,omitempty"`
+
 	// ShutdownTimeout is the timeout value (in seconds) the daemon will wait for the container
 	// to stop when daemon is being shutdown
 	ShutdownTimeout int `json:"shutdown-timeout,omitempty"`
@@ -616,6 +655,30 @@ func Validate(config *Config) error {
 		}
 	}
 
+	if config.RBAC != nil {
+		if err := config.RBAC.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if config.Audit != nil {
+		if err := config.Audit.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if config.RateLimits != nil {
+		if err := config.RateLimits.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if config.ContainerMetrics != nil {
+		if err := config.ContainerMetrics.Validate(); err != nil {
+			return err
+		}
+	}
+
 	// validate platform-specific settings
 	return config.ValidatePlatformConfig()
 }
@@ -70,6 +70,9 @@ type Config struct {
 	NoNewPrivileges      bool                     `json:"no-new-privileges,omitempty"`
 	IpcMode              string                   `json:"default-ipc-mode,omitempty"`
 	CgroupNamespaceMode  string                   `json:"default-cgroupns-mode,omitempty"`
+	// CDISpecDirs are the directories of the Container Device Interface (CDI)
+	// spec files, from the lowest to the highest priority.
+	CDISpecDirs []string `json:"cdi-spec-dirs,omitempty"`
 	// ResolvConf is the path to the configuration of the host resolver
 	ResolvConf string `json:"resolv-conf,omitempty"`
 	Rootless   bool   `json:"rootless,omitempty"`
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/docker/docker/api/server/middleware"
 	"github.com/docker/docker/libnetwork/ipamutils"
 	"github.com/docker/docker/opts"
 	"github.com/spf13/pflag"
@@ -177,6 +178,29 @@ func TestDaemonConfigurationMergeDefaultAddressPools(t *testing.T) {
 	})
 }
 
+func TestDaemonConfigurationRBAC(t *testing.T) {
+	configFile := fs.NewFile(t, "config", fs.WithContent(`{"rbac": {"roles": {"admin": {"rules": [{"routes": ["*"]}]}}, "bindings": [{"uid": 0, "roles": ["admin"]}]}}`))
+	defer configFile.Remove()
+
+	config, err := MergeDaemonConfigurations(&Config{}, pflag.NewFlagSet("test", pflag.ContinueOnError), configFile.Path())
+	assert.NilError(t, err)
+	assert.Assert(t, config.RBAC != nil)
+	assert.Check(t, is.Len(config.RBAC.Bindings, 1))
+	assert.Check(t, is.DeepEqual(config.RBAC.Roles["admin"].Rules[0].Routes, []string{"*"}))
+}
+
+func TestDaemonConfigurationAudit(t *testing.T) {
+	configFile := fs.NewFile(t, "config", fs.WithContent(`{"audit": {"output": "/var/log/docker-audit.log", "max-size": "10m", "max-file": 3, "routes": ["POST /containers/*"]}}`))
+	defer configFile.Remove()
+
+	config, err := MergeDaemonConfigurations(&Config{}, pflag.NewFlagSet("test", pflag.ContinueOnError), configFile.Path())
+	assert.NilError(t, err)
+	assert.Assert(t, config.Audit != nil)
+	assert.Check(t, is.Equal(config.Audit.Output, "/var/log/docker-audit.log"))
+	assert.Check(t, is.Equal(config.Audit.MaxFile, 3))
+	assert.Check(t, is.DeepEqual(config.Audit.Routes, []string{"POST /containers/*"}))
+}
+
 func TestFindConfigurationConflictsWithUnknownKeys(t *testing.T) {
 	config := map[string]interface{}{"tls-verify": "true"}
 	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
@@ -345,6 +369,48 @@ func TestValidateConfigurationErrors(t *testing.T) {
 			},
 			expectedErr: "invalid bind address (127.0.0.1:2375/path): should not contain a path element",
 		},
+		{
+			name: "with rbac binding to unknown role",
+			config: &Config{
+				CommonConfig: CommonConfig{
+					RBAC: &middleware.RBACConfig{
+						Bindings: []middleware.RBACBinding{{User: "alice", Roles: []string{"admin"}}},
+					},
+				},
+			},
+			expectedErr: `invalid rbac binding 0: unknown role "admin"`,
+		},
+		{
+			name: "with audit log without output",
+			config: &Config{
+				CommonConfig: CommonConfig{
+					Audit: &middleware.AuditConfig{MaxSize: "10m"},
+				},
+			},
+			expectedErr: "invalid audit configuration: output is required",
+		},
+		{
+			name: "with rate limit without routes",
+			config: &Config{
+				CommonConfig: CommonConfig{
+					RateLimits: &middleware.RateLimitConfig{
+						Rules: []middleware.RateLimitRule{{Rate: 10}},
+					},
+				},
+			},
+			expectedErr: "invalid rate limit rule 0: rules require routes",
+		},
+		{
+			name: "with unknown container metrics label",
+			config: &Config{
+				CommonConfig: CommonConfig{
+					ContainerMetrics: &ContainerMetricsConfig{
+						Labels: []string{"name", "id"},
+					},
+				},
+			},
+			expectedErr: `invalid container metrics label "id": must be "name", "image" or "label:<key>"`,
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ContainerMetricsConfig is the configuration of the per-container metrics of
+// the metrics API, which export the resource usage of the running containers.
+type ContainerMetricsConfig struct {
+	// Labels are the labels added to the metrics of containers, in addition
+	// to the ID of the container. "name" adds the name of the container,
+	// "image" adds the image of the container, and "label:<key>" adds the
+	// value of the container label with the given key.
+	Labels []string `json:"labels,omitempty"`
+}
+
+// ContainerMetricsLabelPrefix is the prefix of the entries of
+// ContainerMetricsConfig.Labels that select container labels.
+const ContainerMetricsLabelPrefix = "label:"
+
+// Validate validates the configuration of the per-container metrics.
+func (c *ContainerMetricsConfig) Validate() error {
+	seen := make(map[string]bool)
+	for _, l := range c.Labels {
+		switch {
+		case l == "name", l == "image":
+		case strings.HasPrefix(l, ContainerMetricsLabelPrefix) && len(l) > len(ContainerMetricsLabelPrefix):
+		default:
+			return fmt.Errorf("invalid container metrics label %q: must be \"name\", \"image\" or \"%s<key>\"", l, ContainerMetricsLabelPrefix)
+		}
+		if seen[l] {
+			return fmt.Errorf("invalid container metrics label %q: duplicate label", l)
+		}
+		seen[l] = true
+	}
+	return nil
+}
@@ -29,6 +29,9 @@ type createOpts struct {
 	params                  types.ContainerCreateConfig
 	managed                 bool
 	ignoreImagesArgsEscaped bool
+	// ignoreImagePolicy skips the image signature policy for containers of
+	// the builder, which run intermediate images of the build.
+	ignoreImagePolicy bool
 }
 
 // CreateManagedContainer creates a container that is managed by a Service
@@ -53,7 +56,8 @@ func (daemon *Daemon) ContainerCreateIgnoreImagesArgsEscaped(params types.Contai
 	return daemon.containerCreate(createOpts{
 		params:                  params,
 		managed:                 false,
-		ignoreImagesArgsEscaped: true})
+		ignoreImagesArgsEscaped: true,
+		ignoreImagePolicy:       true})
 }
 
 func (daemon *Daemon) containerCreate(opts createOpts) (containertypes.ContainerCreateCreatedBody, error) {
@@ -127,6 +131,11 @@ func (daemon *Daemon) create(opts createOpts) (retC *container.Container, retErr
 		if !system.IsOSSupported(os) {
 			return nil, system.ErrNotSupportedOperatingSystem
 		}
+		if !opts.ignoreImagePolicy {
+			if err := daemon.imageService.VerifyImagePolicy(opts.params.Config.Image, img); err != nil {
+				return nil, err
+			}
+		}
 		imgID = img.ID()
 	} else if isWindows {
 		os = "linux" // 'scratch' case.
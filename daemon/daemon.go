@@ -35,6 +35,7 @@ import (
 	"github.com/docker/docker/daemon/logger"
 	"github.com/docker/docker/daemon/network"
 	"github.com/docker/docker/daemon/stats"
+	"github.com/docker/docker/distribution"
 	dmetadata "github.com/docker/docker/distribution/metadata"
 	"github.com/docker/docker/dockerversion"
 	"github.com/docker/docker/errdefs"
@@ -105,6 +106,7 @@ type Daemon struct {
 	cluster               Cluster
 	genericResources      []swarm.GenericResource
 	metricsPluginListener net.Listener
+	containerMetrics      containerMetrics
 
 	machineMemory uint64
 
@@ -1006,6 +1008,11 @@ func NewDaemon(ctx context.Context, config *config.Config, pluginStore *plugin.S
 		return nil, err
 	}
 
+	partialDownloadRoot := filepath.Join(imageRoot, "partial-downloads")
+	if err := distribution.PrunePartialDownloads(partialDownloadRoot); err != nil {
+		logrus.WithError(err).Warn("failed to prune stale partial layer downloads")
+	}
+
 	sysInfo := d.RawSysInfo()
 	for _, w := range sysInfo.Warnings {
 		logrus.Warn(w)
@@ -1041,6 +1048,8 @@ func NewDaemon(ctx context.Context, config *config.Config, pluginStore *plugin.S
 		MaxConcurrentDownloads:    *config.MaxConcurrentDownloads,
 		MaxConcurrentUploads:      *config.MaxConcurrentUploads,
 		MaxDownloadAttempts:       *config.MaxDownloadAttempts,
+		LazyPullLayerStore:        config.LazyPullLayerStore,
+		PartialDownloadRoot:       partialDownloadRoot,
 		ReferenceStore:            rs,
 		RegistryService:           registryService,
 		TrustKey:                  trustKey,
@@ -1066,6 +1075,9 @@ func NewDaemon(ctx context.Context, config *config.Config, pluginStore *plugin.S
 	// used above to run migration. They could be initialized in ImageService
 	// if migration is called from daemon/images. layerStore might move as well.
 	d.imageService = images.NewImageService(imgSvcConfig)
+	if err := d.imageService.SetImagePolicy(config.ImagePolicy); err != nil {
+		return nil, err
+	}
 	logrus.Debugf("Max Concurrent Downloads: %d", imgSvcConfig.MaxConcurrentDownloads)
 	logrus.Debugf("Max Concurrent Uploads: %d", imgSvcConfig.MaxConcurrentUploads)
 	logrus.Debugf("Max Download Attempts: %d", imgSvcConfig.MaxDownloadAttempts)
@@ -1096,6 +1108,9 @@ func NewDaemon(ctx context.Context, config *config.Config, pluginStore *plugin.S
 	).Set(1)
 	engineCpus.Set(float64(info.NCPU))
 	engineMemory.Set(float64(info.MemTotal))
+	if err := d.registerContainerMetrics(config.ContainerMetrics); err != nil {
+		return nil, err
+	}
 
 	logrus.WithFields(logrus.Fields{
 		"version":     dockerversion.Version,
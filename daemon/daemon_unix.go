@@ -26,6 +26,7 @@ import (
 	pblkiodev "github.com/docker/docker/api/types/blkiodev"
 	containertypes "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/cdi"
 	"github.com/docker/docker/daemon/config"
 	"github.com/docker/docker/daemon/initlayer"
 	"github.com/docker/docker/errdefs"
@@ -38,6 +39,7 @@ import (
 	"github.com/docker/docker/libnetwork/options"
 	lntypes "github.com/docker/docker/libnetwork/types"
 	"github.com/docker/docker/opts"
+	"github.com/docker/docker/pkg/capabilities"
 	"github.com/docker/docker/pkg/containerfs"
 	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/parsers"
@@ -718,6 +720,10 @@ func verifyPlatformContainerSettings(daemon *Daemon, hostConfig *containertypes.
 		}
 	}
 
+	if err := verifyCDIDevices(hostConfig); err != nil {
+		return warnings, err
+	}
+
 	if hostConfig.Runtime == config.LinuxV1RuntimeName || (hostConfig.Runtime == "" && daemon.configStore.DefaultRuntime == config.LinuxV1RuntimeName) {
 		warnings = append(warnings, fmt.Sprintf("Configured runtime %q is deprecated and will be removed in the next release.", config.LinuxV1RuntimeName))
 	}
@@ -725,6 +731,65 @@ func verifyPlatformContainerSettings(daemon *Daemon, hostConfig *containertypes.
 	return warnings, nil
 }
 
+// cdiDeviceName returns the fully-qualified name of the CDI device of the
+// device mapping d, as "vendor/class=name", and whether d is a CDI device.
+// Devices on the host are absolute paths, so a device that is not an absolute
+// path and has a "=" is a CDI device.
+func cdiDeviceName(d containertypes.DeviceMapping) (string, bool) {
+	if filepath.IsAbs(d.PathOnHost) || !strings.Contains(d.PathOnHost, "=") {
+		return "", false
+	}
+	return d.PathOnHost, true
+}
+
+// isCDIDeviceRequest returns whether req is handled by the "cdi" device
+// driver.
+func isCDIDeviceRequest(req containertypes.DeviceRequest) bool {
+	if req.Driver == "" {
+		return capabilities.Set{"cdi": struct{}{}}.Match(req.Capabilities) != nil
+	}
+	return req.Driver == "cdi"
+}
+
+// verifyCDIDevices validates the names of the CDI devices of hostConfig,
+// which are injected when the container is started. The device nodes of CDI
+// devices, and their paths in the container, are given by the CDI specs of
+// the devices, so a device mapping of a CDI device cannot set a path in the
+// container or cgroup permissions.
+func verifyCDIDevices(hostConfig *containertypes.HostConfig) error {
+	for _, d := range hostConfig.Devices {
+		name, ok := cdiDeviceName(d)
+		if !ok {
+			continue
+		}
+		if _, _, err := cdi.ParseQualifiedName(name); err != nil {
+			return err
+		}
+		// Clients set the path in the container to the path on the host
+		// if it is not given, and the permissions to "rwm".
+		if d.PathInContainer != "" && d.PathInContainer != name {
+			return errors.Errorf("cannot set the path in the container of CDI device %s", name)
+		}
+		if d.CgroupPermissions != "" && d.CgroupPermissions != "rwm" {
+			return errors.Errorf("cannot set the cgroup permissions of CDI device %s", name)
+		}
+	}
+	for _, req := range hostConfig.DeviceRequests {
+		if !isCDIDeviceRequest(req) {
+			continue
+		}
+		if req.Count != 0 {
+			return errors.New("cannot set Count on CDI device request")
+		}
+		for _, name := range req.DeviceIDs {
+			if _, _, err := cdi.ParseQualifiedName(name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // verifyDaemonSettings performs validation of daemon config struct
 func verifyDaemonSettings(conf *config.Config) error {
 	if conf.ContainerdNamespace == conf.ContainerdPluginNamespace {
@@ -1394,7 +1459,13 @@ func (daemon *Daemon) stats(c *container.Container) (*types.StatsJSON, error) {
 	case *statsV1.Metrics:
 		return daemon.statsV1(s, t)
 	case *statsV2.Metrics:
-		return daemon.statsV2(s, t)
+		if _, err := daemon.statsV2(s, t); err != nil {
+			return nil, err
+		}
+		// Add the stats that containerd doesn't collect, such as the
+		// pressure stall information, from the cgroup of the container.
+		readCgroup2Stats(s, c.GetPID())
+		return s, nil
 	default:
 		return nil, errors.Errorf("unexpected type of metrics %+v", t)
 	}
@@ -1477,6 +1548,9 @@ func (daemon *Daemon) statsV1(s *types.StatsJSON, stats *statsV1.Metrics) (*type
 				Stats: raw,
 			}
 		}
+		if stats.MemoryOomControl != nil {
+			s.MemoryStats.OOMKills = stats.MemoryOomControl.OomKill
+		}
 
 		// if the container does not set memory limit, use the machineMemory
 		if s.MemoryStats.Limit > daemon.machineMemory && daemon.machineMemory > 0 {
@@ -1497,7 +1571,18 @@ func (daemon *Daemon) statsV1(s *types.StatsJSON, stats *statsV1.Metrics) (*type
 func (daemon *Daemon) statsV2(s *types.StatsJSON, stats *statsV2.Metrics) (*types.StatsJSON, error) {
 	if stats.Io != nil {
 		var isbr []types.BlkioStatEntry
+		var ios []types.BlkioDeviceStats
 		for _, re := range stats.Io.Usage {
+			ios = append(ios, types.BlkioDeviceStats{
+				Major: re.Major,
+				Minor: re.Minor,
+				Stats: map[string]uint64{
+					"rbytes": re.Rbytes,
+					"wbytes": re.Wbytes,
+					"rios":   re.Rios,
+					"wios":   re.Wios,
+				},
+			})
 			isbr = append(isbr,
 				types.BlkioStatEntry{
 					Major: re.Major,
@@ -1516,6 +1601,7 @@ func (daemon *Daemon) statsV2(s *types.StatsJSON, stats *statsV2.Metrics) (*type
 		s.BlkioStats = types.BlkioStats{
 			IoServiceBytesRecursive: isbr,
 			// Other fields are unsupported
+			IoStats: ios,
 		}
 	}
 
@@ -1583,6 +1669,14 @@ func (daemon *Daemon) statsV2(s *types.StatsJSON, stats *statsV2.Metrics) (*type
 			// Failcnt is set to the "oom" field of the "memory.events" file.
 			// See https://www.kernel.org/doc/html/latest/admin-guide/cgroup-v2.html
 			s.MemoryStats.Failcnt = stats.MemoryEvents.Oom
+			s.MemoryStats.OOMKills = stats.MemoryEvents.OomKill
+			s.MemoryStats.Events = &types.MemoryEvents{
+				Low:     stats.MemoryEvents.Low,
+				High:    stats.MemoryEvents.High,
+				Max:     stats.MemoryEvents.Max,
+				OOM:     stats.MemoryEvents.Oom,
+				OOMKill: stats.MemoryEvents.OomKill,
+			}
 		}
 	}
 
@@ -11,6 +11,9 @@ var deviceDrivers = map[string]*deviceDriver{}
 type deviceDriver struct {
 	capset     capabilities.Set
 	updateSpec func(*specs.Spec, *deviceInstance) error
+	// allowNoCapabilities allows requests that explicitly name the driver
+	// to not request any capabilities.
+	allowNoCapabilities bool
 }
 
 type deviceInstance struct {
@@ -22,14 +25,29 @@ func registerDeviceDriver(name string, d *deviceDriver) {
 	deviceDrivers[name] = d
 }
 
+// lookupDeviceDrivers returns the registered device drivers, along with the
+// device drivers of the daemon.
+func (daemon *Daemon) lookupDeviceDrivers() map[string]*deviceDriver {
+	drivers := make(map[string]*deviceDriver, len(deviceDrivers)+1)
+	for name, dd := range deviceDrivers {
+		drivers[name] = dd
+	}
+	drivers["cdi"] = daemon.cdiDeviceDriver()
+	return drivers
+}
+
 func (daemon *Daemon) handleDevice(req container.DeviceRequest, spec *specs.Spec) error {
+	drivers := daemon.lookupDeviceDrivers()
 	if req.Driver == "" {
-		for _, dd := range deviceDrivers {
+		for _, dd := range drivers {
 			if selected := dd.capset.Match(req.Capabilities); selected != nil {
 				return dd.updateSpec(spec, &deviceInstance{req: req, selectedCaps: selected})
 			}
 		}
-	} else if dd := deviceDrivers[req.Driver]; dd != nil {
+	} else if dd := drivers[req.Driver]; dd != nil {
+		if len(req.Capabilities) == 0 && dd.allowNoCapabilities {
+			return dd.updateSpec(spec, &deviceInstance{req: req})
+		}
 		if selected := dd.capset.Match(req.Capabilities); selected != nil {
 			return dd.updateSpec(spec, &deviceInstance{req: req, selectedCaps: selected})
 		}
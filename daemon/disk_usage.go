@@ -65,6 +65,15 @@ func (daemon *Daemon) SystemDiskUsage(ctx context.Context, opts system.DiskUsage
 		})
 	}
 
+	var dedup *types.DedupReport
+	if opts.Dedup {
+		eg.Go(func() error {
+			var err error
+			dedup, err = daemon.imageService.ImageDedupReport(ctx)
+			return err
+		})
+	}
+
 	var volumes []*volume.Volume
 	if opts.Volumes {
 		eg.Go(func() error {
@@ -82,5 +91,6 @@ func (daemon *Daemon) SystemDiskUsage(ctx context.Context, opts system.DiskUsage
 		Containers: containers,
 		Volumes:    volumes,
 		Images:     images,
+		Dedup:      dedup,
 	}, nil
 }
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io"
 	"runtime"
+	"sort"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/docker/docker/api/types"
@@ -191,11 +193,14 @@ func (daemon *Daemon) ContainerExecStart(ctx context.Context, name string, stdin
 			ec.Running = false
 			exitCode := 126
 			ec.ExitCode = &exitCode
+			ec.FinishedAt = time.Now().UTC()
 			if err := ec.CloseStreams(); err != nil {
 				logrus.Errorf("failed to cleanup exec %s streams: %s", c.ID, err)
 			}
+			summary := ec.Summary()
 			ec.Unlock()
 			c.ExecCommands.Delete(ec.ID, ec.Pid)
+			c.ExecCommands.AddHistory(summary)
 		}
 	}()
 
@@ -273,6 +278,7 @@ func (daemon *Daemon) ContainerExecStart(ctx context.Context, name string, stdin
 		return translateContainerdStartErr(ec.Entrypoint, ec.SetExitCode, err)
 	}
 	ec.Pid = systemPid
+	ec.StartedAt = time.Now().UTC()
 	c.ExecCommands.Unlock()
 	ec.Unlock()
 
@@ -306,6 +312,70 @@ func (daemon *Daemon) ContainerExecStart(ctx context.Context, name string, stdin
 	return nil
 }
 
+// ContainerExecList returns the exec instances of a container, including the
+// completed exec instances in its history, ordered by the time they were
+// created.
+func (daemon *Daemon) ContainerExecList(name string) ([]types.ExecSummary, error) {
+	ctr, err := daemon.GetContainer(name)
+	if err != nil {
+		return nil, err
+	}
+
+	execs := ctr.ExecCommands.History()
+	completed := make(map[string]struct{}, len(execs))
+	for _, e := range execs {
+		completed[e.ID] = struct{}{}
+	}
+	for id, ec := range daemon.execCommands.Commands() {
+		if _, ok := completed[id]; ok || ec.ContainerID != ctr.ID {
+			continue
+		}
+		ec.Lock()
+		execs = append(execs, ec.Summary())
+		ec.Unlock()
+	}
+	sort.SliceStable(execs, func(i, j int) bool {
+		return execs[i].Created.Before(execs[j].Created)
+	})
+	return execs, nil
+}
+
+// ContainerExecKill sends the given signal to the process of a running exec
+// instance. SIGKILL is sent if sig is 0.
+func (daemon *Daemon) ContainerExecKill(name string, sig uint64) error {
+	ec, err := daemon.getExecConfig(name)
+	if err != nil {
+		return err
+	}
+
+	if sig == 0 {
+		sig = uint64(signal.SignalMap["KILL"])
+	}
+	if !signal.ValidSignalForPlatform(syscall.Signal(sig)) {
+		return errdefs.InvalidParameter(fmt.Errorf("The %s daemon does not support signal %d", runtime.GOOS, sig))
+	}
+
+	ec.Lock()
+	running := ec.Running
+	ec.Unlock()
+	if !running {
+		return errdefs.Conflict(fmt.Errorf("exec %s is not running", ec.ID))
+	}
+
+	// The exec instance may still be starting.
+	timeout := time.NewTimer(10 * time.Second)
+	defer timeout.Stop()
+
+	select {
+	case <-ec.Started:
+	case <-timeout.C:
+		return fmt.Errorf("timeout waiting for exec session ready")
+	}
+
+	logrus.Debugf("Sending signal %d to process %s in container %s", sig, ec.ID, ec.ContainerID)
+	return daemon.containerd.SignalProcess(context.Background(), ec.ContainerID, ec.ID, int(sig))
+}
+
 // execCommandGC runs a ticker to clean up the daemon references
 // of exec configs that are no longer part of the container.
 func (daemon *Daemon) execCommandGC() {
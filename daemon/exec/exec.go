@@ -4,8 +4,10 @@ import (
 	"context"
 	"runtime"
 	"sync"
+	"time"
 
 	"github.com/containerd/containerd/cio"
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/container/stream"
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/sirupsen/logrus"
@@ -35,6 +37,9 @@ type Config struct {
 	WorkingDir   string
 	Env          []string
 	Pid          int
+	Created      time.Time
+	StartedAt    time.Time
+	FinishedAt   time.Time
 }
 
 // NewConfig initializes the a new exec configuration
@@ -43,6 +48,7 @@ func NewConfig() *Config {
 		ID:           stringid.GenerateRandomID(),
 		StreamConfig: stream.NewConfig(),
 		Started:      make(chan struct{}),
+		Created:      time.Now().UTC(),
 	}
 }
 
@@ -89,9 +95,36 @@ func (c *Config) SetExitCode(code int) {
 	c.ExitCode = &code
 }
 
+// Summary returns the summary of the exec for the API. The exec config must
+// be locked by the caller.
+func (c *Config) Summary() types.ExecSummary {
+	s := types.ExecSummary{
+		ID:         c.ID,
+		Running:    c.Running,
+		Pid:        c.Pid,
+		Cmd:        append([]string{c.Entrypoint}, c.Args...),
+		User:       c.User,
+		Tty:        c.Tty,
+		Privileged: c.Privileged,
+		Created:    c.Created,
+		StartedAt:  c.StartedAt,
+		FinishedAt: c.FinishedAt,
+	}
+	if c.ExitCode != nil {
+		exitCode := *c.ExitCode
+		s.ExitCode = &exitCode
+	}
+	return s
+}
+
+// maxHistory is the number of completed execs that are kept in the history
+// of a store.
+const maxHistory = 50
+
 // Store keeps track of the exec configurations.
 type Store struct {
-	byID map[string]*Config
+	byID    map[string]*Config
+	history []types.ExecSummary
 	sync.RWMutex
 }
 
@@ -145,3 +178,24 @@ func (e *Store) List() []string {
 	e.RUnlock()
 	return IDs
 }
+
+// AddHistory adds a completed exec to the history of the store. Only the
+// maxHistory most recent execs are kept.
+func (e *Store) AddHistory(summary types.ExecSummary) {
+	e.Lock()
+	e.history = append(e.history, summary)
+	if len(e.history) > maxHistory {
+		e.history = append([]types.ExecSummary(nil), e.history[len(e.history)-maxHistory:]...)
+	}
+	e.Unlock()
+}
+
+// History returns the completed execs in the history of the store, oldest
+// first.
+func (e *Store) History() []types.ExecSummary {
+	e.RLock()
+	history := make([]types.ExecSummary, len(e.history))
+	copy(history, e.history)
+	e.RUnlock()
+	return history
+}
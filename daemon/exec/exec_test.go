@@ -0,0 +1,21 @@
+package exec // import "github.com/docker/docker/daemon/exec"
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestStoreHistory(t *testing.T) {
+	s := NewStore()
+	for i := 0; i < maxHistory+10; i++ {
+		s.AddHistory(types.ExecSummary{ID: strconv.Itoa(i)})
+	}
+	history := s.History()
+	assert.Assert(t, is.Len(history, maxHistory))
+	assert.Check(t, is.Equal(history[0].ID, "10"))
+	assert.Check(t, is.Equal(history[maxHistory-1].ID, strconv.Itoa(maxHistory+9)))
+}
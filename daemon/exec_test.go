@@ -0,0 +1,100 @@
+//go:build linux
+// +build linux
+
+package daemon
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/exec"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+type execKillMockContainerdClient struct {
+	MockContainerdClient
+	ProcessID string
+	Signal    int
+}
+
+func (c *execKillMockContainerdClient) SignalProcess(ctx context.Context, containerID, processID string, signal int) error {
+	c.ProcessID = processID
+	c.Signal = signal
+	return nil
+}
+
+func TestContainerExecList(t *testing.T) {
+	d := &Daemon{
+		execCommands: exec.NewStore(),
+		containers:   container.NewMemoryStore(),
+	}
+	c := &container.Container{
+		ID:           "container",
+		ExecCommands: exec.NewStore(),
+		State:        &container.State{Running: true},
+	}
+	d.containers.Add(c.ID, c)
+	other := &container.Container{
+		ID:           "other",
+		ExecCommands: exec.NewStore(),
+		State:        &container.State{Running: true},
+	}
+	d.containers.Add(other.ID, other)
+
+	now := time.Now()
+	exitCode := 1
+	// The exec was removed from the daemon after it completed.
+	c.ExecCommands.AddHistory(types.ExecSummary{ID: "removed", ExitCode: &exitCode, Created: now})
+
+	running := &exec.Config{ID: "running", ContainerID: c.ID, Running: true, Entrypoint: "top", Created: now.Add(2 * time.Second)}
+	d.registerExecCommand(c, running)
+	completed := &exec.Config{ID: "completed", ContainerID: c.ID, ExitCode: &exitCode, Created: now.Add(time.Second)}
+	d.registerExecCommand(c, completed)
+	c.ExecCommands.AddHistory(completed.Summary())
+	d.registerExecCommand(other, &exec.Config{ID: "other", ContainerID: other.ID})
+
+	execs, err := d.ContainerExecList(c.ID)
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(execs, 3))
+	assert.Check(t, is.Equal(execs[0].ID, "removed"))
+	assert.Check(t, is.Equal(execs[1].ID, "completed"))
+	assert.Check(t, is.Equal(*execs[1].ExitCode, 1))
+	assert.Check(t, is.Equal(execs[2].ID, "running"))
+	assert.Check(t, execs[2].Running)
+	assert.Check(t, is.DeepEqual(execs[2].Cmd, []string{"top"}))
+}
+
+func TestContainerExecKill(t *testing.T) {
+	mc := &execKillMockContainerdClient{}
+	d := &Daemon{
+		execCommands: exec.NewStore(),
+		containerd:   mc,
+		containers:   container.NewMemoryStore(),
+	}
+	c := &container.Container{
+		ID:           "container",
+		ExecCommands: exec.NewStore(),
+		State:        &container.State{Running: true},
+	}
+	d.containers.Add(c.ID, c)
+	ec := &exec.Config{ID: "exec", ContainerID: c.ID, Started: make(chan struct{})}
+	d.registerExecCommand(c, ec)
+
+	err := d.ContainerExecKill(ec.ID, 0)
+	assert.Check(t, is.ErrorContains(err, "exec exec is not running"))
+
+	ec.Running = true
+	close(ec.Started)
+	assert.NilError(t, d.ContainerExecKill(ec.ID, uint64(syscall.SIGTERM)))
+	assert.Check(t, is.Equal(mc.ProcessID, ec.ID))
+	assert.Check(t, is.Equal(mc.Signal, int(syscall.SIGTERM)))
+
+	// SIGKILL is the default signal.
+	assert.NilError(t, d.ContainerExecKill(ec.ID, 0))
+	assert.Check(t, is.Equal(mc.Signal, int(syscall.SIGKILL)))
+}
@@ -130,6 +130,17 @@ type DiffGetterDriver interface {
 	DiffGetter(id string) (FileGetCloser, error)
 }
 
+// RemoteDiffDriver is the interface for layered file system drivers that
+// can use a directory provided by a remote filesystem as the content of a
+// read-only layer, for example to lazily fetch layer contents.
+type RemoteDiffDriver interface {
+	Driver
+	// CreateFromRemote creates a new, read-only layer with the given id on
+	// top of parent, using diffPath as its content. The directory must remain
+	// available for as long as the layer exists.
+	CreateFromRemote(id, parent, diffPath string) error
+}
+
 // FileGetCloser extends the storage.FileGetter interface with a Close method
 // for cleaning up.
 type FileGetCloser interface {
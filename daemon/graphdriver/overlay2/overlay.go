@@ -414,6 +414,29 @@ func (d *Driver) create(id, parent string, opts *graphdriver.CreateOpts) (retErr
 	return nil
 }
 
+// CreateFromRemote creates a new, read-only layer with the given id on top of
+// parent, using diffPath, which is provided by a remote filesystem, as the
+// content of the layer. The layer's diff directory is a symlink to diffPath.
+func (d *Driver) CreateFromRemote(id, parent, diffPath string) (retErr error) {
+	if _, err := os.Stat(diffPath); err != nil {
+		return err
+	}
+	if err := d.create(id, parent, nil); err != nil {
+		return err
+	}
+	defer func() {
+		if retErr != nil {
+			d.Remove(id)
+		}
+	}()
+
+	diffDir := d.getDiffPath(id)
+	if err := os.Remove(diffDir); err != nil {
+		return err
+	}
+	return os.Symlink(diffPath, diffDir)
+}
+
 // Parse overlay storage options
 func (d *Driver) parseStorageOpt(storageOpt map[string]string, driver *Driver) error {
 	// Read size to set the disk project quota per container
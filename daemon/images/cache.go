@@ -1,21 +1,47 @@
 package images // import "github.com/docker/docker/daemon/images"
 
 import (
+	"context"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/backend"
 	"github.com/docker/docker/builder"
+	"github.com/docker/docker/distribution"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/image"
 	"github.com/docker/docker/image/cache"
+	"github.com/docker/docker/registry"
 	"github.com/sirupsen/logrus"
 )
 
-// MakeImageCache creates a stateful image cache.
-func (i *ImageService) MakeImageCache(sourceRefs []string) builder.ImageCache {
+// MakeImageCache creates a stateful image cache. Source images that are not
+// available locally are looked up in the registry. Only their configuration is
+// fetched, and they are pulled when they are first used for a cache hit.
+func (i *ImageService) MakeImageCache(ctx context.Context, sourceRefs []string, opts backend.ImageCacheOptions) builder.ImageCache {
 	if len(sourceRefs) == 0 {
-		return cache.NewLocal(i.imageStore)
+		localCache := cache.NewLocal(i.imageStore)
+		if opts.SourceDateEpoch != nil {
+			localCache.SetCreated(*opts.SourceDateEpoch)
+		}
+		return localCache
 	}
 
 	cache := cache.New(i.imageStore)
+	if opts.SourceDateEpoch != nil {
+		cache.SetCreated(*opts.SourceDateEpoch)
+	}
 
 	for _, ref := range sourceRefs {
 		img, err := i.GetImage(ref, nil)
+		if errdefs.IsNotFound(err) {
+			var fetch func() error
+			img, fetch, err = i.getRemoteCacheSource(ctx, ref, opts)
+			if err == nil {
+				cache.PopulateRemote(img, fetch)
+				continue
+			}
+		}
 		if err != nil {
 			logrus.Warnf("Could not look up %s for cache resolution, skipping: %+v", ref, err)
 			continue
@@ -25,3 +51,57 @@ func (i *ImageService) MakeImageCache(sourceRefs []string) builder.ImageCache {
 
 	return cache
 }
+
+// getRemoteCacheSource fetches the configuration of the image with the given
+// reference from the registry. It returns the image, and a function that pulls
+// the image.
+func (i *ImageService) getRemoteCacheSource(ctx context.Context, name string, opts backend.ImageCacheOptions) (*image.Image, func() error, error) {
+	ref, err := reference.ParseNormalizedNamed(name)
+	if err != nil {
+		return nil, nil, errdefs.InvalidParameter(err)
+	}
+	ref = reference.TagNameOnly(ref)
+
+	authConfig, err := i.resolveAuthConfig(ref, opts.AuthConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	manifestDigest, config, err := distribution.FetchImageConfig(ctx, ref, &distribution.ImagePullConfig{
+		Config: distribution.Config{
+			AuthConfig:      authConfig,
+			RegistryService: i.registryService,
+		},
+		Platform: opts.Platform,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	img, err := image.NewFromJSON(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	canonical, err := reference.WithDigest(reference.TrimNamed(ref), manifestDigest)
+	if err != nil {
+		return nil, nil, err
+	}
+	fetch := func() error {
+		return i.pullImageWithReference(ctx, canonical, opts.Platform, nil, authConfig, opts.Output)
+	}
+	return img, fetch, nil
+}
+
+// resolveAuthConfig returns the credentials in authConfigs for the registry of
+// ref.
+func (i *ImageService) resolveAuthConfig(ref reference.Named, authConfigs map[string]types.AuthConfig) (*types.AuthConfig, error) {
+	if len(authConfigs) == 0 {
+		return &types.AuthConfig{}, nil
+	}
+	repoInfo, err := i.registryService.ResolveRepository(ref)
+	if err != nil {
+		return nil, err
+	}
+	resolvedConfig := registry.ResolveAuthConfig(authConfigs, repoInfo.Index)
+	return &resolvedConfig, nil
+}
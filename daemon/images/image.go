@@ -172,9 +172,9 @@ func (i *ImageService) GetImage(refOrID string, platform *specs.Platform) (retIm
 		}
 
 		// This allows us to tell clients that we don't have the image they asked for
-		// Where this gets hairy is the image store does not currently support multi-arch images, e.g.:
+		// Where this gets hairy is that the image store only keeps the platforms that were pulled or built, e.g.:
 		//   An image `foo` may have a multi-arch manifest, but the image store only fetches the image for a specific platform
-		//   The image store does not store the manifest list and image tags are assigned to architecture specific images.
+		//   Tags keep a variant for each platform that was pulled, but not the platforms that were never pulled.
 		//   So we can have a `foo` image that is amd64 but the user requested armv7. If the user looks at the list of images.
 		//   This may be confusing.
 		//   The alternative to this is to return a errdefs.Conflict error with a helpful message, but clients will not be
@@ -201,6 +201,15 @@ func (i *ImageService) GetImage(refOrID string, platform *specs.Platform) (retIm
 	if digest, err := i.referenceStore.Get(namedRef); err == nil {
 		// Search the image stores to get the operating system, defaulting to host OS.
 		id := image.IDFromDigest(digest)
+		// Without a platform, the variant of the tag for the host platform
+		// is preferred over the image that the tag was last set to.
+		p := platforms.DefaultSpec()
+		if platform != nil {
+			p = *platform
+		}
+		if variant, ok := i.platformVariant(namedRef, p); ok {
+			id = variant
+		}
 		if img, err := i.imageStore.Get(id); err == nil {
 			return img, nil
 		}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"runtime"
+	"time"
 
 	"github.com/containerd/containerd/platforms"
 	"github.com/docker/distribution/reference"
@@ -13,12 +14,13 @@ import (
 	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/layer"
+	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/containerfs"
 	"github.com/docker/docker/pkg/progress"
 	"github.com/docker/docker/pkg/streamformatter"
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/pkg/system"
-	"github.com/docker/docker/registry"
+	"github.com/opencontainers/go-digest"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -28,6 +30,10 @@ type roLayer struct {
 	released   bool
 	layerStore layer.Store
 	roLayer    layer.Layer
+
+	// sourceDateEpoch, if set, is the time to which the timestamps of files
+	// in layers committed on top of this layer are clamped.
+	sourceDateEpoch *time.Time
 }
 
 func (l *roLayer) DiffID() layer.DiffID {
@@ -65,7 +71,7 @@ func (l *roLayer) NewRWLayer() (builder.RWLayer, error) {
 		return nil, errors.Wrap(err, "failed to create rwlayer")
 	}
 
-	rwLayer := &rwLayer{layerStore: l.layerStore, rwLayer: newLayer}
+	rwLayer := &rwLayer{layerStore: l.layerStore, rwLayer: newLayer, sourceDateEpoch: l.sourceDateEpoch}
 
 	fs, err := newLayer.Mount("")
 	if err != nil {
@@ -83,6 +89,8 @@ type rwLayer struct {
 	layerStore layer.Store
 	rwLayer    layer.RWLayer
 	fs         containerfs.ContainerFS
+
+	sourceDateEpoch *time.Time
 }
 
 func (l *rwLayer) Root() containerfs.ContainerFS {
@@ -94,6 +102,9 @@ func (l *rwLayer) Commit() (builder.ROLayer, error) {
 	if err != nil {
 		return nil, err
 	}
+	if l.sourceDateEpoch != nil {
+		stream = archive.ClampTimestamps(stream, *l.sourceDateEpoch)
+	}
 	defer stream.Close()
 
 	var chainID layer.ChainID
@@ -106,7 +117,7 @@ func (l *rwLayer) Commit() (builder.ROLayer, error) {
 		return nil, err
 	}
 	// TODO: An optimization would be to handle empty layers before returning
-	return &roLayer{layerStore: l.layerStore, roLayer: newLayer}, nil
+	return &roLayer{layerStore: l.layerStore, roLayer: newLayer, sourceDateEpoch: l.sourceDateEpoch}, nil
 }
 
 func (l *rwLayer) Release() error {
@@ -130,9 +141,9 @@ func (l *rwLayer) Release() error {
 	return nil
 }
 
-func newROLayerForImage(img *image.Image, layerStore layer.Store) (builder.ROLayer, error) {
+func newROLayerForImage(img *image.Image, layerStore layer.Store, sourceDateEpoch *time.Time) (builder.ROLayer, error) {
 	if img == nil || img.RootFS.ChainID() == "" {
-		return &roLayer{layerStore: layerStore}, nil
+		return &roLayer{layerStore: layerStore, sourceDateEpoch: sourceDateEpoch}, nil
 	}
 	// Hold a reference to the image layer so that it can't be removed before
 	// it is released
@@ -140,7 +151,7 @@ func newROLayerForImage(img *image.Image, layerStore layer.Store) (builder.ROLay
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to get layer for image %s", img.ImageID())
 	}
-	return &roLayer{layerStore: layerStore, roLayer: layer}, nil
+	return &roLayer{layerStore: layerStore, roLayer: layer, sourceDateEpoch: sourceDateEpoch}, nil
 }
 
 // TODO: could this use the regular daemon PullImage ?
@@ -151,16 +162,9 @@ func (i *ImageService) pullForBuilder(ctx context.Context, name string, authConf
 	}
 	ref = reference.TagNameOnly(ref)
 
-	pullRegistryAuth := &types.AuthConfig{}
-	if len(authConfigs) > 0 {
-		// The request came with a full auth config, use it
-		repoInfo, err := i.registryService.ResolveRepository(ref)
-		if err != nil {
-			return nil, err
-		}
-
-		resolvedConfig := registry.ResolveAuthConfig(authConfigs, repoInfo.Index)
-		pullRegistryAuth = &resolvedConfig
+	pullRegistryAuth, err := i.resolveAuthConfig(ref, authConfigs)
+	if err != nil {
+		return nil, err
 	}
 
 	if err := i.pullImageWithReference(ctx, ref, platform, nil, pullRegistryAuth, output); err != nil {
@@ -206,7 +210,7 @@ func (i *ImageService) GetImageAndReleasableLayer(ctx context.Context, refOrID s
 		if !system.IsOSSupported(os) {
 			return nil, nil, system.ErrNotSupportedOperatingSystem
 		}
-		layer, err := newROLayerForImage(nil, i.layerStore)
+		layer, err := newROLayerForImage(nil, i.layerStore, opts.SourceDateEpoch)
 		return nil, layer, err
 	}
 
@@ -220,7 +224,12 @@ func (i *ImageService) GetImageAndReleasableLayer(ctx context.Context, refOrID s
 			if !system.IsOSSupported(image.OperatingSystem()) {
 				return nil, nil, system.ErrNotSupportedOperatingSystem
 			}
-			layer, err := newROLayerForImage(image, i.layerStore)
+			// The steps of the build run in containers of the image, so it
+			// must satisfy the image policy like the images of containers.
+			if err := i.VerifyImagePolicy(refOrID, image); err != nil {
+				return nil, nil, err
+			}
+			layer, err := newROLayerForImage(image, i.layerStore, opts.SourceDateEpoch)
 			return image, layer, err
 		}
 	}
@@ -232,7 +241,10 @@ func (i *ImageService) GetImageAndReleasableLayer(ctx context.Context, refOrID s
 	if !system.IsOSSupported(image.OperatingSystem()) {
 		return nil, nil, system.ErrNotSupportedOperatingSystem
 	}
-	layer, err := newROLayerForImage(image, i.layerStore)
+	if err := i.VerifyImagePolicy(refOrID, image); err != nil {
+		return nil, nil, err
+	}
+	layer, err := newROLayerForImage(image, i.layerStore, opts.SourceDateEpoch)
 	return image, layer, err
 }
 
@@ -253,3 +265,62 @@ func (i *ImageService) CreateImage(config []byte, parent string) (builder.Image,
 
 	return i.imageStore.Get(id)
 }
+
+// SetImageProvenance stores the provenance document of the image with the
+// given ID. It is pushed along with the image.
+func (i *ImageService) SetImageProvenance(imageID string, provenance []byte) error {
+	return i.imageStore.SetProvenance(image.ID(imageID), provenance)
+}
+
+// ImageRepoDigest returns the digest of the manifest of the image with the
+// given ID in the repository of ref, or an empty digest if the image was not
+// pulled from that repository.
+func (i *ImageService) ImageRepoDigest(ref, imageID string) digest.Digest {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return ""
+	}
+	if canonical, ok := named.(reference.Canonical); ok {
+		return canonical.Digest()
+	}
+	for _, r := range i.referenceStore.References(digest.Digest(imageID)) {
+		if canonical, ok := r.(reference.Canonical); ok && r.Name() == named.Name() {
+			return canonical.Digest()
+		}
+	}
+	return ""
+}
+
+// ImageDiffSize returns the total size of the layers of the image that are not
+// part of the parent image.
+func (i *ImageService) ImageDiffSize(imageID, parentID string) (int64, error) {
+	img, err := i.imageStore.Get(image.ID(imageID))
+	if err != nil {
+		return 0, err
+	}
+	var parentLayers int
+	if parentID != "" {
+		parent, err := i.imageStore.Get(image.ID(parentID))
+		if err != nil {
+			return 0, err
+		}
+		parentLayers = len(parent.RootFS.DiffIDs)
+	}
+
+	var size int64
+	rootFS := *img.RootFS
+	rootFS.DiffIDs = nil
+	for n, diffID := range img.RootFS.DiffIDs {
+		rootFS.Append(diffID)
+		if n < parentLayers {
+			continue
+		}
+		l, err := i.layerStore.Get(rootFS.ChainID())
+		if err != nil {
+			return 0, err
+		}
+		size += l.DiffSize()
+		layer.ReleaseAndLog(i.layerStore, l)
+	}
+	return size, nil
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/docker/docker/api/types/backend"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/layer"
+	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/pkg/errors"
 )
@@ -17,6 +18,9 @@ func (i *ImageService) CommitImage(c backend.CommitConfig) (image.ID, error) {
 	if err != nil {
 		return "", err
 	}
+	if c.SourceDateEpoch != nil {
+		rwTar = archive.ClampTimestamps(rwTar, *c.SourceDateEpoch)
+	}
 	defer func() {
 		if rwTar != nil {
 			rwTar.Close()
@@ -48,6 +52,17 @@ func (i *ImageService) CommitImage(c backend.CommitConfig) (image.ID, error) {
 		Config:          c.Config,
 		DiffID:          l.DiffID(),
 	}
+	if c.SourceDateEpoch != nil {
+		// The ID and hostname of the container differ between builds, so
+		// they are left out of reproducible images.
+		cc.Created = *c.SourceDateEpoch
+		cc.ContainerID = ""
+		if c.ContainerConfig != nil {
+			containerConfig := *c.ContainerConfig
+			containerConfig.Hostname = ""
+			cc.ContainerConfig = &containerConfig
+		}
+	}
 	config, err := json.Marshal(image.NewChildImage(parent, cc, c.ContainerOS))
 	if err != nil {
 		return "", err
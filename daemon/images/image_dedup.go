@@ -0,0 +1,148 @@
+package images // import "github.com/docker/docker/daemon/images"
+
+import (
+	"context"
+	"sort"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/layer"
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+// maxDedupFiles is the maximum number of duplicated file contents included in
+// a dedup report.
+const maxDedupFiles = 100
+
+type dedupEntry struct {
+	size   int64
+	count  int64
+	paths  map[string]struct{}
+	layers map[layer.ChainID]struct{}
+}
+
+// ImageDedupReport returns a report of regular file content that is present
+// more than once across the layers of all images. Content digests of the
+// files in each layer are computed once, and cached in the layer's metadata.
+func (i *ImageService) ImageDedupReport(ctx context.Context) (*types.DedupReport, error) {
+	ch := i.usage.DoChan("ImageDedupReport", func() (interface{}, error) {
+		return i.imageDedupReport(ctx)
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*types.DedupReport), nil
+	}
+}
+
+func (i *ImageService) imageDedupReport(ctx context.Context) (*types.DedupReport, error) {
+	fds, ok := i.layerStore.(layer.FileDigestStore)
+	if !ok {
+		return nil, errdefs.NotImplemented(errors.New("layer store does not support computing file digests"))
+	}
+
+	// Collect the images using each layer. Layers that are shared between
+	// images are only stored once, so they are only accounted for once.
+	layerImages := map[layer.ChainID][]string{}
+	for id, img := range i.imageStore.Map() {
+		rootFS := *img.RootFS
+		rootFS.DiffIDs = nil
+		for _, diffID := range img.RootFS.DiffIDs {
+			rootFS.Append(diffID)
+			chainID := rootFS.ChainID()
+			layerImages[chainID] = append(layerImages[chainID], id.String())
+		}
+	}
+
+	rep := &types.DedupReport{}
+	entries := map[digest.Digest]*dedupEntry{}
+	for chainID := range layerImages {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		digests, err := fds.FileDigests(chainID)
+		if err != nil {
+			if err == layer.ErrLayerDoesNotExist {
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to compute file digests for layer %s", chainID)
+		}
+		for _, fd := range digests {
+			rep.TotalFiles++
+			rep.TotalSize += fd.Size
+			if fd.Size == 0 {
+				continue
+			}
+			e, ok := entries[fd.Digest]
+			if !ok {
+				e = &dedupEntry{
+					size:   fd.Size,
+					paths:  map[string]struct{}{},
+					layers: map[layer.ChainID]struct{}{},
+				}
+				entries[fd.Digest] = e
+			}
+			e.count++
+			e.paths[fd.Path] = struct{}{}
+			e.layers[chainID] = struct{}{}
+		}
+	}
+
+	var files []*types.DuplicatedFile
+	for dgst, e := range entries {
+		if e.count < 2 {
+			continue
+		}
+		rep.DuplicatedSize += e.size * (e.count - 1)
+
+		f := &types.DuplicatedFile{
+			Digest: dgst.String(),
+			Size:   e.size,
+			Count:  e.count,
+			Paths:  []string{},
+			Layers: []string{},
+			Images: []string{},
+		}
+		for p := range e.paths {
+			f.Paths = append(f.Paths, p)
+		}
+		imgs := map[string]struct{}{}
+		for chainID := range e.layers {
+			f.Layers = append(f.Layers, chainID.String())
+			for _, img := range layerImages[chainID] {
+				imgs[img] = struct{}{}
+			}
+		}
+		for img := range imgs {
+			f.Images = append(f.Images, img)
+		}
+		sort.Strings(f.Paths)
+		sort.Strings(f.Layers)
+		sort.Strings(f.Images)
+		files = append(files, f)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		wi, wj := files[i].Size*(files[i].Count-1), files[j].Size*(files[j].Count-1)
+		if wi != wj {
+			return wi > wj
+		}
+		return files[i].Digest < files[j].Digest
+	})
+	if len(files) > maxDedupFiles {
+		files = files[:maxDedupFiles]
+	}
+	rep.Files = files
+	if rep.Files == nil {
+		rep.Files = []*types.DuplicatedFile{}
+	}
+	return rep, nil
+}
@@ -21,8 +21,9 @@ const (
 	conflictRunningContainer
 	conflictActiveReference
 	conflictStoppedContainer
+	conflictPinned
 	conflictHard = conflictDependentChild | conflictRunningContainer
-	conflictSoft = conflictActiveReference | conflictStoppedContainer
+	conflictSoft = conflictActiveReference | conflictStoppedContainer | conflictPinned
 )
 
 // ImageDelete deletes the image referenced by the given imageRef from this
@@ -51,6 +52,7 @@ const (
 // Soft Conflict:
 // 	- any stopped container using the image.
 // 	- any repository tag or digest references to the image.
+// 	- the image is pinned.
 //
 // The image cannot be removed if there are any hard conflicts and can be
 // removed if there are soft conflicts only if force is true.
@@ -90,6 +92,12 @@ func (i *ImageService) ImageDelete(imageRef string, force, prune bool) ([]types.
 				err := errors.Errorf("conflict: unable to remove repository reference %q (must force) - container %s is using its referenced image %s", imageRef, stringid.TruncateID(container.ID), stringid.TruncateID(imgID.String()))
 				return nil, errdefs.Conflict(err)
 			}
+			if i.imageStore.IsPinned(imgID) {
+				// Removing the last reference would delete the image,
+				// which is not allowed for pinned images.
+				err := errors.Errorf("conflict: unable to remove repository reference %q (must force) - image %s is pinned", imageRef, stringid.TruncateID(imgID.String()))
+				return nil, errdefs.Conflict(err)
+			}
 		}
 
 		parsedRef, err := reference.ParseNormalizedNamed(imageRef)
@@ -97,6 +105,8 @@ func (i *ImageService) ImageDelete(imageRef string, force, prune bool) ([]types.
 			return nil, err
 		}
 
+		variants := i.referenceStore.Platforms(parsedRef)
+
 		parsedRef, err = i.removeImageRef(parsedRef)
 		if err != nil {
 			return nil, err
@@ -107,6 +117,18 @@ func (i *ImageService) ImageDelete(imageRef string, force, prune bool) ([]types.
 		i.LogImageEvent(imgID.String(), imgID.String(), "untag")
 		records = append(records, untaggedRecord)
 
+		// Quietly delete the images of the other platform variants of the
+		// tag, which would otherwise be left dangling.
+		for _, v := range variants {
+			variantID := image.IDFromDigest(v.ID)
+			if variantID == imgID || len(i.referenceStore.References(v.ID)) > 0 {
+				continue
+			}
+			if err := i.imageDeleteHelper(variantID, &records, force, prune, true); err != nil {
+				return records, err
+			}
+		}
+
 		repoRefs = i.referenceStore.References(imgID.Digest())
 
 		// If a tag reference was removed and the only remaining
@@ -160,7 +182,7 @@ func (i *ImageService) ImageDelete(imageRef string, force, prune bool) ([]types.
 			}
 
 			for _, repoRef := range repoRefs {
-				parsedRef, err := i.removeImageRef(repoRef)
+				parsedRef, err := i.removeImageVariantRef(repoRef, imgID)
 				if err != nil {
 					return nil, err
 				}
@@ -237,6 +259,19 @@ func (i *ImageService) removeImageRef(ref reference.Named) (reference.Named, err
 	return ref, err
 }
 
+// removeImageVariantRef removes the given reference to imgID, like
+// removeImageRef. If the reference is a tag with platform variants, only the
+// variant of imgID is removed, and the tag keeps its variants for other
+// platforms.
+func (i *ImageService) removeImageVariantRef(ref reference.Named, imgID image.ID) (reference.Named, error) {
+	if _, isCanonical := ref.(reference.Canonical); isCanonical || len(i.referenceStore.Platforms(ref)) == 0 {
+		return i.removeImageRef(ref)
+	}
+	ref = reference.TagNameOnly(ref)
+	_, err := i.referenceStore.DeletePlatform(ref, imgID.Digest())
+	return ref, err
+}
+
 // removeAllReferencesToImageID attempts to remove every reference to the given
 // imgID from this daemon's store of repository tag/digest references. Returns
 // on the first encountered error. Removed references are logged to this
@@ -246,7 +281,7 @@ func (i *ImageService) removeAllReferencesToImageID(imgID image.ID, records *[]t
 	imageRefs := i.referenceStore.References(imgID.Digest())
 
 	for _, imageRef := range imageRefs {
-		parsedRef, err := i.removeImageRef(imageRef)
+		parsedRef, err := i.removeImageVariantRef(imageRef, imgID)
 		if err != nil {
 			return err
 		}
@@ -265,6 +300,7 @@ func (i *ImageService) removeAllReferencesToImageID(imgID image.ID, records *[]t
 type imageDeleteConflict struct {
 	hard    bool
 	used    bool
+	pinned  bool
 	imgID   image.ID
 	message string
 }
@@ -301,9 +337,9 @@ func (i *ImageService) imageDeleteHelper(imgID image.ID, records *[]types.ImageD
 		c |= conflictSoft
 	}
 	if conflict := i.checkImageDeleteConflict(imgID, c); conflict != nil {
-		if quiet && (!i.imageIsDangling(imgID) || conflict.used) {
-			// Ignore conflicts UNLESS the image is "dangling" or not being used in
-			// which case we want the user to know.
+		if quiet && (!i.imageIsDangling(imgID) || conflict.used || conflict.pinned) {
+			// Ignore conflicts UNLESS the image is "dangling" or not being used
+			// (and not pinned) in which case we want the user to know.
 			return nil
 		}
 
@@ -350,8 +386,9 @@ func (i *ImageService) imageDeleteHelper(imgID image.ID, records *[]types.ImageD
 // preventing deletion of the given image from this daemon. A hard conflict is
 // any image which has the given image as a parent or any running container
 // using the image. A soft conflict is any tags/digest referencing the given
-// image or any stopped container using the image. If ignoreSoftConflicts is
-// true, this function will not check for soft conflict conditions.
+// image, any stopped container using the image, or the image being pinned.
+// If ignoreSoftConflicts is true, this function will not check for soft
+// conflict conditions.
 func (i *ImageService) checkImageDeleteConflict(imgID image.ID, mask conflictType) *imageDeleteConflict {
 	// Check if the image has any descendant images.
 	if mask&conflictDependentChild != 0 && len(i.imageStore.Children(imgID)) > 0 {
@@ -399,6 +436,14 @@ func (i *ImageService) checkImageDeleteConflict(imgID image.ID, mask conflictTyp
 		}
 	}
 
+	if mask&conflictPinned != 0 && i.imageStore.IsPinned(imgID) {
+		return &imageDeleteConflict{
+			imgID:   imgID,
+			pinned:  true,
+			message: "image is pinned",
+		}
+	}
+
 	return nil
 }
 
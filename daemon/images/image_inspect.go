@@ -81,6 +81,7 @@ func (i *ImageService) LookupImage(name string) (*types.ImageInspect, error) {
 		RootFS: rootFSToAPIType(img.RootFS),
 		Metadata: types.ImageMetadata{
 			LastTagTime: lastUpdated,
+			Pinned:      i.imageStore.IsPinned(img.ID()),
 		},
 	}, nil
 }
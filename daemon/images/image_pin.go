@@ -0,0 +1,23 @@
+package images // import "github.com/docker/docker/daemon/images"
+
+// ImagePin pins or unpins the image named imageName (alternatively, imageName
+// can also be an image ID). Pinned images are skipped by ImagesPrune, and
+// cannot be removed by ImageDelete unless force is set.
+func (i *ImageService) ImagePin(imageName string, pinned bool) error {
+	img, err := i.GetImage(imageName, nil)
+	if err != nil {
+		return err
+	}
+
+	imgID := img.ID()
+	if err := i.imageStore.SetPinned(imgID, pinned); err != nil {
+		return err
+	}
+
+	action := "pin"
+	if !pinned {
+		action = "unpin"
+	}
+	i.LogImageEvent(imgID.String(), imageName, action)
+	return nil
+}
@@ -0,0 +1,238 @@
+package images // import "github.com/docker/docker/daemon/images"
+
+import (
+	"context"
+	"encoding/json"
+
+	dist "github.com/docker/distribution"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/image/policy"
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// SetImagePolicy loads the image signature policy at path, and its trust
+// store, which apply to the images that are pulled and run from then on. An
+// empty path removes the policy. The current policy is kept if the policy
+// cannot be loaded.
+func (i *ImageService) SetImagePolicy(path string) error {
+	var p *policy.Policy
+	if path != "" {
+		var err error
+		p, err = policy.Load(path)
+		if err != nil {
+			return errors.Wrap(err, "failed to load image policy")
+		}
+	}
+	i.imagePolicyMu.Lock()
+	i.imagePolicy = p
+	i.imagePolicyMu.Unlock()
+	return nil
+}
+
+// getImagePolicy returns the image signature policy, or nil if no policy is
+// configured.
+func (i *ImageService) getImagePolicy() *policy.Policy {
+	i.imagePolicyMu.RLock()
+	defer i.imagePolicyMu.RUnlock()
+	return i.imagePolicy
+}
+
+// ImagePolicyEnabled returns whether an image signature policy is configured.
+func (i *ImageService) ImagePolicyEnabled() bool {
+	return i.getImagePolicy() != nil
+}
+
+// rejectImage logs an event for an image that is rejected by the image
+// policy, and returns the error of the rejection.
+func (i *ImageService) rejectImage(imageID, refName string, err error) error {
+	logrus.WithError(err).WithField("image", refName).Warn("image rejected by image policy")
+	i.LogImageEventWithAttributes(imageID, refName, "reject", map[string]string{"reason": err.Error()})
+	return errdefs.Forbidden(err)
+}
+
+// policyVerifier verifies the manifests that are pulled against the image
+// policy, and keeps the signatures by which they were verified.
+type policyVerifier struct {
+	images   *ImageService
+	policy   *policy.Policy
+	verified []verifiedManifest
+}
+
+type verifiedManifest struct {
+	ref        reference.Canonical
+	signatures []policy.Signature
+}
+
+// VerifyManifest implements distribution.ManifestVerifier.
+func (v *policyVerifier) VerifyManifest(ctx context.Context, repo dist.Repository, ref reference.Named, dgst digest.Digest) error {
+	var signatures []policy.Signature
+	if v.policy.RequirementFor(ref).Type == policy.TypeSignedBy {
+		var err error
+		signatures, err = policy.Fetch(ctx, repo, dgst)
+		if err != nil {
+			return v.images.rejectImage(reference.FamiliarString(ref), reference.FamiliarName(ref), err)
+		}
+	}
+	if err := v.policy.Verify(ref, dgst, signatures); err != nil {
+		return v.images.rejectImage(reference.FamiliarString(ref), reference.FamiliarName(ref), err)
+	}
+	if len(signatures) > 0 {
+		canonical, err := reference.WithDigest(reference.TrimNamed(ref), dgst)
+		if err != nil {
+			return err
+		}
+		v.verified = append(v.verified, verifiedManifest{ref: canonical, signatures: signatures})
+	}
+	return nil
+}
+
+// VerifyImageManifest verifies the manifest with digest dgst of the image ref
+// against the image policy, as pulls do, and fetches its signatures from the
+// registry if the requirement of its repository needs them. It is used for
+// images that BuildKit builds pull from registries.
+func (i *ImageService) VerifyImageManifest(ctx context.Context, ref reference.Named, dgst digest.Digest) error {
+	p := i.getImagePolicy()
+	if p == nil {
+		return nil
+	}
+	var repo dist.Repository
+	if p.RequirementFor(ref).Type == policy.TypeSignedBy {
+		var err error
+		repo, err = i.GetRepository(ctx, ref, &types.AuthConfig{})
+		if err != nil {
+			return i.rejectImage(reference.FamiliarString(ref), reference.FamiliarName(ref), err)
+		}
+	}
+	v := &policyVerifier{images: i, policy: p}
+	return v.VerifyManifest(ctx, repo, ref, dgst)
+}
+
+// storeSignatures records the verified signatures with the images that were
+// pulled, so that the images can be verified when containers are created.
+func (v *policyVerifier) storeSignatures() error {
+	for _, m := range v.verified {
+		id, err := v.images.referenceStore.Get(m.ref)
+		if err != nil {
+			return errors.Wrapf(err, "failed to resolve %s", reference.FamiliarString(m.ref))
+		}
+		if err := v.images.addSignatures(image.IDFromDigest(id), m.signatures); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *ImageService) getSignatures(id image.ID) ([]policy.Signature, error) {
+	dt, err := i.imageStore.GetSignatures(id)
+	if err != nil || len(dt) == 0 {
+		return nil, err
+	}
+	var signatures []policy.Signature
+	if err := json.Unmarshal(dt, &signatures); err != nil {
+		return nil, errors.Wrapf(err, "invalid signatures of image %s", id)
+	}
+	return signatures, nil
+}
+
+func (i *ImageService) addSignatures(id image.ID, signatures []policy.Signature) error {
+	existing, err := i.getSignatures(id)
+	if err != nil {
+		return err
+	}
+	for _, sig := range signatures {
+		if !containsSignature(existing, sig) {
+			existing = append(existing, sig)
+		}
+	}
+	dt, err := json.Marshal(existing)
+	if err != nil {
+		return err
+	}
+	return i.imageStore.SetSignatures(id, dt)
+}
+
+func containsSignature(signatures []policy.Signature, sig policy.Signature) bool {
+	for _, s := range signatures {
+		if string(s.Payload) == string(sig.Payload) && string(s.Signature) == string(sig.Signature) {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyImagePolicy returns an error if containers must not be created from
+// img, which was looked up by refOrID, according to the image policy. If
+// refOrID is a reference, the requirement of its repository applies.
+// Otherwise the requirements of all repositories that reference the image
+// apply. Signatures are verified against the signatures that were recorded
+// when the image was pulled, so this does not contact the registry.
+func (i *ImageService) VerifyImagePolicy(refOrID string, img *image.Image) error {
+	p := i.getImagePolicy()
+	if p == nil {
+		return nil
+	}
+
+	id := img.ID()
+	names := i.policyNames(refOrID, id)
+	if len(names) == 0 {
+		if p.Default.Type == policy.TypeAccept {
+			return nil
+		}
+		return i.rejectImage(id.String(), "", errors.Errorf("image %s has no repository name, and is rejected by the image policy", refOrID))
+	}
+
+	signatures, err := i.getSignatures(id)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := i.verifyImageName(p, name, id, signatures); err != nil {
+			return i.rejectImage(id.String(), reference.FamiliarName(name), err)
+		}
+	}
+	return nil
+}
+
+// policyNames returns the repositories of which the requirements apply to
+// the image with the given ID that was looked up by refOrID.
+func (i *ImageService) policyNames(refOrID string, id image.ID) []reference.Named {
+	if ref, err := reference.ParseNormalizedNamed(refOrID); err == nil {
+		if refID, err := i.referenceStore.Get(reference.TagNameOnly(ref)); err == nil && image.IDFromDigest(refID) == id {
+			return []reference.Named{reference.TrimNamed(ref)}
+		}
+	}
+	var names []reference.Named
+	seen := map[string]bool{}
+	for _, ref := range i.referenceStore.References(id.Digest()) {
+		if !seen[ref.Name()] {
+			seen[ref.Name()] = true
+			names = append(names, reference.TrimNamed(ref))
+		}
+	}
+	return names
+}
+
+// verifyImageName verifies the image with the given ID against the
+// requirement of the repository of name, using the digests of the image in
+// that repository.
+func (i *ImageService) verifyImageName(p *policy.Policy, name reference.Named, id image.ID, signatures []policy.Signature) error {
+	if p.RequirementFor(name).Type != policy.TypeSignedBy {
+		return p.Verify(name, "", nil)
+	}
+	err := errors.Errorf("%s has no digest in %s, and cannot be verified", id, reference.FamiliarName(name))
+	for _, ref := range i.referenceStore.References(id.Digest()) {
+		canonical, ok := ref.(reference.Canonical)
+		if !ok || canonical.Name() != name.Name() {
+			continue
+		}
+		if err = p.Verify(name, canonical.Digest(), signatures); err == nil {
+			return nil
+		}
+	}
+	return err
+}
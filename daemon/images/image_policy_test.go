@@ -0,0 +1,256 @@
+package images
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	dist "github.com/docker/distribution"
+	distmanifest "github.com/docker/distribution/manifest"
+	"github.com/docker/distribution/manifest/ocischema"
+	"github.com/docker/distribution/reference"
+	daemonevents "github.com/docker/docker/daemon/events"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/image/policy"
+	dockerreference "github.com/docker/docker/reference"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+const testImagePolicy = `{
+	"default": {"type": "insecureAcceptAnything"},
+	"repositories": {
+		"docker.io/library/busybox": {"type": "signedBy", "keys": ["release"]},
+		"docker.io/evil/*": {"type": "reject"}
+	}
+}`
+
+// newPolicyTestService returns an ImageService with the given image policy,
+// and the key of which signatures are required by the policy.
+func newPolicyTestService(t *testing.T, imagePolicy string) (*ImageService, *ecdsa.PrivateKey) {
+	t.Helper()
+	dir := t.TempDir()
+	backend, err := image.NewFSStoreBackend(filepath.Join(dir, "images"))
+	assert.NilError(t, err)
+	imageStore, err := image.NewImageStore(backend, nil)
+	assert.NilError(t, err)
+	referenceStore, err := dockerreference.NewReferenceStore(filepath.Join(dir, "repositories.json"))
+	assert.NilError(t, err)
+	i := &ImageService{
+		imageStore:     imageStore,
+		referenceStore: referenceStore,
+		eventsService:  daemonevents.New(),
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	assert.NilError(t, err)
+	assert.NilError(t, os.MkdirAll(filepath.Join(dir, "trust"), 0o755))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "trust", "release.pub"), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "policy.json"), []byte(imagePolicy), 0o644))
+	assert.NilError(t, i.SetImagePolicy(filepath.Join(dir, "policy.json")))
+	return i, key
+}
+
+func signManifest(t *testing.T, key *ecdsa.PrivateKey, repo string, dgst digest.Digest) policy.Signature {
+	t.Helper()
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":%q},"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`, repo, dgst))
+	hash := sha256.Sum256(payload)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	assert.NilError(t, err)
+	return policy.Signature{Payload: payload, Signature: sig}
+}
+
+// signatureRepository is a repository that only holds the signature
+// manifest of a manifest, and the payloads of its signatures.
+type signatureRepository struct {
+	dist.Repository
+	manifest dist.Manifest
+	payloads map[digest.Digest][]byte
+}
+
+func newSignatureRepository(t *testing.T, signatures ...policy.Signature) *signatureRepository {
+	t.Helper()
+	r := &signatureRepository{payloads: map[digest.Digest][]byte{}}
+	var layers []dist.Descriptor
+	for _, sig := range signatures {
+		dgst := digest.FromBytes(sig.Payload)
+		r.payloads[dgst] = sig.Payload
+		layers = append(layers, dist.Descriptor{
+			MediaType:   "application/vnd.dev.cosign.simplesigning.v1+json",
+			Digest:      dgst,
+			Size:        int64(len(sig.Payload)),
+			Annotations: map[string]string{"dev.cosignproject.cosign/signature": base64.StdEncoding.EncodeToString(sig.Signature)},
+		})
+	}
+	m, err := ocischema.FromStruct(ocischema.Manifest{
+		Versioned: distmanifest.Versioned{SchemaVersion: 2, MediaType: specs.MediaTypeImageManifest},
+		Layers:    layers,
+	})
+	assert.NilError(t, err)
+	r.manifest = m
+	return r
+}
+
+func (r *signatureRepository) Manifests(context.Context, ...dist.ManifestServiceOption) (dist.ManifestService, error) {
+	return &signatureManifests{r: r}, nil
+}
+
+func (r *signatureRepository) Blobs(context.Context) dist.BlobStore {
+	return &signatureBlobs{r: r}
+}
+
+type signatureManifests struct {
+	dist.ManifestService
+	r *signatureRepository
+}
+
+func (m *signatureManifests) Get(context.Context, digest.Digest, ...dist.ManifestServiceOption) (dist.Manifest, error) {
+	return m.r.manifest, nil
+}
+
+type signatureBlobs struct {
+	dist.BlobStore
+	r *signatureRepository
+}
+
+func (b *signatureBlobs) Get(_ context.Context, dgst digest.Digest) ([]byte, error) {
+	dt, ok := b.r.payloads[dgst]
+	if !ok {
+		return nil, dist.ErrBlobUnknown
+	}
+	return dt, nil
+}
+
+func TestPolicyVerifier(t *testing.T) {
+	i, key := newPolicyTestService(t, testImagePolicy)
+	v := &policyVerifier{images: i, policy: i.getImagePolicy()}
+	ctx := context.Background()
+	dgst := digest.FromString("manifest")
+
+	// Accepted repositories do not need signatures.
+	alpine, _ := reference.ParseNormalizedNamed("alpine:latest")
+	assert.Check(t, v.VerifyManifest(ctx, nil, alpine, dgst))
+
+	evil, _ := reference.ParseNormalizedNamed("evil/app:latest")
+	err := v.VerifyManifest(ctx, nil, evil, dgst)
+	assert.Check(t, errdefs.IsForbidden(err))
+	assert.Check(t, is.ErrorContains(err, "rejected by the image policy"))
+
+	busybox, _ := reference.ParseNormalizedNamed("busybox:latest")
+	err = v.VerifyManifest(ctx, newSignatureRepository(t), busybox, dgst)
+	assert.Check(t, errdefs.IsForbidden(err))
+	assert.Check(t, is.ErrorContains(err, "is not signed"))
+
+	err = v.VerifyManifest(ctx, newSignatureRepository(t, signManifest(t, key, "docker.io/library/busybox", digest.FromString("other"))), busybox, dgst)
+	assert.Check(t, errdefs.IsForbidden(err))
+	assert.Check(t, is.Len(v.verified, 0))
+
+	sig := signManifest(t, key, "docker.io/library/busybox", dgst)
+	assert.Check(t, v.VerifyManifest(ctx, newSignatureRepository(t, sig), busybox, dgst))
+	assert.Assert(t, is.Len(v.verified, 1))
+	assert.Check(t, is.Equal(v.verified[0].ref.String(), "docker.io/library/busybox@"+dgst.String()))
+
+	// The signatures are stored with the image that was pulled.
+	img, err := i.imageStore.Create([]byte(`{"os": "linux", "architecture": "amd64", "rootfs": {"type": "layers"}}`))
+	assert.NilError(t, err)
+	assert.NilError(t, i.referenceStore.AddDigest(v.verified[0].ref, img.Digest(), false))
+	assert.NilError(t, v.storeSignatures())
+	signatures, err := i.getSignatures(img)
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(signatures, []policy.Signature{sig}))
+
+	// Storing them again does not duplicate them.
+	assert.NilError(t, v.storeSignatures())
+	signatures, err = i.getSignatures(img)
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(signatures, 1))
+}
+
+func TestVerifyImagePolicy(t *testing.T) {
+	i, key := newPolicyTestService(t, testImagePolicy)
+
+	create := func(comment string, refs ...string) *image.Image {
+		t.Helper()
+		id, err := i.imageStore.Create([]byte(fmt.Sprintf(`{"os": "linux", "architecture": "amd64", "comment": %q, "rootfs": {"type": "layers"}}`, comment)))
+		assert.NilError(t, err)
+		for _, r := range refs {
+			ref, err := reference.ParseNormalizedNamed(r)
+			assert.NilError(t, err)
+			if canonical, ok := ref.(reference.Canonical); ok {
+				assert.NilError(t, i.referenceStore.AddDigest(canonical, id.Digest(), false))
+			} else {
+				assert.NilError(t, i.referenceStore.AddTag(ref, id.Digest(), false))
+			}
+		}
+		img, err := i.imageStore.Get(id)
+		assert.NilError(t, err)
+		return img
+	}
+
+	signedDigest := digest.FromString("signed")
+	signed := create("signed", "busybox:latest", "busybox@"+signedDigest.String(), "alpine:latest")
+	assert.NilError(t, i.addSignatures(signed.ID(), []policy.Signature{signManifest(t, key, "docker.io/library/busybox", signedDigest)}))
+	unsigned := create("unsigned", "busybox:unsigned", "busybox@"+digest.FromString("unsigned").String())
+	undigested := create("undigested", "busybox:undigested")
+	evil := create("evil", "evil/app:latest", "alpine:evil")
+	dangling := create("dangling")
+
+	// By reference, only the requirement of its repository applies.
+	assert.Check(t, is.DeepEqual(policyNameStrings(i.policyNames("alpine:evil", evil.ID())), []string{"docker.io/library/alpine"}))
+	assert.Check(t, i.VerifyImagePolicy("alpine:evil", evil))
+	// By ID, the requirements of all repositories of the image apply.
+	assert.Check(t, is.DeepEqual(policyNameStrings(i.policyNames(signed.ID().String(), signed.ID())), []string{"docker.io/library/alpine", "docker.io/library/busybox"}))
+	err := i.VerifyImagePolicy(evil.ID().String(), evil)
+	assert.Check(t, errdefs.IsForbidden(err))
+	assert.Check(t, is.ErrorContains(err, "rejected by the image policy"))
+
+	assert.Check(t, i.VerifyImagePolicy("busybox", signed))
+	assert.Check(t, i.VerifyImagePolicy(signed.ID().String(), signed))
+
+	err = i.VerifyImagePolicy("busybox:unsigned", unsigned)
+	assert.Check(t, errdefs.IsForbidden(err))
+	assert.Check(t, is.ErrorContains(err, "is not signed"))
+
+	err = i.VerifyImagePolicy("busybox:undigested", undigested)
+	assert.Check(t, errdefs.IsForbidden(err))
+	assert.Check(t, is.ErrorContains(err, "has no digest in busybox"))
+
+	// Images without a name are subject to the default requirement.
+	assert.Check(t, i.VerifyImagePolicy(dangling.ID().String(), dangling))
+	i, _ = newPolicyTestService(t, `{"default": {"type": "reject"}}`)
+	err = i.VerifyImagePolicy(dangling.ID().String(), dangling)
+	assert.Check(t, errdefs.IsForbidden(err))
+	assert.Check(t, is.ErrorContains(err, "has no repository name"))
+}
+
+func TestVerifyImagePolicyDisabled(t *testing.T) {
+	i, _ := newPolicyTestService(t, testImagePolicy)
+	assert.Check(t, i.ImagePolicyEnabled())
+	assert.NilError(t, i.SetImagePolicy(""))
+	assert.Check(t, !i.ImagePolicyEnabled())
+
+	evil, _ := reference.ParseNormalizedNamed("evil/app:latest")
+	assert.Check(t, i.VerifyImageManifest(context.Background(), evil, digest.FromString("manifest")))
+}
+
+func policyNameStrings(names []reference.Named) []string {
+	var strs []string
+	for _, name := range names {
+		strs = append(strs, name.String())
+	}
+	return strs
+}
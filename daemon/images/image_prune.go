@@ -79,6 +79,9 @@ func (i *ImageService) ImagesPrune(ctx context.Context, pruneFilters filters.Arg
 			if len(i.referenceStore.References(dgst)) == 0 && len(i.imageStore.Children(id)) != 0 {
 				continue
 			}
+			if i.imageStore.IsPinned(id) {
+				continue
+			}
 			if !until.IsZero() && img.Created.After(until) {
 				continue
 			}
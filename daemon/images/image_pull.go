@@ -119,15 +119,25 @@ func (i *ImageService) pullImageWithReference(ctx context.Context, ref reference
 			ImageEventLogger: i.LogImageEvent,
 			MetadataStore:    i.distributionMetadataStore,
 			ImageStore:       imageStore,
-			ReferenceStore:   i.referenceStore,
+			ReferenceStore:   &platformReferenceStore{Store: i.referenceStore, images: i},
 		},
-		DownloadManager: i.downloadManager,
-		Platform:        platform,
+		DownloadManager:     i.downloadManager,
+		Platform:            platform,
+		PartialDownloadRoot: i.partialDownloadRoot,
+	}
+
+	var verifier *policyVerifier
+	if imagePolicy := i.getImagePolicy(); imagePolicy != nil {
+		verifier = &policyVerifier{images: i, policy: imagePolicy}
+		imagePullConfig.ManifestVerifier = verifier
 	}
 
 	err = distribution.Pull(ctx, ref, imagePullConfig, cs)
 	close(progressChan)
 	<-writesDone
+	if err == nil && verifier != nil {
+		err = verifier.storeSignatures()
+	}
 	return err
 }
 
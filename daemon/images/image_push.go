@@ -5,17 +5,22 @@ import (
 	"io"
 	"time"
 
+	"github.com/containerd/containerd/platforms"
+	"github.com/docker/distribution/manifest/manifestlist"
 	"github.com/docker/distribution/manifest/schema2"
 	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/api/types"
+	imagetypes "github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/distribution"
 	progressutils "github.com/docker/docker/distribution/utils"
+	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/pkg/progress"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
 )
 
 // PushImage initiates a push operation on the repository named localName.
 func (i *ImageService) PushImage(ctx context.Context, image, tag string, metaHeaders map[string][]string, authConfig *types.AuthConfig, outStream io.Writer) error {
-	start := time.Now()
 	ref, err := reference.ParseNormalizedNamed(image)
 	if err != nil {
 		return err
@@ -28,6 +33,71 @@ func (i *ImageService) PushImage(ctx context.Context, image, tag string, metaHea
 		}
 	}
 
+	return i.push(ctx, ref, metaHeaders, authConfig, outStream, nil, "")
+}
+
+// PushManifestList pushes a manifest list of the local images of list, with
+// the tag of the repository named image.
+func (i *ImageService) PushManifestList(ctx context.Context, image, tag string, list imagetypes.ManifestList, metaHeaders map[string][]string, authConfig *types.AuthConfig, outStream io.Writer) error {
+	ref, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return errdefs.InvalidParameter(err)
+	}
+	if tag != "" {
+		ref, err = reference.WithTag(ref, tag)
+		if err != nil {
+			return errdefs.InvalidParameter(err)
+		}
+	}
+	ref = reference.TagNameOnly(ref)
+	if _, ok := ref.(reference.NamedTagged); !ok {
+		return errdefs.InvalidParameter(errors.New("a manifest list can only be pushed to a tag"))
+	}
+
+	switch list.MediaType {
+	case "", manifestlist.MediaTypeManifestList, specs.MediaTypeImageIndex:
+	default:
+		return errdefs.InvalidParameter(errors.Errorf("unsupported manifest list media type %q", list.MediaType))
+	}
+	if len(list.Manifests) == 0 {
+		return errdefs.InvalidParameter(errors.New("a manifest list requires at least one image"))
+	}
+
+	entries := make([]distribution.ManifestListEntry, 0, len(list.Manifests))
+	for _, m := range list.Manifests {
+		img, err := i.GetImage(m.Image, m.Platform)
+		if err != nil {
+			return err
+		}
+		platform := imagePlatform(img)
+		if m.Platform != nil {
+			platform = *m.Platform
+		}
+		if platform.OS == "" || platform.Architecture == "" {
+			return errdefs.InvalidParameter(errors.Errorf("image %s has no platform", m.Image))
+		}
+		// Clients select a single image of a manifest list by its platform,
+		// so the images must have different platforms. Windows images for
+		// different OS versions can be in the same manifest list.
+		for j, e := range entries {
+			if samePlatform(e.Platform, platform) && e.Platform.OSVersion == platform.OSVersion {
+				return errdefs.InvalidParameter(errors.Errorf("images %s and %s have the same platform %s", list.Manifests[j].Image, m.Image, platforms.Format(platform)))
+			}
+		}
+		entries = append(entries, distribution.ManifestListEntry{
+			ID:          img.ID().Digest(),
+			Platform:    platform,
+			Annotations: m.Annotations,
+		})
+	}
+	return i.push(ctx, ref, metaHeaders, authConfig, outStream, entries, list.MediaType)
+}
+
+// push pushes ref, or a manifest list of manifestList with the tag of ref if
+// manifestList is not empty.
+func (i *ImageService) push(ctx context.Context, ref reference.Named, metaHeaders map[string][]string, authConfig *types.AuthConfig, outStream io.Writer, manifestList []distribution.ManifestListEntry, manifestListMediaType string) error {
+	start := time.Now()
+
 	// Include a buffer so that slow client connections don't affect
 	// transfer performance.
 	progressChan := make(chan progress.Progress, 100)
@@ -52,13 +122,15 @@ func (i *ImageService) PushImage(ctx context.Context, image, tag string, metaHea
 			ImageStore:       distribution.NewImageConfigStoreFromStore(i.imageStore),
 			ReferenceStore:   i.referenceStore,
 		},
-		ConfigMediaType: schema2.MediaTypeImageConfig,
-		LayerStores:     distribution.NewLayerProvidersFromStore(i.layerStore),
-		TrustKey:        i.trustKey,
-		UploadManager:   i.uploadManager,
+		ConfigMediaType:       schema2.MediaTypeImageConfig,
+		LayerStores:           distribution.NewLayerProvidersFromStore(i.layerStore),
+		TrustKey:              i.trustKey,
+		UploadManager:         i.uploadManager,
+		ManifestList:          manifestList,
+		ManifestListMediaType: manifestListMediaType,
 	}
 
-	err = distribution.Push(ctx, ref, imagePushConfig)
+	err := distribution.Push(ctx, ref, imagePushConfig)
 	close(progressChan)
 	<-writesDone
 	imageActions.WithValues("push").UpdateSince(start)
@@ -0,0 +1,107 @@
+package images // import "github.com/docker/docker/daemon/images"
+
+import (
+	"github.com/containerd/containerd/platforms"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/image"
+	dockerreference "github.com/docker/docker/reference"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// imagePlatform returns the platform of img.
+func imagePlatform(img *image.Image) specs.Platform {
+	return specs.Platform{
+		OS:           img.OperatingSystem(),
+		Architecture: img.Architecture,
+		Variant:      img.Variant,
+	}
+}
+
+func samePlatform(a, b specs.Platform) bool {
+	return platforms.Format(platforms.Normalize(a)) == platforms.Format(platforms.Normalize(b))
+}
+
+// addPlatformVariant sets the tag ref to the image with the given ID, as the
+// variant of the tag for the platform of the image. The variants of the tag
+// for other platforms are kept, so that a single tag can refer to an image
+// for multiple platforms.
+func (i *ImageService) addPlatformVariant(ref reference.Named, id image.ID) error {
+	img, err := i.imageStore.Get(id)
+	if err != nil {
+		return err
+	}
+	platform := imagePlatform(img)
+
+	if len(i.referenceStore.Platforms(ref)) == 0 {
+		// The tag is set to a single image. Keep that image as a variant
+		// if it is for another platform.
+		if oldID, err := i.referenceStore.Get(ref); err == nil && oldID != id.Digest() {
+			if old, err := i.imageStore.Get(image.IDFromDigest(oldID)); err == nil && !samePlatform(imagePlatform(old), platform) {
+				if err := i.referenceStore.AddPlatform(ref, imagePlatform(old), oldID); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return i.referenceStore.AddPlatform(ref, platform, id.Digest())
+}
+
+// platformVariant returns the ID of the variant of the tag ref that matches
+// platform. The image that the tag is set to is preferred if it matches.
+func (i *ImageService) platformVariant(ref reference.Named, platform specs.Platform) (image.ID, bool) {
+	variants := i.referenceStore.Platforms(ref)
+	if len(variants) == 0 {
+		return "", false
+	}
+	defaultID, _ := i.referenceStore.Get(ref)
+	matcher := OnlyPlatformWithFallback(platform)
+
+	var match digest.Digest
+	for _, v := range variants {
+		if !matcher.Match(v.Platform) {
+			continue
+		}
+		if v.ID == defaultID {
+			return image.IDFromDigest(v.ID), true
+		}
+		if match == "" {
+			match = v.ID
+		}
+	}
+	if match == "" {
+		return "", false
+	}
+	return image.IDFromDigest(match), true
+}
+
+// TagPlatformVariantWithReference adds the given reference to the image ID
+// provided, as the variant of the reference for the platform of the image.
+// Unlike TagImageWithReference, the images of the reference for other
+// platforms are kept.
+func (i *ImageService) TagPlatformVariantWithReference(imageID image.ID, newTag reference.Named) error {
+	if err := i.addPlatformVariant(newTag, imageID); err != nil {
+		return err
+	}
+
+	if err := i.imageStore.SetLastUpdated(imageID); err != nil {
+		return err
+	}
+	i.LogImageEvent(imageID.String(), reference.FamiliarString(newTag), "tag")
+	return nil
+}
+
+// platformReferenceStore is a reference store that adds images to tags as
+// platform variants, instead of replacing the images of the tags. It is used
+// for pulls, so that pulling an image for another platform keeps the images
+// that were pulled before.
+type platformReferenceStore struct {
+	dockerreference.Store
+	images *ImageService
+}
+
+// AddTag adds the image with the given ID to ref as the variant for its
+// platform. Existing tags are always overwritten, as pulls do.
+func (s *platformReferenceStore) AddTag(ref reference.Named, id digest.Digest, _ bool) error {
+	return s.images.addPlatformVariant(reference.TagNameOnly(ref), image.IDFromDigest(id))
+}
@@ -0,0 +1,212 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/containerd/platforms"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+	imagetypes "github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/container"
+	daemonevents "github.com/docker/docker/daemon/events"
+	"github.com/docker/docker/image"
+	dockerreference "github.com/docker/docker/reference"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestPlatformVariants(t *testing.T) {
+	dir := t.TempDir()
+	backend, err := image.NewFSStoreBackend(filepath.Join(dir, "images"))
+	assert.NilError(t, err)
+	imageStore, err := image.NewImageStore(backend, nil)
+	assert.NilError(t, err)
+	referenceStore, err := dockerreference.NewReferenceStore(filepath.Join(dir, "repositories.json"))
+	assert.NilError(t, err)
+	i := &ImageService{imageStore: imageStore, referenceStore: referenceStore}
+
+	amd64, err := imageStore.Create([]byte(`{"os": "linux", "architecture": "amd64", "rootfs": {"type": "layers"}}`))
+	assert.NilError(t, err)
+	arm64, err := imageStore.Create([]byte(`{"os": "linux", "architecture": "arm64", "rootfs": {"type": "layers"}}`))
+	assert.NilError(t, err)
+	newAmd64, err := imageStore.Create([]byte(`{"os": "linux", "architecture": "amd64", "comment": "new", "rootfs": {"type": "layers"}}`))
+	assert.NilError(t, err)
+
+	ref, err := reference.ParseNormalizedNamed("foo:latest")
+	assert.NilError(t, err)
+
+	// A tag that was set to a single image keeps that image as a variant.
+	assert.NilError(t, referenceStore.AddTag(ref, amd64.Digest(), true))
+	assert.NilError(t, i.addPlatformVariant(ref, arm64))
+	assert.Check(t, is.Len(referenceStore.Platforms(ref), 2))
+
+	img, err := i.GetImage("foo", &specs.Platform{OS: "linux", Architecture: "amd64"})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(img.ID(), amd64))
+	img, err = i.GetImage("foo", &specs.Platform{OS: "linux", Architecture: "arm64"})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(img.ID(), arm64))
+
+	// A new image for the same platform replaces the variant.
+	assert.NilError(t, i.addPlatformVariant(ref, newAmd64))
+	assert.Check(t, is.Len(referenceStore.References(amd64.Digest()), 0))
+	img, err = i.GetImage("foo", &specs.Platform{OS: "linux", Architecture: "amd64"})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(img.ID(), newAmd64))
+	img, err = i.GetImage("foo", &specs.Platform{OS: "linux", Architecture: "arm64"})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(img.ID(), arm64))
+}
+
+func TestPlatformVariantsHostPlatform(t *testing.T) {
+	dir := t.TempDir()
+	backend, err := image.NewFSStoreBackend(filepath.Join(dir, "images"))
+	assert.NilError(t, err)
+	imageStore, err := image.NewImageStore(backend, nil)
+	assert.NilError(t, err)
+	referenceStore, err := dockerreference.NewReferenceStore(filepath.Join(dir, "repositories.json"))
+	assert.NilError(t, err)
+	i := &ImageService{imageStore: imageStore, referenceStore: referenceStore}
+
+	host := platforms.DefaultSpec()
+	other := "s390x"
+	if host.Architecture == other {
+		other = "ppc64le"
+	}
+	hostImage, err := imageStore.Create([]byte(fmt.Sprintf(`{"os": %q, "architecture": %q, "variant": %q, "rootfs": {"type": "layers"}}`, host.OS, host.Architecture, host.Variant)))
+	assert.NilError(t, err)
+	otherImage, err := imageStore.Create([]byte(fmt.Sprintf(`{"os": %q, "architecture": %q, "rootfs": {"type": "layers"}}`, host.OS, other)))
+	assert.NilError(t, err)
+
+	ref, err := reference.ParseNormalizedNamed("foo:latest")
+	assert.NilError(t, err)
+	assert.NilError(t, i.addPlatformVariant(ref, hostImage))
+	assert.NilError(t, i.addPlatformVariant(ref, otherImage))
+
+	// Without a platform, the variant for the host platform is used, even
+	// though the tag was last set to the other variant.
+	img, err := i.GetImage("foo", nil)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(img.ID(), hostImage))
+	img, err = i.GetImage("foo", &specs.Platform{OS: host.OS, Architecture: other})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(img.ID(), otherImage))
+}
+
+func TestImageDeletePlatformVariant(t *testing.T) {
+	dir := t.TempDir()
+	backend, err := image.NewFSStoreBackend(filepath.Join(dir, "images"))
+	assert.NilError(t, err)
+	imageStore, err := image.NewImageStore(backend, nil)
+	assert.NilError(t, err)
+	referenceStore, err := dockerreference.NewReferenceStore(filepath.Join(dir, "repositories.json"))
+	assert.NilError(t, err)
+	i := &ImageService{
+		imageStore:     imageStore,
+		referenceStore: referenceStore,
+		containers:     container.NewMemoryStore(),
+		eventsService:  daemonevents.New(),
+	}
+
+	amd64, err := imageStore.Create([]byte(`{"os": "linux", "architecture": "amd64", "rootfs": {"type": "layers"}}`))
+	assert.NilError(t, err)
+	arm64, err := imageStore.Create([]byte(`{"os": "linux", "architecture": "arm64", "rootfs": {"type": "layers"}}`))
+	assert.NilError(t, err)
+	arm, err := imageStore.Create([]byte(`{"os": "linux", "architecture": "arm", "variant": "v7", "rootfs": {"type": "layers"}}`))
+	assert.NilError(t, err)
+
+	ref, err := reference.ParseNormalizedNamed("foo:latest")
+	assert.NilError(t, err)
+	for _, id := range []image.ID{amd64, arm64, arm} {
+		assert.NilError(t, i.addPlatformVariant(ref, id))
+	}
+
+	// Deleting a variant by ID keeps the other variants of the tag.
+	records, err := i.ImageDelete(arm.String(), false, false)
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(records, []types.ImageDeleteResponseItem{
+		{Untagged: "foo:latest"},
+		{Deleted: arm.String()},
+	}))
+	assert.Check(t, is.Len(referenceStore.Platforms(ref), 2))
+	id, err := referenceStore.Get(ref)
+	assert.NilError(t, err)
+	assert.Check(t, id != arm.Digest())
+	img, err := i.GetImage("foo", &specs.Platform{OS: "linux", Architecture: "arm64"})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(img.ID(), arm64))
+
+	// Deleting the tag deletes its remaining variants.
+	records, err = i.ImageDelete("foo", false, false)
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(records, 3))
+	for _, id := range []image.ID{amd64, arm64} {
+		_, err := imageStore.Get(id)
+		assert.Check(t, is.ErrorContains(err, ""), id)
+	}
+}
+
+func TestPushManifestListInvalid(t *testing.T) {
+	dir := t.TempDir()
+	backend, err := image.NewFSStoreBackend(filepath.Join(dir, "images"))
+	assert.NilError(t, err)
+	imageStore, err := image.NewImageStore(backend, nil)
+	assert.NilError(t, err)
+	referenceStore, err := dockerreference.NewReferenceStore(filepath.Join(dir, "repositories.json"))
+	assert.NilError(t, err)
+	i := &ImageService{imageStore: imageStore, referenceStore: referenceStore}
+
+	amd64, err := imageStore.Create([]byte(`{"os": "linux", "architecture": "amd64", "rootfs": {"type": "layers"}}`))
+	assert.NilError(t, err)
+	otherAmd64, err := imageStore.Create([]byte(`{"os": "linux", "architecture": "amd64", "comment": "other", "rootfs": {"type": "layers"}}`))
+	assert.NilError(t, err)
+
+	testCases := []struct {
+		doc   string
+		image string
+		list  imagetypes.ManifestList
+		err   string
+	}{
+		{
+			doc:   "digest reference",
+			image: "foo@" + amd64.String(),
+			list:  imagetypes.ManifestList{Manifests: []imagetypes.ManifestListEntry{{Image: amd64.String()}}},
+			err:   "a manifest list can only be pushed to a tag",
+		},
+		{
+			doc:   "unsupported media type",
+			image: "foo",
+			list:  imagetypes.ManifestList{MediaType: specs.MediaTypeImageManifest, Manifests: []imagetypes.ManifestListEntry{{Image: amd64.String()}}},
+			err:   "unsupported manifest list media type",
+		},
+		{
+			doc:   "no images",
+			image: "foo",
+			err:   "a manifest list requires at least one image",
+		},
+		{
+			doc:   "duplicate platform",
+			image: "foo",
+			list:  imagetypes.ManifestList{Manifests: []imagetypes.ManifestListEntry{{Image: amd64.String()}, {Image: otherAmd64.String()}}},
+			err:   "have the same platform linux/amd64",
+		},
+		{
+			doc:   "unknown image",
+			image: "foo",
+			list:  imagetypes.ManifestList{Manifests: []imagetypes.ManifestListEntry{{Image: amd64.String()}, {Image: "bar"}}},
+			err:   "No such image: bar:latest",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.doc, func(t *testing.T) {
+			err := i.PushManifestList(context.Background(), tc.image, "", tc.list, nil, nil, io.Discard)
+			assert.Check(t, is.ErrorContains(err, tc.err))
+		})
+	}
+}
@@ -4,16 +4,20 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sync"
 
 	"github.com/containerd/containerd/content"
 	"github.com/containerd/containerd/leases"
+	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/container"
 	daemonevents "github.com/docker/docker/daemon/events"
+	"github.com/docker/docker/distribution"
 	"github.com/docker/docker/distribution/metadata"
 	"github.com/docker/docker/distribution/xfer"
 	"github.com/docker/docker/image"
+	"github.com/docker/docker/image/policy"
 	"github.com/docker/docker/layer"
 	dockerreference "github.com/docker/docker/reference"
 	"github.com/docker/docker/registry"
@@ -42,6 +46,8 @@ type ImageServiceConfig struct {
 	MaxConcurrentDownloads    int
 	MaxConcurrentUploads      int
 	MaxDownloadAttempts       int
+	LazyPullLayerStore        string
+	PartialDownloadRoot       string
 	ReferenceStore            dockerreference.Store
 	RegistryService           registry.Service
 	TrustKey                  libtrust.PrivateKey
@@ -52,13 +58,18 @@ type ImageServiceConfig struct {
 
 // NewImageService returns a new ImageService from a configuration
 func NewImageService(config ImageServiceConfig) *ImageService {
+	downloadOptions := []xfer.DownloadOption{xfer.WithMaxDownloadAttempts(config.MaxDownloadAttempts)}
+	if config.LazyPullLayerStore != "" {
+		downloadOptions = append(downloadOptions, xfer.WithRemoteLayerSource(distribution.NewAdditionalLayerStore(config.LazyPullLayerStore)))
+	}
 	return &ImageService{
 		containers:                config.ContainerStore,
 		distributionMetadataStore: config.DistributionMetadataStore,
-		downloadManager:           xfer.NewLayerDownloadManager(config.LayerStore, config.MaxConcurrentDownloads, xfer.WithMaxDownloadAttempts(config.MaxDownloadAttempts)),
+		downloadManager:           xfer.NewLayerDownloadManager(config.LayerStore, config.MaxConcurrentDownloads, downloadOptions...),
 		eventsService:             config.EventsService,
 		imageStore:                &imageStoreWithLease{Store: config.ImageStore, leases: config.Leases, ns: config.ContentNamespace},
 		layerStore:                config.LayerStore,
+		partialDownloadRoot:       config.PartialDownloadRoot,
 		referenceStore:            config.ReferenceStore,
 		registryService:           config.RegistryService,
 		trustKey:                  config.TrustKey,
@@ -75,8 +86,11 @@ type ImageService struct {
 	distributionMetadataStore metadata.Store
 	downloadManager           *xfer.LayerDownloadManager
 	eventsService             *daemonevents.Events
+	imagePolicyMu             sync.RWMutex
+	imagePolicy               *policy.Policy // protected by imagePolicyMu
 	imageStore                image.Store
 	layerStore                layer.Store
+	partialDownloadRoot       string
 	pruneRunning              int32
 	referenceStore            dockerreference.Store
 	registryService           registry.Service
@@ -95,16 +109,24 @@ type DistributionServices struct {
 	LayerStore        layer.Store
 	ImageStore        image.Store
 	ReferenceStore    dockerreference.Store
+	// VerifyImagePolicy verifies local images against the image signature
+	// policy.
+	VerifyImagePolicy func(refOrID string, img *image.Image) error
+	// VerifyImageManifest verifies the manifests of images in registries
+	// against the image signature policy.
+	VerifyImageManifest func(ctx context.Context, ref reference.Named, dgst digest.Digest) error
 }
 
 // DistributionServices return services controlling daemon image storage
 func (i *ImageService) DistributionServices() DistributionServices {
 	return DistributionServices{
-		DownloadManager:   i.downloadManager,
-		V2MetadataService: metadata.NewV2MetadataService(i.distributionMetadataStore),
-		LayerStore:        i.layerStore,
-		ImageStore:        i.imageStore,
-		ReferenceStore:    i.referenceStore,
+		DownloadManager:     i.downloadManager,
+		V2MetadataService:   metadata.NewV2MetadataService(i.distributionMetadataStore),
+		LayerStore:          i.layerStore,
+		ImageStore:          i.imageStore,
+		ReferenceStore:      i.referenceStore,
+		VerifyImagePolicy:   i.VerifyImagePolicy,
+		VerifyImageManifest: i.VerifyImageManifest,
 	}
 }
 
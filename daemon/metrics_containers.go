@@ -0,0 +1,398 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/config"
+	metrics "github.com/docker/go-metrics"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// containerMetricsCollector exports the resource usage of the running
+// containers to Prometheus. The metrics are the latest samples of the stats
+// collector, to which the collector subscribes for the running containers
+// when the metrics are enabled, and for each container when it is started,
+// so that the containers have a sample by the time the metrics are collected.
+// On Linux, the stats of the cgroup v2 of the containers are also exported,
+// which are read when collected.
+type containerMetricsCollector struct {
+	daemon *Daemon
+	// labels are the entries of the configuration of the labels of the
+	// metrics, which are added after the ID of the container.
+	labels []string
+
+	mu      sync.Mutex
+	samples map[string]*containerSample
+	// closed is set when the collector is replaced, after which it doesn't
+	// subscribe to the stats of containers anymore.
+	closed bool
+
+	cpuUsage         *prometheus.Desc
+	cpuUser          *prometheus.Desc
+	cpuKernel        *prometheus.Desc
+	cpuThrottled     *prometheus.Desc
+	cpuThrottledTime *prometheus.Desc
+	memoryUsage      *prometheus.Desc
+	memoryLimit      *prometheus.Desc
+	networkRxBytes   *prometheus.Desc
+	networkRxPackets *prometheus.Desc
+	networkRxErrors  *prometheus.Desc
+	networkRxDropped *prometheus.Desc
+	networkTxBytes   *prometheus.Desc
+	networkTxPackets *prometheus.Desc
+	networkTxErrors  *prometheus.Desc
+	networkTxDropped *prometheus.Desc
+	blkioReadBytes   *prometheus.Desc
+	blkioWriteBytes  *prometheus.Desc
+	pids             *prometheus.Desc
+
+	cgroup2 cgroup2Metrics
+}
+
+// containerSample is the latest sample of the stats of a container.
+type containerSample struct {
+	container *container.Container
+	updates   chan interface{}
+	stats     types.StatsJSON
+}
+
+// containerMetrics is the collector of the per-container metrics that is
+// registered with the metrics API, which collects the metrics of the current
+// configuration. It is unchecked, as it doesn't describe the metrics, so that
+// the labels of the metrics can change when the configuration is reloaded.
+type containerMetrics struct {
+	mu  sync.RWMutex
+	ctr *containerMetricsCollector // nil if the metrics are disabled
+}
+
+func (m *containerMetrics) Describe(ch chan<- *prometheus.Desc) {}
+
+func (m *containerMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.mu.RLock()
+	ctr := m.ctr
+	m.mu.RUnlock()
+	if ctr != nil {
+		ctr.Collect(ch)
+	}
+}
+
+// registerContainerMetrics registers the collector of the per-container
+// metrics with the metrics API, and configures it with conf.
+func (daemon *Daemon) registerContainerMetrics(conf *config.ContainerMetricsConfig) error {
+	if err := daemon.setContainerMetrics(conf); err != nil {
+		return err
+	}
+	return errors.Wrap(prometheus.Register(&daemon.containerMetrics), "failed to register container metrics")
+}
+
+// setContainerMetrics replaces the collector of the per-container metrics
+// with one of the configuration conf. The metrics are disabled if conf is nil.
+func (daemon *Daemon) setContainerMetrics(conf *config.ContainerMetricsConfig) error {
+	var ctr *containerMetricsCollector
+	if conf != nil {
+		var err error
+		ctr, err = newContainerMetricsCollector(daemon, conf)
+		if err != nil {
+			return err
+		}
+		ctr.describe(metrics.NewNamespace("engine", "daemon", nil))
+	}
+
+	daemon.containerMetrics.mu.Lock()
+	old := daemon.containerMetrics.ctr
+	daemon.containerMetrics.ctr = ctr
+	daemon.containerMetrics.mu.Unlock()
+	if old != nil {
+		old.close()
+	}
+	// Containers that are started from now on are subscribed to by
+	// containerStartedMetrics.
+	if ctr != nil {
+		for _, c := range daemon.containers.List() {
+			if c.IsRunning() {
+				ctr.add(c)
+			}
+		}
+	}
+	return nil
+}
+
+// containerStartedMetrics subscribes the collector of the per-container
+// metrics, if they are enabled, to the stats of the container c, which was
+// started.
+func (daemon *Daemon) containerStartedMetrics(c *container.Container) {
+	daemon.containerMetrics.mu.RLock()
+	ctr := daemon.containerMetrics.ctr
+	daemon.containerMetrics.mu.RUnlock()
+	if ctr != nil {
+		ctr.add(c)
+	}
+}
+
+// containerStoppedMetrics unsubscribes the collector of the per-container
+// metrics, if they are enabled, from the stats of the container c, which
+// stopped. The container is subscribed to again if it is restarted.
+func (daemon *Daemon) containerStoppedMetrics(c *container.Container) {
+	daemon.containerMetrics.mu.RLock()
+	ctr := daemon.containerMetrics.ctr
+	daemon.containerMetrics.mu.RUnlock()
+	if ctr != nil {
+		ctr.remove(c)
+	}
+}
+
+func newContainerMetricsCollector(daemon *Daemon, conf *config.ContainerMetricsConfig) (*containerMetricsCollector, error) {
+	ctr := &containerMetricsCollector{
+		daemon:  daemon,
+		labels:  conf.Labels,
+		samples: make(map[string]*containerSample),
+	}
+	// Container labels with different keys may have the same name once
+	// sanitized, which Prometheus doesn't allow.
+	seen := make(map[string]string)
+	for i, name := range ctr.labelNames()[1:] {
+		if other, ok := seen[name]; ok {
+			return nil, errors.Errorf("invalid container metrics labels: %q and %q have the same name %s", other, conf.Labels[i], name)
+		}
+		seen[name] = conf.Labels[i]
+	}
+	return ctr, nil
+}
+
+// labelNames returns the names of the labels of the metrics of a container.
+func (ctr *containerMetricsCollector) labelNames() []string {
+	names := []string{"container_id"}
+	for _, l := range ctr.labels {
+		if key := strings.TrimPrefix(l, config.ContainerMetricsLabelPrefix); key != l {
+			names = append(names, "container_label_"+sanitizeMetricLabel(key))
+		} else {
+			names = append(names, l)
+		}
+	}
+	return names
+}
+
+// labelValues returns the values of the labels of the metrics of the
+// container c.
+func (ctr *containerMetricsCollector) labelValues(c *container.Container) []string {
+	c.Lock()
+	defer c.Unlock()
+	values := []string{c.ID}
+	for _, l := range ctr.labels {
+		switch {
+		case l == "name":
+			values = append(values, strings.TrimPrefix(c.Name, "/"))
+		case l == "image":
+			values = append(values, c.Config.Image)
+		default:
+			values = append(values, c.Config.Labels[strings.TrimPrefix(l, config.ContainerMetricsLabelPrefix)])
+		}
+	}
+	return values
+}
+
+// sanitizeMetricLabel replaces the characters of s that are not allowed in
+// the names of Prometheus labels with underscores.
+func sanitizeMetricLabel(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+func (ctr *containerMetricsCollector) describe(ns *metrics.Namespace) {
+	labels := ctr.labelNames()
+	withLabel := func(l string) []string {
+		return append(append([]string{}, labels...), l)
+	}
+	ctr.cpuUsage = ns.NewDesc("container_cpu_usage_seconds", "The total CPU time consumed by a container", metrics.Total, labels...)
+	ctr.cpuUser = ns.NewDesc("container_cpu_user_seconds", "The total CPU time consumed by a container in user mode", metrics.Total, labels...)
+	ctr.cpuKernel = ns.NewDesc("container_cpu_kernel_seconds", "The total CPU time consumed by a container in kernel mode", metrics.Total, labels...)
+	ctr.cpuThrottled = ns.NewDesc("container_cpu_throttled_periods", "The number of periods in which a container was throttled", metrics.Total, labels...)
+	ctr.cpuThrottledTime = ns.NewDesc("container_cpu_throttled_seconds", "The total time for which a container was throttled", metrics.Total, labels...)
+	ctr.memoryUsage = ns.NewDesc("container_memory_usage", "The memory usage of a container", metrics.Bytes, labels...)
+	ctr.memoryLimit = ns.NewDesc("container_memory_limit", "The memory limit of a container", metrics.Bytes, labels...)
+	ctr.networkRxBytes = ns.NewDesc("container_network_receive_bytes", "The number of bytes received by a network interface of a container", metrics.Total, withLabel("interface")...)
+	ctr.networkRxPackets = ns.NewDesc("container_network_receive_packets", "The number of packets received by a network interface of a container", metrics.Total, withLabel("interface")...)
+	ctr.networkRxErrors = ns.NewDesc("container_network_receive_errors", "The number of errors receiving packets on a network interface of a container", metrics.Total, withLabel("interface")...)
+	ctr.networkRxDropped = ns.NewDesc("container_network_receive_dropped", "The number of incoming packets dropped on a network interface of a container", metrics.Total, withLabel("interface")...)
+	ctr.networkTxBytes = ns.NewDesc("container_network_transmit_bytes", "The number of bytes sent by a network interface of a container", metrics.Total, withLabel("interface")...)
+	ctr.networkTxPackets = ns.NewDesc("container_network_transmit_packets", "The number of packets sent by a network interface of a container", metrics.Total, withLabel("interface")...)
+	ctr.networkTxErrors = ns.NewDesc("container_network_transmit_errors", "The number of errors sending packets on a network interface of a container", metrics.Total, withLabel("interface")...)
+	ctr.networkTxDropped = ns.NewDesc("container_network_transmit_dropped", "The number of outgoing packets dropped on a network interface of a container", metrics.Total, withLabel("interface")...)
+	ctr.blkioReadBytes = ns.NewDesc("container_blkio_read_bytes", "The number of bytes read from a block device by a container", metrics.Total, withLabel("device")...)
+	ctr.blkioWriteBytes = ns.NewDesc("container_blkio_write_bytes", "The number of bytes written to a block device by a container", metrics.Total, withLabel("device")...)
+	ctr.pids = ns.NewDesc("container_pids", "The number of processes of a container", metrics.Unit(""), labels...)
+	ctr.cgroup2.describe(ns, labels)
+}
+
+func (ctr *containerMetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		ctr.cpuUsage, ctr.cpuUser, ctr.cpuKernel, ctr.cpuThrottled, ctr.cpuThrottledTime,
+		ctr.memoryUsage, ctr.memoryLimit,
+		ctr.networkRxBytes, ctr.networkRxPackets, ctr.networkRxErrors, ctr.networkRxDropped,
+		ctr.networkTxBytes, ctr.networkTxPackets, ctr.networkTxErrors, ctr.networkTxDropped,
+		ctr.blkioReadBytes, ctr.blkioWriteBytes,
+		ctr.pids,
+	} {
+		ch <- d
+	}
+	for _, d := range ctr.cgroup2.descs() {
+		ch <- d
+	}
+}
+
+func (ctr *containerMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	// The samples are copied so that the lock is not held while the
+	// containers are locked to read their labels, as the collector is
+	// subscribed to the stats of containers while they are locked.
+	ctr.mu.Lock()
+	samples := make([]containerSample, 0, len(ctr.samples))
+	for _, s := range ctr.samples {
+		samples = append(samples, *s)
+	}
+	ctr.mu.Unlock()
+
+	for _, s := range samples {
+		if !s.container.IsRunning() {
+			continue
+		}
+		labels := ctr.labelValues(s.container)
+		ctr.cgroup2.collectContainer(ch, labels, s.container)
+		if s.stats.Read.IsZero() {
+			continue
+		}
+		ctr.collect(ch, labels, &s.stats)
+	}
+}
+
+// close unsubscribes the collector from the stats of the containers.
+func (ctr *containerMetricsCollector) close() {
+	ctr.mu.Lock()
+	ctr.closed = true
+	samples := ctr.samples
+	ctr.samples = make(map[string]*containerSample)
+	ctr.mu.Unlock()
+
+	// The subscriptions are closed without holding the lock, which the
+	// subscriptions wait for to record the samples that are being published.
+	for _, s := range samples {
+		ctr.daemon.unsubscribeToContainerStats(s.container, s.updates)
+	}
+}
+
+// add subscribes to the stats of the container c, and records its latest
+// sample until the subscription is closed, unless the collector is already
+// subscribed to them.
+func (ctr *containerMetricsCollector) add(c *container.Container) {
+	ctr.mu.Lock()
+	defer ctr.mu.Unlock()
+	if _, ok := ctr.samples[c.ID]; ok || ctr.closed {
+		return
+	}
+	s := &containerSample{
+		container: c,
+		updates:   ctr.daemon.subscribeToContainerStats(c),
+	}
+	ctr.samples[c.ID] = s
+	go func() {
+		for v := range s.updates {
+			stats, ok := v.(types.StatsJSON)
+			if !ok {
+				continue
+			}
+			ctr.mu.Lock()
+			s.stats = stats
+			ctr.mu.Unlock()
+		}
+	}()
+}
+
+// remove unsubscribes from the stats of the container c.
+func (ctr *containerMetricsCollector) remove(c *container.Container) {
+	ctr.mu.Lock()
+	s, ok := ctr.samples[c.ID]
+	delete(ctr.samples, c.ID)
+	ctr.mu.Unlock()
+
+	// The subscription is closed without holding the lock, as in close.
+	if ok {
+		ctr.daemon.unsubscribeToContainerStats(s.container, s.updates)
+	}
+}
+
+func (ctr *containerMetricsCollector) collect(ch chan<- prometheus.Metric, labels []string, stats *types.StatsJSON) {
+	withLabel := func(l string) []string {
+		return append(append([]string{}, labels...), l)
+	}
+	counter := func(desc *prometheus.Desc, v float64, labels []string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v, labels...)
+	}
+	gauge := func(desc *prometheus.Desc, v float64, labels []string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, labels...)
+	}
+
+	// The CPU times are in nanoseconds on Linux, and in 100's of nanoseconds
+	// on Windows.
+	cpuTime := func(v uint64) float64 {
+		if isWindows {
+			v *= 100
+		}
+		return (time.Duration(v) * time.Nanosecond).Seconds()
+	}
+	cpu := stats.CPUStats
+	counter(ctr.cpuUsage, cpuTime(cpu.CPUUsage.TotalUsage), labels)
+	counter(ctr.cpuUser, cpuTime(cpu.CPUUsage.UsageInUsermode), labels)
+	counter(ctr.cpuKernel, cpuTime(cpu.CPUUsage.UsageInKernelmode), labels)
+	if !isWindows {
+		counter(ctr.cpuThrottled, float64(cpu.ThrottlingData.ThrottledPeriods), labels)
+		counter(ctr.cpuThrottledTime, cpuTime(cpu.ThrottlingData.ThrottledTime), labels)
+	}
+
+	if isWindows {
+		gauge(ctr.memoryUsage, float64(stats.MemoryStats.PrivateWorkingSet), labels)
+	} else {
+		gauge(ctr.memoryUsage, float64(stats.MemoryStats.Usage), labels)
+		if stats.MemoryStats.Limit > 0 {
+			gauge(ctr.memoryLimit, float64(stats.MemoryStats.Limit), labels)
+		}
+	}
+
+	for name, n := range stats.Networks {
+		l := withLabel(name)
+		counter(ctr.networkRxBytes, float64(n.RxBytes), l)
+		counter(ctr.networkRxPackets, float64(n.RxPackets), l)
+		counter(ctr.networkRxErrors, float64(n.RxErrors), l)
+		counter(ctr.networkRxDropped, float64(n.RxDropped), l)
+		counter(ctr.networkTxBytes, float64(n.TxBytes), l)
+		counter(ctr.networkTxPackets, float64(n.TxPackets), l)
+		counter(ctr.networkTxErrors, float64(n.TxErrors), l)
+		counter(ctr.networkTxDropped, float64(n.TxDropped), l)
+	}
+
+	// The operations are capitalized with cgroup v1, and in lower case with
+	// cgroup v2.
+	for _, e := range stats.BlkioStats.IoServiceBytesRecursive {
+		l := withLabel(strconv.FormatUint(e.Major, 10) + ":" + strconv.FormatUint(e.Minor, 10))
+		switch strings.ToLower(e.Op) {
+		case "read":
+			counter(ctr.blkioReadBytes, float64(e.Value), l)
+		case "write":
+			counter(ctr.blkioWriteBytes, float64(e.Value), l)
+		}
+	}
+
+	if isWindows {
+		gauge(ctr.pids, float64(stats.NumProcs), labels)
+	} else {
+		gauge(ctr.pids, float64(stats.PidsStats.Current), labels)
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+// +build !linux
+
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"github.com/docker/docker/container"
+	metrics "github.com/docker/go-metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// cgroup2Metrics exports the stats of the cgroup v2 of the running containers,
+// which only exists on Linux.
+type cgroup2Metrics struct{}
+
+func (m *cgroup2Metrics) describe(ns *metrics.Namespace, labels []string) {}
+
+func (m *cgroup2Metrics) descs() []*prometheus.Desc {
+	return nil
+}
+
+func (m *cgroup2Metrics) collectContainer(ch chan<- prometheus.Metric, labels []string, c *container.Container) {
+}
@@ -0,0 +1,147 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/config"
+	"github.com/docker/docker/daemon/stats"
+	metrics "github.com/docker/go-metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestContainerMetricsLabels(t *testing.T) {
+	ctr, err := newContainerMetricsCollector(nil, &config.ContainerMetricsConfig{
+		Labels: []string{"name", "image", "label:com.example.team"},
+	})
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(ctr.labelNames(), []string{"container_id", "name", "image", "container_label_com_example_team"}))
+
+	c := &container.Container{
+		State: container.NewState(),
+		ID:    "abc",
+		Name:  "/web",
+		Config: &containertypes.Config{
+			Image:  "nginx:latest",
+			Labels: map[string]string{"com.example.team": "infra"},
+		},
+	}
+	assert.Check(t, is.DeepEqual(ctr.labelValues(c), []string{"abc", "web", "nginx:latest", "infra"}))
+
+	_, err = newContainerMetricsCollector(nil, &config.ContainerMetricsConfig{
+		Labels: []string{"label:com.example.team", "label:com.example-team"},
+	})
+	assert.Check(t, is.ErrorContains(err, `"label:com.example.team" and "label:com.example-team" have the same name container_label_com_example_team`))
+}
+
+func TestContainerMetricsCollect(t *testing.T) {
+	ctr, err := newContainerMetricsCollector(nil, &config.ContainerMetricsConfig{Labels: []string{"name"}})
+	assert.NilError(t, err)
+	ctr.describe(metrics.NewNamespace("engine", "daemon", nil))
+
+	stats := &types.StatsJSON{
+		Stats: types.Stats{
+			Read: time.Now(),
+			CPUStats: types.CPUStats{
+				CPUUsage: types.CPUUsage{TotalUsage: 3e9, UsageInUsermode: 2e9, UsageInKernelmode: 1e9},
+			},
+			MemoryStats: types.MemoryStats{Usage: 1024, Limit: 4096},
+			BlkioStats: types.BlkioStats{
+				IoServiceBytesRecursive: []types.BlkioStatEntry{
+					{Major: 8, Minor: 0, Op: "Read", Value: 100},
+					{Major: 8, Minor: 0, Op: "Write", Value: 200},
+					{Major: 8, Minor: 0, Op: "Total", Value: 300},
+				},
+			},
+			PidsStats: types.PidsStats{Current: 3},
+		},
+		Networks: map[string]types.NetworkStats{
+			"eth0": {RxBytes: 10, TxBytes: 20},
+		},
+	}
+	ch := make(chan prometheus.Metric, 100)
+	ctr.collect(ch, []string{"abc", "web"}, stats)
+	close(ch)
+
+	values := make(map[*prometheus.Desc][]float64)
+	for m := range ch {
+		var out dto.Metric
+		assert.NilError(t, m.Write(&out))
+		assert.Check(t, is.Equal(out.Label[0].GetValue(), "abc"))
+		v := out.GetCounter().GetValue()
+		if out.Gauge != nil {
+			v = out.GetGauge().GetValue()
+		}
+		values[m.Desc()] = append(values[m.Desc()], v)
+	}
+	if isWindows {
+		return
+	}
+	assert.Check(t, is.DeepEqual(values[ctr.cpuUsage], []float64{3}))
+	assert.Check(t, is.DeepEqual(values[ctr.cpuUser], []float64{2}))
+	assert.Check(t, is.DeepEqual(values[ctr.memoryUsage], []float64{1024}))
+	assert.Check(t, is.DeepEqual(values[ctr.memoryLimit], []float64{4096}))
+	assert.Check(t, is.DeepEqual(values[ctr.networkRxBytes], []float64{10}))
+	assert.Check(t, is.DeepEqual(values[ctr.networkTxBytes], []float64{20}))
+	assert.Check(t, is.DeepEqual(values[ctr.blkioReadBytes], []float64{100}))
+	assert.Check(t, is.DeepEqual(values[ctr.blkioWriteBytes], []float64{200}))
+	assert.Check(t, is.DeepEqual(values[ctr.pids], []float64{3}))
+}
+
+func TestContainerMetricsSubscribe(t *testing.T) {
+	d := &Daemon{
+		containers:     container.NewMemoryStore(),
+		statsCollector: stats.NewCollector(nil, time.Second),
+	}
+	newContainer := func(id string, running bool) *container.Container {
+		c := container.NewBaseContainer(id, t.TempDir())
+		c.Config = &containertypes.Config{}
+		if running {
+			c.SetRunning(1, true)
+		}
+		d.containers.Add(id, c)
+		return c
+	}
+	running := newContainer("running", true)
+	newContainer("stopped", false)
+	subscribed := func() []string {
+		t.Helper()
+		ctr := d.containerMetrics.ctr
+		ctr.mu.Lock()
+		defer ctr.mu.Unlock()
+		var ids []string
+		for id := range ctr.samples {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	// The running containers are subscribed to when the metrics are
+	// enabled, and the other containers when they are started.
+	assert.NilError(t, d.setContainerMetrics(&config.ContainerMetricsConfig{}))
+	assert.Check(t, is.DeepEqual(subscribed(), []string{"running"}))
+	started := newContainer("started", true)
+	d.containerStartedMetrics(started)
+	assert.Check(t, is.DeepEqual(subscribed(), []string{"running", "started"}))
+
+	running.SetStopped(&container.ExitStatus{})
+	d.containerStoppedMetrics(running)
+	assert.Check(t, is.DeepEqual(subscribed(), []string{"started"}))
+
+	// The subscriptions of the previous configuration are closed when it is
+	// replaced.
+	old := d.containerMetrics.ctr
+	assert.NilError(t, d.setContainerMetrics(&config.ContainerMetricsConfig{Labels: []string{"name"}}))
+	assert.Check(t, is.DeepEqual(subscribed(), []string{"started"}))
+	assert.Check(t, is.Len(old.samples, 0))
+	assert.NilError(t, d.setContainerMetrics(nil))
+	assert.Check(t, d.containerMetrics.ctr == nil)
+}
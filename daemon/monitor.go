@@ -72,6 +72,7 @@ func (daemon *Daemon) handleContainerExit(c *container.Container, e *libcontaine
 	defer c.Unlock() // needs to be called before autoRemove
 
 	daemon.setStateCounter(c)
+	daemon.containerStoppedMetrics(c)
 	cpErr := c.CheckpointTo(daemon.containersReplica)
 
 	daemon.LogContainerEventWithAttributes(c, "die", attributes)
@@ -139,6 +140,7 @@ func (daemon *Daemon) ProcessEvent(id string, e libcontainerdtypes.EventType, ei
 			defer execConfig.Unlock()
 			execConfig.ExitCode = &ec
 			execConfig.Running = false
+			execConfig.FinishedAt = time.Now().UTC()
 
 			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 			execConfig.StreamConfig.Wait(ctx)
@@ -151,6 +153,7 @@ func (daemon *Daemon) ProcessEvent(id string, e libcontainerdtypes.EventType, ei
 			// remove the exec command from the container's store only and not the
 			// daemon's store so that the exec command can be inspected.
 			c.ExecCommands.Delete(execConfig.ID, execConfig.Pid)
+			c.ExecCommands.AddHistory(execConfig.Summary())
 
 			exitCode = ec
 		}
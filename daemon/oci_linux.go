@@ -859,7 +859,10 @@ func WithCgroups(daemon *Daemon, c *container.Container) coci.SpecOpts {
 func WithDevices(daemon *Daemon, c *container.Container) coci.SpecOpts {
 	return func(ctx context.Context, _ coci.Client, _ *containers.Container, s *coci.Spec) error {
 		// Build lists of devices allowed and created within the container.
-		var devs []specs.LinuxDevice
+		var (
+			devs       []specs.LinuxDevice
+			cdiDevices []string
+		)
 		devPermissions := s.Linux.Resources.Devices
 
 		if c.HostConfig.Privileged {
@@ -871,6 +874,10 @@ func WithDevices(daemon *Daemon, c *container.Container) coci.SpecOpts {
 
 			// adding device mappings in privileged containers
 			for _, deviceMapping := range c.HostConfig.Devices {
+				if name, ok := cdiDeviceName(deviceMapping); ok {
+					cdiDevices = append(cdiDevices, name)
+					continue
+				}
 				// issue a warning that custom cgroup permissions are ignored in privileged mode
 				if deviceMapping.CgroupPermissions != "rwm" {
 					logrus.WithField("container", c.ID).Warnf("custom %s permissions for device %s are ignored in privileged mode", deviceMapping.CgroupPermissions, deviceMapping.PathOnHost)
@@ -895,6 +902,10 @@ func WithDevices(daemon *Daemon, c *container.Container) coci.SpecOpts {
 			}
 		} else {
 			for _, deviceMapping := range c.HostConfig.Devices {
+				if name, ok := cdiDeviceName(deviceMapping); ok {
+					cdiDevices = append(cdiDevices, name)
+					continue
+				}
 				d, dPermissions, err := oci.DevicesFromPath(deviceMapping.PathOnHost, deviceMapping.PathInContainer, deviceMapping.CgroupPermissions)
 				if err != nil {
 					return err
@@ -913,6 +924,14 @@ func WithDevices(daemon *Daemon, c *container.Container) coci.SpecOpts {
 		s.Linux.Devices = append(s.Linux.Devices, devs...)
 		s.Linux.Resources.Devices = devPermissions
 
+		// Devices with fully-qualified CDI names, as "vendor/class=name",
+		// are CDI devices.
+		if len(cdiDevices) > 0 {
+			if err := daemon.injectCDIDevices(s, cdiDevices...); err != nil {
+				return err
+			}
+		}
+
 		for _, req := range c.HostConfig.DeviceRequests {
 			if err := daemon.handleDevice(req, s); err != nil {
 				return err
@@ -3,6 +3,7 @@ package daemon // import "github.com/docker/docker/daemon"
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
 
 	"github.com/docker/docker/daemon/config"
 	"github.com/sirupsen/logrus"
@@ -22,6 +23,8 @@ import (
 // - Insecure registries
 // - Registry mirrors
 // - Daemon live restore
+// - Image signature policy
+// - Per-container metrics
 func (daemon *Daemon) Reload(conf *config.Config) (err error) {
 	daemon.configStore.Lock()
 	attributes := map[string]string{}
@@ -77,9 +80,57 @@ func (daemon *Daemon) Reload(conf *config.Config) (err error) {
 	if err := daemon.reloadLiveRestore(conf, attributes); err != nil {
 		return err
 	}
+	if err := daemon.reloadImagePolicy(conf, attributes); err != nil {
+		return err
+	}
+	if err := daemon.reloadContainerMetrics(conf, attributes); err != nil {
+		return err
+	}
 	return daemon.reloadNetworkDiagnosticPort(conf, attributes)
 }
 
+// reloadImagePolicy loads the image signature policy and its trust store
+// again, so that changes to the policy or to its keys take effect, and
+// updates the passed attributes
+func (daemon *Daemon) reloadImagePolicy(conf *config.Config, attributes map[string]string) error {
+	if daemon.imageService != nil {
+		if err := daemon.imageService.SetImagePolicy(conf.ImagePolicy); err != nil {
+			return err
+		}
+	}
+	daemon.configStore.ImagePolicy = conf.ImagePolicy
+
+	// prepare reload event attributes with updatable configurations
+	attributes["image-policy"] = daemon.configStore.ImagePolicy
+	return nil
+}
+
+// reloadContainerMetrics replaces the collector of the per-container metrics
+// with one of the new configuration, which disables the metrics if it is not
+// set, and updates the passed attributes.
+func (daemon *Daemon) reloadContainerMetrics(conf *config.Config, attributes map[string]string) error {
+	// The collector is only replaced if the configuration changed, so that
+	// the samples of the containers are kept.
+	if !reflect.DeepEqual(daemon.configStore.ContainerMetrics, conf.ContainerMetrics) {
+		if err := daemon.setContainerMetrics(conf.ContainerMetrics); err != nil {
+			return err
+		}
+		daemon.configStore.ContainerMetrics = conf.ContainerMetrics
+	}
+
+	// prepare reload event attributes with updatable configurations
+	if daemon.configStore.ContainerMetrics != nil {
+		containerMetrics, err := json.Marshal(daemon.configStore.ContainerMetrics)
+		if err != nil {
+			return err
+		}
+		attributes["container-metrics"] = string(containerMetrics)
+	} else {
+		attributes["container-metrics"] = ""
+	}
+	return nil
+}
+
 // reloadDebug updates configuration with Debug option
 // and updates the passed attributes
 func (daemon *Daemon) reloadDebug(conf *config.Config, attributes map[string]string) {
@@ -2,9 +2,11 @@ package daemon // import "github.com/docker/docker/daemon"
 
 import (
 	"os"
+	"path/filepath"
 	"sort"
 	"testing"
 
+	"github.com/docker/docker/container"
 	"github.com/docker/docker/daemon/config"
 	"github.com/docker/docker/daemon/images"
 	"github.com/docker/docker/libnetwork"
@@ -406,3 +408,78 @@ func TestDaemonReloadNetworkDiagnosticPort(t *testing.T) {
 	}
 
 }
+
+func TestDaemonReloadImagePolicy(t *testing.T) {
+	daemon := &Daemon{
+		configStore:  &config.Config{},
+		imageService: images.NewImageService(images.ImageServiceConfig{}),
+	}
+	muteLogs()
+
+	path := filepath.Join(t.TempDir(), "policy.json")
+	assert.NilError(t, os.WriteFile(path, []byte(`{"default":{"type":"reject"}}`), 0o644))
+	newConfig := &config.Config{
+		CommonConfig: config.CommonConfig{
+			ImagePolicy: path,
+			ValuesSet:   map[string]interface{}{"image-policy": path},
+		},
+	}
+	assert.NilError(t, daemon.Reload(newConfig))
+	assert.Check(t, daemon.imageService.ImagePolicyEnabled())
+	assert.Check(t, is.Equal(daemon.configStore.ImagePolicy, path))
+
+	// An invalid policy is rejected, and the current policy is kept.
+	assert.NilError(t, os.WriteFile(path, []byte(`{}`), 0o644))
+	assert.Check(t, is.ErrorContains(daemon.Reload(newConfig), "failed to load image policy"))
+	assert.Check(t, daemon.imageService.ImagePolicyEnabled())
+
+	assert.NilError(t, daemon.Reload(&config.Config{}))
+	assert.Check(t, !daemon.imageService.ImagePolicyEnabled())
+}
+
+func TestDaemonReloadContainerMetrics(t *testing.T) {
+	daemon := &Daemon{
+		configStore:  &config.Config{},
+		imageService: images.NewImageService(images.ImageServiceConfig{}),
+		containers:   container.NewMemoryStore(),
+	}
+	muteLogs()
+
+	newConfig := &config.Config{
+		CommonConfig: config.CommonConfig{
+			ContainerMetrics: &config.ContainerMetricsConfig{Labels: []string{"name"}},
+		},
+	}
+	assert.NilError(t, daemon.Reload(newConfig))
+	assert.Assert(t, daemon.containerMetrics.ctr != nil)
+	assert.Check(t, is.DeepEqual(daemon.containerMetrics.ctr.labelNames(), []string{"container_id", "name"}))
+	ctr := daemon.containerMetrics.ctr
+
+	// The collector is kept if the configuration didn't change.
+	assert.NilError(t, daemon.Reload(newConfig))
+	assert.Check(t, daemon.containerMetrics.ctr == ctr)
+
+	// The labels of the metrics are replaced.
+	newConfig = &config.Config{
+		CommonConfig: config.CommonConfig{
+			ContainerMetrics: &config.ContainerMetricsConfig{Labels: []string{"name", "image"}},
+		},
+	}
+	assert.NilError(t, daemon.Reload(newConfig))
+	assert.Check(t, is.DeepEqual(daemon.containerMetrics.ctr.labelNames(), []string{"container_id", "name", "image"}))
+	assert.Check(t, ctr.closed)
+
+	// Invalid labels are rejected, and the current collector is kept.
+	ctr = daemon.containerMetrics.ctr
+	err := daemon.Reload(&config.Config{
+		CommonConfig: config.CommonConfig{
+			ContainerMetrics: &config.ContainerMetricsConfig{Labels: []string{"label:a.b", "label:a-b"}},
+		},
+	})
+	assert.Check(t, is.ErrorContains(err, "have the same name"))
+	assert.Check(t, daemon.containerMetrics.ctr == ctr)
+
+	assert.NilError(t, daemon.Reload(&config.Config{}))
+	assert.Check(t, daemon.containerMetrics.ctr == nil)
+	assert.Check(t, is.Nil(daemon.configStore.ContainerMetrics))
+}
@@ -0,0 +1,55 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// ResourceLabels returns the labels of the API resource with the given name of
+// the given kind, which is the first element of the path of the routes of the
+// resource, such as "containers". It is used for the label selectors of the
+// role-based access control of the API. The labels of an exec are the labels
+// of its container.
+func (daemon *Daemon) ResourceLabels(ctx context.Context, kind, name string) (map[string]string, error) {
+	switch kind {
+	case "containers":
+		ctr, err := daemon.GetContainer(name)
+		if err != nil {
+			return nil, err
+		}
+		return ctr.Config.Labels, nil
+	case "exec":
+		ec := daemon.execCommands.Get(name)
+		if ec == nil {
+			return nil, errExecNotFound(name)
+		}
+		ctr, err := daemon.GetContainer(ec.ContainerID)
+		if err != nil {
+			return nil, err
+		}
+		return ctr.Config.Labels, nil
+	case "images":
+		img, err := daemon.imageService.GetImage(name, nil)
+		if err != nil {
+			return nil, err
+		}
+		if img.Config == nil {
+			return nil, nil
+		}
+		return img.Config.Labels, nil
+	case "volumes":
+		v, err := daemon.volumes.Get(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		return v.Labels, nil
+	case "networks":
+		n, err := daemon.FindNetwork(name)
+		if err != nil {
+			return nil, err
+		}
+		return n.Info().Labels(), nil
+	}
+	return nil, errors.Errorf("unsupported resource kind %q", kind)
+}
@@ -217,6 +217,7 @@ func (daemon *Daemon) containerStart(container *container.Container, checkpoint
 			Errorf("failed to store container")
 	}
 
+	daemon.containerStartedMetrics(container)
 	daemon.LogContainerEvent(container, "start")
 	containerActions.WithValues("start").UpdateSince(start)
 
@@ -9,6 +9,8 @@ import (
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/backend"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/versions"
 	"github.com/docker/docker/api/types/versions/v1p20"
 	"github.com/docker/docker/container"
@@ -136,6 +138,160 @@ func (daemon *Daemon) ContainerStats(ctx context.Context, prefixOrName string, c
 	}
 }
 
+// ContainerStatsAll writes the stats of all the running containers that
+// match the filters in the config object to the stream given in the config
+// object, as batches of the samples of each iteration of the stats collector.
+func (daemon *Daemon) ContainerStatsAll(ctx context.Context, config *backend.ContainerStatsAllConfig) error {
+	type subscription struct {
+		container *container.Container
+		updates   chan interface{}
+	}
+	subscriptions := make(map[string]subscription)
+	defer func() {
+		for _, sub := range subscriptions {
+			daemon.unsubscribeToContainerStats(sub.container, sub.updates)
+		}
+	}()
+
+	// refresh subscribes to the stats of the containers that match the
+	// filters, so that the collector samples them, and unsubscribes from the
+	// containers that no longer match. The samples are received from the
+	// batches of the collector, so the updates of the subscriptions are
+	// discarded.
+	refresh := func() error {
+		list, err := daemon.Containers(&types.ContainerListOptions{Filters: config.Filters})
+		if err != nil {
+			return err
+		}
+		matched := make(map[string]bool, len(list))
+		for _, c := range list {
+			matched[c.ID] = true
+			if _, ok := subscriptions[c.ID]; ok {
+				continue
+			}
+			ctr, err := daemon.GetContainer(c.ID)
+			if err != nil {
+				// The container was removed after it was listed.
+				continue
+			}
+			updates := daemon.subscribeToContainerStats(ctr)
+			go func() {
+				for range updates {
+				}
+			}()
+			subscriptions[c.ID] = subscription{container: ctr, updates: updates}
+		}
+		for id, sub := range subscriptions {
+			if !matched[id] {
+				daemon.unsubscribeToContainerStats(sub.container, sub.updates)
+				delete(subscriptions, id)
+			}
+		}
+		return nil
+	}
+
+	// The containers that match the filters may change when containers start,
+	// stop, or are renamed, and the subscriptions are refreshed then. The
+	// events are subscribed to before the containers are listed, so that no
+	// container is missed.
+	_, containerEvents := daemon.SubscribeToEvents(time.Time{}, time.Time{}, filters.NewArgs(
+		filters.Arg("type", string(events.ContainerEventType)),
+		filters.Arg("event", "start"),
+		filters.Arg("event", "die"),
+		filters.Arg("event", "rename"),
+		filters.Arg("event", "destroy"),
+	))
+	defer daemon.UnsubscribeFromEvents(containerEvents)
+
+	// Invalid filters are reported before the stream starts.
+	if err := refresh(); err != nil {
+		return err
+	}
+
+	outStream := config.OutStream
+	if config.Stream {
+		wf := ioutils.NewWriteFlusher(outStream)
+		defer wf.Close()
+		wf.Flush()
+		outStream = wf
+	}
+	enc := json.NewEncoder(outStream)
+
+	if len(subscriptions) == 0 && !config.Stream {
+		return enc.Encode(&types.StatsBatch{Read: time.Now(), Stats: []types.StatsJSON{}})
+	}
+
+	batches := daemon.statsCollector.CollectBatches()
+	defer daemon.statsCollector.UnsubscribeBatches(batches)
+
+	type previous struct {
+		cpuStats types.CPUStats
+		read     time.Time
+	}
+	pre := make(map[string]previous)
+	noStreamFirstFrame := true
+	for {
+		select {
+		case v, ok := <-batches:
+			if !ok {
+				return nil
+			}
+			b := v.(types.StatsBatch)
+			batch := types.StatsBatch{Read: b.Read, Stats: make([]types.StatsJSON, 0, len(subscriptions))}
+			for _, ss := range b.Stats {
+				if _, ok := subscriptions[ss.ID]; !ok {
+					continue
+				}
+				p := pre[ss.ID]
+				ss.PreCPUStats = p.cpuStats
+				ss.PreRead = p.read
+				pre[ss.ID] = previous{cpuStats: ss.CPUStats, read: ss.Read}
+				batch.Stats = append(batch.Stats, ss)
+			}
+			for id := range pre {
+				if _, ok := subscriptions[id]; !ok {
+					delete(pre, id)
+				}
+			}
+
+			if !config.Stream && noStreamFirstFrame {
+				// prime the cpu stats so they aren't 0 in the final output
+				noStreamFirstFrame = false
+				continue
+			}
+
+			if err := enc.Encode(&batch); err != nil {
+				return err
+			}
+
+			if !config.Stream {
+				return nil
+			}
+		case _, ok := <-containerEvents:
+			if !ok {
+				return nil
+			}
+			// Containers often start or stop together, so the pending
+			// events are handled with a single refresh.
+			for pending := true; pending; {
+				select {
+				case _, ok := <-containerEvents:
+					if !ok {
+						return nil
+					}
+				default:
+					pending = false
+				}
+			}
+			if err := refresh(); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
 func (daemon *Daemon) subscribeToContainerStats(c *container.Container) chan interface{} {
 	return daemon.statsCollector.Collect(c)
 }
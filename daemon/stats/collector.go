@@ -19,7 +19,9 @@ type Collector struct {
 	supervisor supervisor
 	interval   time.Duration
 	publishers map[*container.Container]*pubsub.Publisher
-	bufReader  *bufio.Reader
+	// batches publishes the samples of each iteration of the collector.
+	batches   *pubsub.Publisher
+	bufReader *bufio.Reader
 }
 
 // NewCollector creates a stats collector that will poll the supervisor with the specified interval
@@ -28,6 +30,7 @@ func NewCollector(supervisor supervisor, interval time.Duration) *Collector {
 		interval:   interval,
 		supervisor: supervisor,
 		publishers: make(map[*container.Container]*pubsub.Publisher),
+		batches:    pubsub.NewPublisher(0, 1),
 		bufReader:  bufio.NewReaderSize(nil, 128),
 	}
 	s.cond = sync.NewCond(&s.m)
@@ -80,6 +83,22 @@ func (s *Collector) Unsubscribe(c *container.Container, ch chan interface{}) {
 	s.m.Unlock()
 }
 
+// CollectBatches returns a channel for the subscriber to receive the samples
+// of each iteration of the collector on, as a types.StatsBatch of the stats
+// of the containers of which stats were collected. Only the containers that
+// are registered with Collect are sampled. The batches are sent without
+// blocking, so that a slow subscriber doesn't delay the sampling, and
+// subscribers that are not ready to receive a batch miss it.
+func (s *Collector) CollectBatches() chan interface{} {
+	return s.batches.Subscribe()
+}
+
+// UnsubscribeBatches removes a subscriber of the samples of the iterations of
+// the collector.
+func (s *Collector) UnsubscribeBatches(ch chan interface{}) {
+	s.batches.Evict(ch)
+}
+
 // Run starts the collectors and will indefinitely collect stats from the supervisor
 func (s *Collector) Run() {
 	type publishersPair struct {
@@ -113,6 +132,11 @@ func (s *Collector) Run() {
 			continue
 		}
 
+		var batch *types.StatsBatch
+		if s.batches.Len() > 0 {
+			batch = &types.StatsBatch{Stats: make([]types.StatsJSON, 0, len(pairs))}
+		}
+
 		for _, pair := range pairs {
 			stats, err := s.supervisor.GetContainerStats(pair.container)
 
@@ -132,6 +156,13 @@ func (s *Collector) Run() {
 
 				pair.publisher.Publish(*stats)
 
+				if batch != nil {
+					sample := *stats
+					sample.Name = pair.container.Name
+					sample.ID = pair.container.ID
+					batch.Stats = append(batch.Stats, sample)
+				}
+
 			case errdefs.ErrConflict, errdefs.ErrNotFound:
 				// publish empty stats containing only name and ID if not running or not found
 				pair.publisher.Publish(types.StatsJSON{
@@ -148,6 +179,11 @@ func (s *Collector) Run() {
 			}
 		}
 
+		if batch != nil {
+			batch.Read = time.Now()
+			s.batches.Publish(*batch)
+		}
+
 		time.Sleep(s.interval)
 	}
 }
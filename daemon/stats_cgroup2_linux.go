@@ -0,0 +1,329 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/cgroups"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/container"
+	metrics "github.com/docker/go-metrics"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// cgroup2Root is the mount point of the cgroup v2 hierarchy; for mocking in
+// unit tests.
+var cgroup2Root = "/sys/fs/cgroup"
+
+// pressureResources are the resources that have a pressure file in cgroup v2.
+var pressureResources = []string{"cpu", "memory", "io"}
+
+// cgroup2Stats are the stats of a cgroup v2 that containerd doesn't collect.
+type cgroup2Stats struct {
+	// Pressure is the pressure stall information of each resource, which is
+	// missing if the kernel doesn't support it.
+	Pressure     map[string]*types.PressureStats
+	MemoryStats  map[string]uint64
+	MemoryEvents map[string]uint64
+	IoStats      []types.BlkioDeviceStats
+}
+
+// readCgroup2Stats adds the stats that containerd doesn't collect to s, from
+// the cgroup v2 of the process with the given PID. The stats are optional, so
+// errors are only logged.
+func readCgroup2Stats(s *types.StatsJSON, pid int) {
+	dir, err := cgroup2Dir(pid)
+	if err != nil {
+		logrus.WithError(err).WithField("pid", pid).Debug("failed to get cgroup of container")
+		return
+	}
+	cs, err := readCgroup2Dir(dir)
+	if err != nil {
+		logrus.WithError(err).WithField("cgroup", dir).Debug("failed to read stats of cgroup")
+		return
+	}
+	s.CPUStats.Pressure = cs.Pressure["cpu"]
+	s.MemoryStats.Pressure = cs.Pressure["memory"]
+	s.BlkioStats.Pressure = cs.Pressure["io"]
+	if cs.MemoryStats != nil {
+		s.MemoryStats.Stats = cs.MemoryStats
+	}
+	if cs.IoStats != nil {
+		s.BlkioStats.IoStats = cs.IoStats
+	}
+}
+
+// cgroup2Dir returns the directory of the cgroup v2 of the process with the
+// given PID.
+func cgroup2Dir(pid int) (string, error) {
+	if cgroups.Mode() != cgroups.Unified {
+		return "", errors.New("cgroup v2 is not enabled")
+	}
+	f, err := os.Open(filepath.Join(procRoot, strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		// The line of cgroup v2 has an empty list of controllers, and an ID
+		// of 0.
+		if p := strings.TrimPrefix(s.Text(), "0::"); p != s.Text() {
+			return filepath.Join(cgroup2Root, filepath.Clean("/"+p)), nil
+		}
+	}
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.Errorf("no cgroup v2 for process %d", pid)
+}
+
+// readCgroup2Dir reads the stats of the cgroup v2 in the directory dir. The
+// stats of missing files are skipped, as the controllers of the cgroup may be
+// disabled.
+func readCgroup2Dir(dir string) (cgroup2Stats, error) {
+	cs := cgroup2Stats{Pressure: make(map[string]*types.PressureStats)}
+	for _, r := range pressureResources {
+		p, err := readPressure(filepath.Join(dir, r+".pressure"))
+		if err != nil {
+			// Reading the pressure files fails with EOPNOTSUPP if PSI is
+			// disabled by the "psi=0" kernel parameter.
+			if os.IsNotExist(err) || errors.Is(err, unix.EOPNOTSUPP) {
+				continue
+			}
+			return cs, err
+		}
+		cs.Pressure[r] = p
+	}
+
+	var err error
+	cs.MemoryStats, err = readFlatKeyed(filepath.Join(dir, "memory.stat"))
+	if err != nil && !os.IsNotExist(err) {
+		return cs, err
+	}
+	cs.MemoryEvents, err = readFlatKeyed(filepath.Join(dir, "memory.events"))
+	if err != nil && !os.IsNotExist(err) {
+		return cs, err
+	}
+	cs.IoStats, err = readIoStat(filepath.Join(dir, "io.stat"))
+	if err != nil && !os.IsNotExist(err) {
+		return cs, err
+	}
+	return cs, nil
+}
+
+// readPressure parses a pressure file, which has the format:
+//
+//	some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//
+// See https://www.kernel.org/doc/html/latest/accounting/psi.html
+func readPressure(path string) (*types.PressureStats, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var p types.PressureStats
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		var data *types.PressureData
+		switch fields[0] {
+		case "some":
+			data = &p.Some
+		case "full":
+			data = &p.Full
+		default:
+			continue
+		}
+		for _, f := range fields[1:] {
+			kv := strings.SplitN(f, "=", 2)
+			if len(kv) != 2 {
+				return nil, errors.Errorf("invalid line in %s: %q", path, line)
+			}
+			k, v := kv[0], kv[1]
+			switch k {
+			case "avg10", "avg60", "avg300":
+				avg, err := strconv.ParseFloat(v, 64)
+				if err != nil {
+					return nil, errors.Wrapf(err, "invalid line in %s", path)
+				}
+				switch k {
+				case "avg10":
+					data.Avg10 = avg
+				case "avg60":
+					data.Avg60 = avg
+				default:
+					data.Avg300 = avg
+				}
+			case "total":
+				if data.Total, err = strconv.ParseUint(v, 10, 64); err != nil {
+					return nil, errors.Wrapf(err, "invalid line in %s", path)
+				}
+			}
+		}
+	}
+	return &p, nil
+}
+
+// readFlatKeyed parses a flat keyed file of cgroup v2, such as memory.stat,
+// which has a key and a value on each line.
+func readFlatKeyed(path string) (map[string]uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]uint64)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid line in %s", path)
+		}
+		values[fields[0]] = v
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// readIoStat parses the io.stat file, which has a line for each device with
+// its "major:minor" number and the stats of the device as key=value pairs:
+//
+//	8:16 rbytes=1459200 wbytes=314773504 rios=192 wios=353 dbytes=0 dios=0
+//
+// Values that are not numbers, such as "depth=max" of the io.latency
+// controller, are skipped.
+func readIoStat(path string) ([]types.BlkioDeviceStats, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ios := []types.BlkioDeviceStats{}
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		device := strings.SplitN(fields[0], ":", 2)
+		if len(device) != 2 {
+			return nil, errors.Errorf("invalid device in %s: %q", path, fields[0])
+		}
+		var (
+			d   = types.BlkioDeviceStats{Stats: make(map[string]uint64)}
+			err error
+		)
+		if d.Major, err = strconv.ParseUint(device[0], 10, 64); err != nil {
+			return nil, errors.Wrapf(err, "invalid device in %s", path)
+		}
+		if d.Minor, err = strconv.ParseUint(device[1], 10, 64); err != nil {
+			return nil, errors.Wrapf(err, "invalid device in %s", path)
+		}
+		for _, f := range fields[1:] {
+			kv := strings.SplitN(f, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			if n, err := strconv.ParseUint(kv[1], 10, 64); err == nil {
+				d.Stats[kv[0]] = n
+			}
+		}
+		ios = append(ios, d)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return ios, nil
+}
+
+// cgroup2Metrics exports the stats of the cgroup v2 of the running containers
+// as part of the per-container metrics. The stats are read when they are
+// collected.
+type cgroup2Metrics struct {
+	pressure     *prometheus.Desc
+	memoryStat   *prometheus.Desc
+	memoryEvents *prometheus.Desc
+	ioStat       *prometheus.Desc
+}
+
+func (m *cgroup2Metrics) describe(ns *metrics.Namespace, labels []string) {
+	withLabels := func(l ...string) []string {
+		return append(append([]string{}, labels...), l...)
+	}
+	m.pressure = ns.NewDesc("container_pressure_seconds", "The total time in which some or all tasks of a container were stalled waiting for a resource", metrics.Total, withLabels("resource", "kind")...)
+	m.memoryStat = ns.NewDesc("container_memory_stat", "The stats of the memory.stat file of the cgroup of a container", metrics.Unit(""), withLabels("stat")...)
+	m.memoryEvents = ns.NewDesc("container_memory_events", "The number of memory events of the memory.events file of the cgroup of a container", metrics.Total, withLabels("event")...)
+	m.ioStat = ns.NewDesc("container_io_stat", "The stats of each device of the io.stat file of the cgroup of a container", metrics.Unit(""), withLabels("device", "stat")...)
+}
+
+func (m *cgroup2Metrics) descs() []*prometheus.Desc {
+	return []*prometheus.Desc{m.pressure, m.memoryStat, m.memoryEvents, m.ioStat}
+}
+
+// collectContainer collects the stats of the cgroup of the container c, of
+// which labels are the values of the labels of the metrics.
+func (m *cgroup2Metrics) collectContainer(ch chan<- prometheus.Metric, labels []string, c *container.Container) {
+	if cgroups.Mode() != cgroups.Unified {
+		return
+	}
+	pid := c.GetPID()
+	if pid == 0 {
+		return
+	}
+	dir, err := cgroup2Dir(pid)
+	if err != nil {
+		return
+	}
+	cs, err := readCgroup2Dir(dir)
+	if err != nil {
+		logrus.WithError(err).WithField("cgroup", dir).Debug("failed to read stats of cgroup")
+		return
+	}
+	m.collect(ch, labels, cs)
+}
+
+func (m *cgroup2Metrics) collect(ch chan<- prometheus.Metric, labels []string, cs cgroup2Stats) {
+	withLabels := func(l ...string) []string {
+		return append(append([]string{}, labels...), l...)
+	}
+	for _, r := range pressureResources {
+		p := cs.Pressure[r]
+		if p == nil {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(m.pressure, prometheus.CounterValue, float64(p.Some.Total)/1e6, withLabels(r, "some")...)
+		ch <- prometheus.MustNewConstMetric(m.pressure, prometheus.CounterValue, float64(p.Full.Total)/1e6, withLabels(r, "full")...)
+	}
+	for k, v := range cs.MemoryStats {
+		ch <- prometheus.MustNewConstMetric(m.memoryStat, prometheus.UntypedValue, float64(v), withLabels(k)...)
+	}
+	for k, v := range cs.MemoryEvents {
+		ch <- prometheus.MustNewConstMetric(m.memoryEvents, prometheus.CounterValue, float64(v), withLabels(k)...)
+	}
+	for _, d := range cs.IoStats {
+		device := strconv.FormatUint(d.Major, 10) + ":" + strconv.FormatUint(d.Minor, 10)
+		for k, v := range d.Stats {
+			ch <- prometheus.MustNewConstMetric(m.ioStat, prometheus.UntypedValue, float64(v), withLabels(device, k)...)
+		}
+	}
+}
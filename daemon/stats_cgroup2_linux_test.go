@@ -0,0 +1,84 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	metrics "github.com/docker/go-metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestReadCgroup2Dir(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) {
+		t.Helper()
+		assert.NilError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	writeFile("cpu.pressure", "some avg10=1.50 avg60=0.25 avg300=0.00 total=123456\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=0\n")
+	writeFile("memory.pressure", "some avg10=0.00 avg60=0.00 avg300=0.00 total=10\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=5\n")
+	// The io controller is disabled, so io.pressure and io.stat are missing.
+	writeFile("memory.stat", "anon 4096\nfile 8192\nzswap 0\npgfault 42\n")
+	writeFile("memory.events", "low 0\nhigh 3\nmax 2\noom 1\noom_kill 1\n")
+
+	cs, err := readCgroup2Dir(dir)
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(cs.Pressure, map[string]*types.PressureStats{
+		"cpu": {
+			Some: types.PressureData{Avg10: 1.5, Avg60: 0.25, Total: 123456},
+		},
+		"memory": {
+			Some: types.PressureData{Total: 10},
+			Full: types.PressureData{Total: 5},
+		},
+	}))
+	assert.Check(t, is.DeepEqual(cs.MemoryStats, map[string]uint64{"anon": 4096, "file": 8192, "zswap": 0, "pgfault": 42}))
+	assert.Check(t, is.Equal(cs.MemoryEvents["oom_kill"], uint64(1)))
+	assert.Check(t, is.Len(cs.IoStats, 0))
+
+	writeFile("io.stat", "8:0 rbytes=1024 wbytes=2048 rios=1 wios=2 dbytes=0 dios=0\n259:1 rbytes=0 wbytes=0 rios=0 wios=0 dbytes=512 dios=1 depth=max avg_lat=300 win=100\n")
+	cs, err = readCgroup2Dir(dir)
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(cs.IoStats, []types.BlkioDeviceStats{
+		{Major: 8, Minor: 0, Stats: map[string]uint64{"rbytes": 1024, "wbytes": 2048, "rios": 1, "wios": 2, "dbytes": 0, "dios": 0}},
+		{Major: 259, Minor: 1, Stats: map[string]uint64{"rbytes": 0, "wbytes": 0, "rios": 0, "wios": 0, "dbytes": 512, "dios": 1, "avg_lat": 300, "win": 100}},
+	}))
+
+	writeFile("io.pressure", "some avg10=abc avg60=0.00 avg300=0.00 total=0\n")
+	_, err = readCgroup2Dir(dir)
+	assert.Check(t, is.ErrorContains(err, "invalid line"))
+}
+
+func TestCgroup2MetricsCollect(t *testing.T) {
+	var m cgroup2Metrics
+	m.describe(metrics.NewNamespace("engine", "daemon", nil), []string{"container_id", "name"})
+
+	ch := make(chan prometheus.Metric, 100)
+	m.collect(ch, []string{"abc", "web"}, cgroup2Stats{
+		Pressure:     map[string]*types.PressureStats{"memory": {Some: types.PressureData{Total: 2e6}}},
+		MemoryEvents: map[string]uint64{"oom_kill": 1},
+		IoStats:      []types.BlkioDeviceStats{{Major: 8, Minor: 0, Stats: map[string]uint64{"rbytes": 1024}}},
+	})
+	close(ch)
+
+	labels := make(map[*prometheus.Desc][][]string)
+	for metric := range ch {
+		var out dto.Metric
+		assert.NilError(t, metric.Write(&out))
+		var values []string
+		for _, l := range out.Label {
+			values = append(values, l.GetName()+"="+l.GetValue())
+		}
+		labels[metric.Desc()] = append(labels[metric.Desc()], values)
+	}
+	assert.Check(t, is.DeepEqual(labels[m.pressure], [][]string{
+		{"container_id=abc", "kind=some", "name=web", "resource=memory"},
+		{"container_id=abc", "kind=full", "name=web", "resource=memory"},
+	}))
+	assert.Check(t, is.DeepEqual(labels[m.memoryEvents], [][]string{{"container_id=abc", "event=oom_kill", "name=web"}}))
+	assert.Check(t, is.DeepEqual(labels[m.ioStat], [][]string{{"container_id=abc", "device=8:0", "name=web", "stat=rbytes"}}))
+}
@@ -0,0 +1,158 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+)
+
+const (
+	// clockTicksPerSecond is the unit of the CPU times in /proc. The value
+	// comes from `C.sysconf(C._SC_CLK_TCK)`, which is a constant on Linux.
+	clockTicksPerSecond = 100
+)
+
+// procRoot is the mount point of procfs; for mocking in unit tests.
+var procRoot = "/proc"
+
+// listProcesses returns the processes with the given PIDs, which it reads from
+// /proc instead of running ps. Processes that exited are skipped.
+func listProcesses(pids []uint32) ([]container.TopProcess, error) {
+	bootTime, err := readBootTime()
+	if err != nil {
+		return nil, errdefs.System(errors.Wrap(err, "failed to list processes"))
+	}
+	now := time.Now()
+	pageSize := uint64(os.Getpagesize())
+
+	procs := make([]container.TopProcess, 0, len(pids))
+	for _, pid := range pids {
+		p, err := readProcess(int(pid), bootTime, now, pageSize)
+		if err != nil {
+			if os.IsNotExist(errors.Cause(err)) {
+				continue
+			}
+			return nil, errdefs.System(errors.Wrap(err, "failed to list processes"))
+		}
+		procs = append(procs, p)
+	}
+	return procs, nil
+}
+
+// readBootTime returns the time the host booted, from the btime line of
+// /proc/stat.
+func readBootTime() (time.Time, error) {
+	f, err := os.Open(filepath.Join(procRoot, "stat"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if v := strings.TrimPrefix(s.Text(), "btime "); v != s.Text() {
+			btime, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return time.Time{}, errors.Wrap(err, "invalid btime in /proc/stat")
+			}
+			return time.Unix(btime, 0), nil
+		}
+	}
+	if err := s.Err(); err != nil {
+		return time.Time{}, err
+	}
+	return time.Time{}, errors.New("no btime in /proc/stat")
+}
+
+func readProcess(pid int, bootTime, now time.Time, pageSize uint64) (container.TopProcess, error) {
+	dir := filepath.Join(procRoot, strconv.Itoa(pid))
+	p := container.TopProcess{Pid: pid}
+
+	stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return p, err
+	}
+	// The name of the command is in parentheses and may contain spaces and
+	// parentheses, so the fields are parsed after the last parenthesis.
+	start, end := bytes.IndexByte(stat, '('), bytes.LastIndexByte(stat, ')')
+	if start < 0 || end < start {
+		return p, errors.Errorf("invalid stat of process %d", pid)
+	}
+	comm := string(stat[start+1 : end])
+	fields := strings.Fields(string(stat[end+1:]))
+	// See proc(5) for the fields, which start with the state (field 3).
+	const (
+		fieldState     = 0
+		fieldPPid      = 1
+		fieldUTime     = 11
+		fieldSTime     = 12
+		fieldStartTime = 19
+		fieldRSS       = 21
+	)
+	if len(fields) <= fieldRSS {
+		return p, errors.Errorf("invalid stat of process %d", pid)
+	}
+	var values [fieldRSS + 1]uint64
+	for _, i := range []int{fieldPPid, fieldUTime, fieldSTime, fieldStartTime, fieldRSS} {
+		if values[i], err = strconv.ParseUint(fields[i], 10, 64); err != nil {
+			return p, errors.Wrapf(err, "invalid stat of process %d", pid)
+		}
+	}
+	p.State = fields[fieldState]
+	p.PPid = int(values[fieldPPid])
+	p.RSS = values[fieldRSS] * pageSize
+	p.CPUTime = float64(values[fieldUTime]+values[fieldSTime]) / clockTicksPerSecond
+	p.StartTime = bootTime.Add(time.Duration(values[fieldStartTime]) * time.Second / clockTicksPerSecond)
+	if elapsed := now.Sub(p.StartTime).Seconds(); elapsed > 0 {
+		p.CPUPercent = p.CPUTime / elapsed * 100
+	}
+
+	if p.UID, err = readProcessUID(dir); err != nil {
+		return p, errors.Wrapf(err, "invalid status of process %d", pid)
+	}
+
+	cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline"))
+	if err != nil {
+		return p, err
+	}
+	if cmdline = bytes.TrimRight(cmdline, "\x00"); len(cmdline) > 0 {
+		p.Cmd = strings.Split(string(cmdline), "\x00")
+	} else {
+		// Zombies and kernel threads have no command line, for which ps
+		// shows the name of the command in brackets.
+		p.Cmd = []string{fmt.Sprintf("[%s]", comm)}
+	}
+	return p, nil
+}
+
+// readProcessUID returns the effective user ID of a process from the Uid line
+// of its status.
+func readProcessUID(dir string) (uint32, error) {
+	f, err := os.Open(filepath.Join(dir, "status"))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		// The line has the real, effective, saved and filesystem user IDs.
+		if fields := strings.Fields(s.Text()); len(fields) >= 3 && fields[0] == "Uid:" {
+			uid, err := strconv.ParseUint(fields[2], 10, 32)
+			return uint32(uid), err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return 0, err
+	}
+	return 0, errors.New("no Uid")
+}
@@ -0,0 +1,66 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestListProcesses(t *testing.T) {
+	root := t.TempDir()
+	defer func(orig string) { procRoot = orig }(procRoot)
+	procRoot = root
+
+	bootTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	writeFile := func(path, content string) {
+		t.Helper()
+		assert.NilError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0o755))
+		assert.NilError(t, os.WriteFile(filepath.Join(root, path), []byte(content), 0o644))
+	}
+	writeFile("stat", "cpu  1 2 3 4 5 6 7 0 0 0\nbtime "+strconv.FormatInt(bootTime.Unix(), 10)+"\nprocesses 42\n")
+	// The process started 30 minutes after boot, and used 9 seconds of CPU.
+	writeFile("42/stat", "42 (my (cmd)) S 1 42 42 0 -1 4194560 100 0 0 0 600 300 0 0 20 0 1 0 180000 1000000 25 18446744073709551615\n")
+	writeFile("42/status", "Name:\tmy (cmd)\nUid:\t0\t1000\t1000\t1000\nGid:\t0\t0\t0\t0\n")
+	writeFile("42/cmdline", "sleep\x00infinity\x00")
+	writeFile("43/stat", "43 (defunct) Z 42 42 42 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 180000 0 0 18446744073709551615\n")
+	writeFile("43/status", "Name:\tdefunct\nUid:\t0\t0\t0\t0\n")
+	writeFile("43/cmdline", "")
+
+	// PID 44 exited, and is skipped.
+	processes, err := listProcesses([]uint32{42, 43, 44})
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(processes, 2))
+
+	p := processes[0]
+	assert.Check(t, is.Equal(p.Pid, 42))
+	assert.Check(t, is.Equal(p.PPid, 1))
+	assert.Check(t, is.Equal(p.UID, uint32(1000)))
+	assert.Check(t, is.Equal(p.State, "S"))
+	assert.Check(t, is.Equal(p.CPUTime, 9.0))
+	assert.Check(t, is.Equal(p.RSS, 25*uint64(os.Getpagesize())))
+	assert.Check(t, p.StartTime.Equal(bootTime.Add(30*time.Minute)), p.StartTime)
+	assert.Check(t, p.CPUPercent > 0.49 && p.CPUPercent < 0.51, p.CPUPercent)
+	assert.Check(t, is.DeepEqual(p.Cmd, []string{"sleep", "infinity"}))
+
+	assert.Check(t, is.Equal(processes[1].State, "Z"))
+	assert.Check(t, is.DeepEqual(processes[1].Cmd, []string{"[defunct]"}))
+}
+
+func TestFormatPSProcesses(t *testing.T) {
+	now := time.Date(2022, time.June, 1, 12, 0, 0, 0, time.Local)
+	procList := formatPSProcesses([]container.TopProcess{
+		{Pid: 42, PPid: 1, UID: 0, User: "root", CPUPercent: 1.5, CPUTime: 3723, StartTime: now.Add(-time.Hour), Cmd: []string{"sleep", "10"}},
+		{Pid: 43, PPid: 42, UID: 1000, StartTime: now.Add(-48 * time.Hour), Cmd: []string{"top"}},
+	}, now)
+	assert.Check(t, is.DeepEqual(procList.Titles, []string{"UID", "PID", "PPID", "C", "STIME", "TTY", "TIME", "CMD"}))
+	assert.Check(t, is.DeepEqual(procList.Processes, [][]string{
+		{"root", "42", "1", "1", "11:00", "?", "01:02:03", "sleep 10"},
+		{"1000", "43", "42", "0", "May30", "?", "00:00:00", "top"},
+	}))
+}
@@ -11,9 +11,12 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types/container"
+	containerpkg "github.com/docker/docker/container"
 	"github.com/docker/docker/errdefs"
+	"github.com/opencontainers/runc/libcontainer/user"
 	"github.com/pkg/errors"
 )
 
@@ -135,7 +138,8 @@ func psPidsArg(pids []uint32) string {
 // container by calling ps with the given args, or with the flags
 // "-ef" if no args are given.  An error is returned if the container
 // is not found, or is not running, or if there are any problems
-// running ps, or parsing the output.
+// running ps, or parsing the output. If ps is not installed on the
+// host, the processes are listed in the format of "ps -ef" without it.
 func (daemon *Daemon) ContainerTop(name string, psArgs string) (*container.ContainerTopOKBody, error) {
 	if psArgs == "" {
 		psArgs = "-ef"
@@ -145,22 +149,18 @@ func (daemon *Daemon) ContainerTop(name string, psArgs string) (*container.Conta
 		return nil, err
 	}
 
-	ctr, err := daemon.GetContainer(name)
+	ctr, procs, err := daemon.getTopPids(name)
 	if err != nil {
 		return nil, err
 	}
 
-	if !ctr.IsRunning() {
-		return nil, errNotRunning(ctr.ID)
-	}
-
-	if ctr.IsRestarting() {
-		return nil, errContainerIsRestarting(ctr.ID)
-	}
-
-	procs, err := daemon.containerd.ListPids(context.Background(), ctr.ID)
-	if err != nil {
-		return nil, err
+	if _, err := exec.LookPath("ps"); err != nil && psArgs == "-ef" {
+		processes, err := daemon.listContainerProcesses(ctr, procs)
+		if err != nil {
+			return nil, err
+		}
+		daemon.LogContainerEvent(ctr, "top")
+		return formatPSProcesses(processes, time.Now()), nil
 	}
 
 	args := strings.Split(psArgs, " ")
@@ -188,3 +188,114 @@ func (daemon *Daemon) ContainerTop(name string, psArgs string) (*container.Conta
 	daemon.LogContainerEvent(ctr, "top")
 	return procList, nil
 }
+
+// ContainerTopProcesses lists the processes running inside of the given
+// container, which it reads from /proc instead of running ps.
+func (daemon *Daemon) ContainerTopProcesses(name string) (*container.TopProcessList, error) {
+	ctr, procs, err := daemon.getTopPids(name)
+	if err != nil {
+		return nil, err
+	}
+	processes, err := daemon.listContainerProcesses(ctr, procs)
+	if err != nil {
+		return nil, err
+	}
+	daemon.LogContainerEvent(ctr, "top")
+	return &container.TopProcessList{Processes: processes}, nil
+}
+
+// getTopPids returns the running container with the given name, and the PIDs
+// of its processes.
+func (daemon *Daemon) getTopPids(name string) (*containerpkg.Container, []uint32, error) {
+	ctr, err := daemon.GetContainer(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !ctr.IsRunning() {
+		return nil, nil, errNotRunning(ctr.ID)
+	}
+
+	if ctr.IsRestarting() {
+		return nil, nil, errContainerIsRestarting(ctr.ID)
+	}
+
+	procs, err := daemon.containerd.ListPids(context.Background(), ctr.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ctr, procs, nil
+}
+
+// listContainerProcesses returns the processes of ctr with the given PIDs,
+// with the names of their users in the container.
+func (daemon *Daemon) listContainerProcesses(ctr *containerpkg.Container, pids []uint32) ([]container.TopProcess, error) {
+	processes, err := listProcesses(pids)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make(map[int]string)
+	if passwd, err := ctr.GetResourcePath("/etc/passwd"); err == nil {
+		// The container may have no passwd file, in which case only the IDs
+		// of the users are known.
+		entries, _ := user.ParsePasswdFile(passwd)
+		for _, u := range entries {
+			if _, ok := users[u.Uid]; !ok {
+				users[u.Uid] = u.Name
+			}
+		}
+	}
+	for i, p := range processes {
+		if uid, ok := daemon.containerUID(int(p.UID)); ok {
+			processes[i].User = users[uid]
+		}
+	}
+	return processes, nil
+}
+
+// containerUID returns the user ID in containers of the given user ID on the
+// host, which differ if user namespaces are remapped.
+func (daemon *Daemon) containerUID(hostUID int) (int, bool) {
+	if daemon.idMapping.Empty() {
+		return hostUID, true
+	}
+	for _, m := range daemon.idMapping.UIDMaps {
+		if hostUID >= m.HostID && hostUID < m.HostID+m.Size {
+			return m.ContainerID + hostUID - m.HostID, true
+		}
+	}
+	return 0, false
+}
+
+// formatPSProcesses returns processes in the format of "ps -ef".
+func formatPSProcesses(processes []container.TopProcess, now time.Time) *container.ContainerTopOKBody {
+	procList := &container.ContainerTopOKBody{
+		Titles:    []string{"UID", "PID", "PPID", "C", "STIME", "TTY", "TIME", "CMD"},
+		Processes: make([][]string, 0, len(processes)),
+	}
+	for _, p := range processes {
+		uid := p.User
+		if uid == "" {
+			uid = strconv.FormatUint(uint64(p.UID), 10)
+		}
+		// ps shows the time of processes that started today, and the date
+		// of older processes.
+		stime := p.StartTime.Local().Format("Jan02")
+		if y, m, d := p.StartTime.Local().Date(); now.Local().Year() == y && now.Local().Month() == m && now.Local().Day() == d {
+			stime = p.StartTime.Local().Format("15:04")
+		}
+		cpuTime := int(p.CPUTime)
+		procList.Processes = append(procList.Processes, []string{
+			uid,
+			strconv.Itoa(p.Pid),
+			strconv.Itoa(p.PPid),
+			strconv.Itoa(int(p.CPUPercent)),
+			stime,
+			"?",
+			fmt.Sprintf("%02d:%02d:%02d", cpuTime/3600, cpuTime/60%60, cpuTime%60),
+			strings.Join(p.Cmd, " "),
+		})
+	}
+	return procList
+}
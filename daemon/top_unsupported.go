@@ -0,0 +1,16 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"runtime"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+)
+
+func listProcesses(pids []uint32) ([]container.TopProcess, error) {
+	return nil, errdefs.NotImplemented(errors.New("listing processes without ps is not supported on " + runtime.GOOS))
+}
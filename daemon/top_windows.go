@@ -61,3 +61,41 @@ func (daemon *Daemon) ContainerTop(name string, psArgs string) (*containertypes.
 
 	return procList, nil
 }
+
+// ContainerTopProcesses lists the processes running inside of the given
+// container.
+func (daemon *Daemon) ContainerTopProcesses(name string) (*containertypes.TopProcessList, error) {
+	container, err := daemon.GetContainer(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !container.IsRunning() {
+		return nil, errNotRunning(container.ID)
+	}
+
+	if container.IsRestarting() {
+		return nil, errContainerIsRestarting(container.ID)
+	}
+
+	s, err := daemon.containerd.Summary(context.Background(), container.ID)
+	if err != nil {
+		return nil, err
+	}
+	procList := &containertypes.TopProcessList{Processes: make([]containertypes.TopProcess, 0, len(s))}
+	now := time.Now()
+	for _, j := range s {
+		p := containertypes.TopProcess{
+			Pid:       int(j.ProcessID),
+			CPUTime:   time.Duration((j.KernelTime_100Ns + j.UserTime_100Ns) * 100).Seconds(),
+			RSS:       j.MemoryWorkingSetPrivateBytes,
+			StartTime: j.CreatedAt,
+			Cmd:       []string{j.ImageName},
+		}
+		if elapsed := now.Sub(j.CreatedAt).Seconds(); elapsed > 0 {
+			p.CPUPercent = p.CPUTime / elapsed * 100
+		}
+		procList.Processes = append(procList.Processes, p)
+	}
+	return procList, nil
+}
@@ -0,0 +1,101 @@
+package distribution // import "github.com/docker/docker/distribution"
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/distribution/registry/client/auth"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/registry"
+	"github.com/sirupsen/logrus"
+)
+
+// tokenHandlerIdleTimeout is the time after which token handlers that were
+// not used are removed from the cache.
+const tokenHandlerIdleTimeout = 10 * time.Minute
+
+// tokenHandlers caches the token handlers of repositories, so that bearer
+// tokens are reused by subsequent pulls and pushes of a repository until they
+// expire, instead of being negotiated for every operation.
+var tokenHandlers = newTokenHandlerCache()
+
+// hasAuthConfig returns whether authConfig has any credentials.
+func hasAuthConfig(authConfig *types.AuthConfig) bool {
+	return authConfig != nil && (authConfig.Username != "" || authConfig.Password != "" || authConfig.Auth != "" ||
+		authConfig.IdentityToken != "" || authConfig.RegistryToken != "")
+}
+
+// resolveAuthConfig sets the credentials of config to the credentials of the
+// registry of repoInfo that are configured in the daemon, if the client did
+// not send any. This allows operations that are not started by a client,
+// such as pulls for restarts of containers, to access private registries. It
+// must only be used for pulls, so that clients cannot push with the
+// credentials of the daemon.
+func resolveAuthConfig(ctx context.Context, config *Config, repoInfo *registry.RepositoryInfo) {
+	if hasAuthConfig(config.AuthConfig) {
+		return
+	}
+	authConfig, err := config.RegistryService.LookupCredentials(ctx, reference.Domain(repoInfo.Name))
+	if err != nil {
+		logrus.WithError(err).WithField("repository", repoInfo.Name.Name()).Warn("failed to look up registry credentials")
+		return
+	}
+	if authConfig != nil {
+		config.AuthConfig = authConfig
+	}
+}
+
+type tokenHandlerCache struct {
+	mu       sync.Mutex
+	handlers map[string]*cachedTokenHandler
+}
+
+type cachedTokenHandler struct {
+	handler  auth.AuthenticationHandler
+	lastUsed time.Time
+}
+
+func newTokenHandlerCache() *tokenHandlerCache {
+	return &tokenHandlerCache{handlers: make(map[string]*cachedTokenHandler)}
+}
+
+// get returns the cached token handler with the given key, or caches the
+// token handler that is returned by create if there is none. Token handlers
+// refresh their token when it expires.
+func (c *tokenHandlerCache) get(key string, create func() auth.AuthenticationHandler) auth.AuthenticationHandler {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, h := range c.handlers {
+		if now.Sub(h.lastUsed) > tokenHandlerIdleTimeout {
+			delete(c.handlers, k)
+		}
+	}
+	h, ok := c.handlers[key]
+	if !ok {
+		h = &cachedTokenHandler{handler: create()}
+		c.handlers[key] = h
+	}
+	h.lastUsed = now
+	return h.handler
+}
+
+// tokenHandlerKey returns the key of the token handler of the given scope on
+// endpoint with authConfig. The credentials are hashed, so that they are not
+// kept in the keys of the cache.
+func tokenHandlerKey(endpoint *url.URL, scope auth.Scope, authConfig *types.AuthConfig) string {
+	h := sha256.New()
+	for _, s := range []string{authConfig.Username, authConfig.Password, authConfig.Auth, authConfig.IdentityToken} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return strings.Join([]string{endpoint.String(), scope.String(), hex.EncodeToString(h.Sum(nil))}, " ")
+}
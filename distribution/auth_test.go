@@ -0,0 +1,47 @@
+package distribution // import "github.com/docker/docker/distribution"
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/distribution/registry/client/auth"
+	"github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+type fakeAuthHandler string
+
+func (fakeAuthHandler) Scheme() string { return "bearer" }
+
+func (fakeAuthHandler) AuthorizeRequest(*http.Request, map[string]string) error { return nil }
+
+func TestTokenHandlerCache(t *testing.T) {
+	c := newTokenHandlerCache()
+	endpoint, _ := url.Parse("https://registry.example.com")
+	scope := auth.RepositoryScope{Repository: "foo", Actions: []string{"pull"}}
+	user := tokenHandlerKey(endpoint, scope, &types.AuthConfig{Username: "user", Password: "pass"})
+	other := tokenHandlerKey(endpoint, scope, &types.AuthConfig{Username: "user", Password: "other"})
+	anonymous := tokenHandlerKey(endpoint, scope, &types.AuthConfig{})
+	push := tokenHandlerKey(endpoint, auth.RepositoryScope{Repository: "foo", Actions: []string{"push", "pull"}}, &types.AuthConfig{Username: "user", Password: "pass"})
+	assert.Check(t, user != other)
+	assert.Check(t, user != anonymous)
+	assert.Check(t, user != push)
+	assert.Check(t, !strings.Contains(user, "pass"))
+
+	create := func(name string) func() auth.AuthenticationHandler {
+		return func() auth.AuthenticationHandler { return fakeAuthHandler(name) }
+	}
+	assert.Check(t, is.Equal(c.get(user, create("first")), auth.AuthenticationHandler(fakeAuthHandler("first"))))
+	assert.Check(t, is.Equal(c.get(user, create("second")), auth.AuthenticationHandler(fakeAuthHandler("first"))))
+	assert.Check(t, is.Equal(c.get(other, create("other")), auth.AuthenticationHandler(fakeAuthHandler("other"))))
+
+	// Handlers that were not used for a while are removed.
+	c.handlers[user].lastUsed = time.Now().Add(-2 * tokenHandlerIdleTimeout)
+	assert.Check(t, is.Equal(c.get(other, create("unused")), auth.AuthenticationHandler(fakeAuthHandler("other"))))
+	assert.Check(t, is.Len(c.handlers, 1))
+	assert.Check(t, is.Equal(c.get(user, create("third")), auth.AuthenticationHandler(fakeAuthHandler("third"))))
+}
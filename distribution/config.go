@@ -8,6 +8,7 @@ import (
 
 	"github.com/docker/distribution"
 	"github.com/docker/distribution/manifest/schema2"
+	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/distribution/metadata"
 	"github.com/docker/docker/distribution/xfer"
@@ -63,6 +64,14 @@ type ImagePullConfig struct {
 	Schema2Types []string
 	// Platform is the requested platform of the image being pulled
 	Platform *specs.Platform
+	// PartialDownloadRoot is an optional directory in which layer downloads
+	// are persisted, so that interrupted downloads can be resumed by a
+	// later pull. If omitted, interrupted downloads are discarded.
+	PartialDownloadRoot string
+	// ManifestVerifier is an optional verifier of the manifests that are
+	// pulled. The pull fails before any layers are downloaded if a manifest
+	// is not verified.
+	ManifestVerifier ManifestVerifier
 }
 
 // ImagePushConfig stores push configuration.
@@ -79,6 +88,23 @@ type ImagePushConfig struct {
 	TrustKey libtrust.PrivateKey
 	// UploadManager dispatches uploads.
 	UploadManager *xfer.LayerUploadManager
+	// ManifestList is an optional list of local images of which a manifest
+	// list is pushed with the tag of the pushed reference. If set, the local
+	// images of the reference itself are not pushed.
+	ManifestList []ManifestListEntry
+	// ManifestListMediaType is the media type of ManifestList. It defaults
+	// to the media type of Docker manifest lists.
+	ManifestListMediaType string
+}
+
+// ManifestListEntry is an image of a manifest list that is pushed.
+type ManifestListEntry struct {
+	// ID is the ID of the local image.
+	ID digest.Digest
+	// Platform is the platform of the image.
+	Platform specs.Platform
+	// Annotations are the annotations of the image in the manifest list.
+	Annotations map[string]string
 }
 
 // ImageConfigStore handles storing and getting image configurations
@@ -89,6 +115,19 @@ type ImageConfigStore interface {
 	Get(context.Context, digest.Digest) ([]byte, error)
 }
 
+// ManifestVerifier verifies the manifests of images that are pulled.
+type ManifestVerifier interface {
+	// VerifyManifest returns an error if the manifest with the given digest
+	// that is pulled from repo for ref must not be pulled.
+	VerifyManifest(ctx context.Context, repo distribution.Repository, ref reference.Named, dgst digest.Digest) error
+}
+
+// ImageProvenanceStore is implemented by ImageConfigStores that store the
+// provenance of images, which is pushed along with the image.
+type ImageProvenanceStore interface {
+	GetProvenance(context.Context, digest.Digest) ([]byte, error)
+}
+
 // PushLayerProvider provides layers to be pushed by ChainID.
 type PushLayerProvider interface {
 	Get(layer.ChainID) (PushLayer, error)
@@ -131,6 +170,10 @@ func (s *imageConfigStore) Get(_ context.Context, d digest.Digest) ([]byte, erro
 	return img.RawJSON(), nil
 }
 
+func (s *imageConfigStore) GetProvenance(_ context.Context, d digest.Digest) ([]byte, error) {
+	return s.Store.GetProvenance(image.IDFromDigest(d))
+}
+
 func rootFSFromConfig(c []byte) (*image.RootFS, error) {
 	var unmarshalledConfig image.Image
 	if err := json.Unmarshal(c, &unmarshalledConfig); err != nil {
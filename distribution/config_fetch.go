@@ -0,0 +1,81 @@
+package distribution // import "github.com/docker/docker/distribution"
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/ocischema"
+	"github.com/docker/distribution/manifest/schema2"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/errdefs"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+// FetchImageConfig resolves ref in the registry, and returns the digest of the
+// manifest of the image for the platform in config, and the image's
+// configuration. The layers of the image are not pulled.
+func FetchImageConfig(ctx context.Context, ref reference.Named, config *ImagePullConfig) (manifestDigest digest.Digest, configJSON []byte, err error) {
+	repo, err := GetRepository(ctx, ref, config)
+	if err != nil {
+		return "", nil, err
+	}
+
+	switch r := ref.(type) {
+	case reference.Canonical:
+		manifestDigest = r.Digest()
+	case reference.NamedTagged:
+		desc, err := repo.Tags(ctx).Get(ctx, r.Tag())
+		if err != nil {
+			return "", nil, translatePullError(err, ref)
+		}
+		manifestDigest = desc.Digest
+	default:
+		return "", nil, errdefs.InvalidParameter(errors.Errorf("reference %s has neither a tag nor a digest", reference.FamiliarString(ref)))
+	}
+
+	manSvc, err := repo.Manifests(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	mfst, err := manSvc.Get(ctx, manifestDigest)
+	if err != nil {
+		return "", nil, translatePullError(err, ref)
+	}
+
+	if mfstList, ok := mfst.(*manifestlist.DeserializedManifestList); ok {
+		var platform specs.Platform
+		if config.Platform != nil {
+			platform = *config.Platform
+		}
+		matches := filterManifests(mfstList.Manifests, platform)
+		if len(matches) == 0 {
+			return "", nil, errdefs.NotFound(fmt.Errorf("no matching manifest for %s in the manifest list entries", formatPlatform(platform)))
+		}
+		manifestDigest = matches[0].Digest
+		mfst, err = manSvc.Get(ctx, manifestDigest)
+		if err != nil {
+			return "", nil, translatePullError(err, ref)
+		}
+	}
+
+	var target distribution.Descriptor
+	switch m := mfst.(type) {
+	case *schema2.DeserializedManifest:
+		target = m.Target()
+	case *ocischema.DeserializedManifest:
+		target = m.Target()
+	default:
+		return "", nil, invalidManifestFormatError{}
+	}
+
+	p := &puller{repo: repo}
+	configJSON, err = p.pullSchema2Config(ctx, target.Digest)
+	if err != nil {
+		return "", nil, imageConfigPullError{Err: err}
+	}
+	return manifestDigest, configJSON, nil
+}
@@ -68,6 +68,11 @@ func (e notFoundError) Cause() error {
 // information which is not used by the returned error gets output to
 // log at info level.
 func translatePullError(err error, ref reference.Named) error {
+	if errdefs.IsForbidden(err) {
+		// The pull was rejected by the ManifestVerifier, which is not an
+		// error of the registry.
+		return err
+	}
 	switch v := err.(type) {
 	case errcode.Errors:
 		if len(v) != 0 {
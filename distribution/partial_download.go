@@ -0,0 +1,76 @@
+package distribution // import "github.com/docker/docker/distribution"
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// partialDownloadMaxAge is the age after which partially downloaded blobs
+// that were not resumed are removed by PrunePartialDownloads.
+const partialDownloadMaxAge = 7 * 24 * time.Hour
+
+// partialDownloadPath returns the path at which the partially downloaded blob
+// with the given digest is persisted in root.
+func partialDownloadPath(root string, dgst digest.Digest) string {
+	return filepath.Join(root, dgst.Algorithm().String(), dgst.Encoded())
+}
+
+// openPartialDownload opens the file used to download the blob with the given
+// digest. If root is empty, a temporary file is created which is not kept
+// after the download is closed. Otherwise, the blob is downloaded to a file in
+// root that is kept if the download is interrupted, and any data that was
+// downloaded by a previous pull is preserved, so that it can be resumed.
+func openPartialDownload(root string, dgst digest.Digest) (*os.File, error) {
+	if root == "" {
+		return createDownloadFile()
+	}
+	if err := dgst.Validate(); err != nil {
+		return nil, err
+	}
+	p := partialDownloadPath(root, dgst)
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(p, os.O_RDWR|os.O_CREATE, 0600)
+}
+
+// resumePartialDownload re-hashes the data that was downloaded by a previous
+// pull into verifier, and returns the offset at which the download should be
+// resumed.
+func resumePartialDownload(f *os.File, verifier io.Writer) (int64, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	offset, err := io.Copy(verifier, f)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to verify partial download")
+	}
+	return offset, nil
+}
+
+// PrunePartialDownloads removes partially downloaded blobs in root that have
+// not been modified for longer than a week.
+func PrunePartialDownloads(root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || time.Since(info.ModTime()) < partialDownloadMaxAge {
+			return nil
+		}
+		logrus.Debugf("removing stale partial download %s", path)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	})
+}
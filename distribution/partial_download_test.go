@@ -0,0 +1,140 @@
+package distribution // import "github.com/docker/docker/distribution"
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/progress"
+	"github.com/opencontainers/go-digest"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestResumePartialDownload(t *testing.T) {
+	blob := make([]byte, 64*1024)
+	rand.New(rand.NewSource(1)).Read(blob)
+	dgst := digest.FromBytes(blob)
+
+	tests := []struct {
+		name         string
+		partial      []byte
+		expectRanges []string
+		expectRetry  bool
+	}{
+		{
+			name:         "no partial data",
+			expectRanges: []string{""},
+		},
+		{
+			name:         "resume",
+			partial:      blob[:len(blob)/2],
+			expectRanges: []string{"bytes=32768-"},
+		},
+		{
+			name:    "complete",
+			partial: blob,
+		},
+		{
+			name:         "corrupt",
+			partial:      bytes.Repeat([]byte{'x'}, len(blob)/2),
+			expectRanges: []string{"bytes=32768-", ""},
+			expectRetry:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var ranges []string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/v2/docker.io/library/testremotename/blobs/"+dgst.String():
+					ranges = append(ranges, r.Header.Get("Range"))
+					http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(blob))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer ts.Close()
+
+			p := testNewPuller(t, ts.URL)
+			root := t.TempDir()
+			if tc.partial != nil {
+				f, err := openPartialDownload(root, dgst)
+				assert.NilError(t, err)
+				_, err = f.Write(tc.partial)
+				assert.NilError(t, err)
+				assert.NilError(t, f.Close())
+			}
+
+			ld := &layerDescriptor{
+				digest:              dgst,
+				repoInfo:            p.repoInfo,
+				repo:                p.repo,
+				partialDownloadRoot: root,
+			}
+			defer ld.Close()
+
+			rc, _, err := ld.Download(context.Background(), progress.DiscardOutput())
+			if tc.expectRetry {
+				assert.ErrorContains(t, err, "verification failed")
+				rc, _, err = ld.Download(context.Background(), progress.DiscardOutput())
+			}
+			assert.NilError(t, err)
+			data, err := io.ReadAll(rc)
+			assert.NilError(t, err)
+			assert.Check(t, bytes.Equal(data, blob))
+			assert.NilError(t, rc.Close())
+
+			assert.Check(t, is.DeepEqual(ranges, tc.expectRanges))
+			_, err = os.Stat(partialDownloadPath(root, dgst))
+			assert.Check(t, os.IsNotExist(err), "expected completed download to be removed")
+		})
+	}
+}
+
+func TestClosePartialDownload(t *testing.T) {
+	dgst := digest.FromString("foo")
+	root := t.TempDir()
+
+	f, err := openPartialDownload(root, dgst)
+	assert.NilError(t, err)
+	_, err = f.WriteString("fo")
+	assert.NilError(t, err)
+
+	ld := &layerDescriptor{digest: dgst, tmpFile: f, partialDownloadRoot: root}
+	ld.Close()
+
+	data, err := os.ReadFile(partialDownloadPath(root, dgst))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(data), "fo"))
+}
+
+func TestPrunePartialDownloads(t *testing.T) {
+	root := t.TempDir()
+	stale, recent := digest.FromString("stale"), digest.FromString("recent")
+	for _, dgst := range []digest.Digest{stale, recent} {
+		f, err := openPartialDownload(root, dgst)
+		assert.NilError(t, err)
+		assert.NilError(t, f.Close())
+	}
+	old := time.Now().Add(-2 * partialDownloadMaxAge)
+	assert.NilError(t, os.Chtimes(partialDownloadPath(root, stale), old, old))
+
+	assert.NilError(t, PrunePartialDownloads(root))
+
+	_, err := os.Stat(partialDownloadPath(root, stale))
+	assert.Check(t, os.IsNotExist(err))
+	_, err = os.Stat(partialDownloadPath(root, recent))
+	assert.Check(t, err)
+
+	assert.NilError(t, PrunePartialDownloads(filepath.Join(root, "nonexistent")))
+}
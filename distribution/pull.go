@@ -30,6 +30,7 @@ func Pull(ctx context.Context, ref reference.Named, config *ImagePullConfig, loc
 	if err != nil {
 		return err
 	}
+	resolveAuthConfig(ctx, &config.Config, repoInfo)
 
 	var (
 		lastErr error
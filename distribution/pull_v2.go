@@ -155,12 +155,17 @@ func (p *puller) writeStatus(requestedTag string, layersDownloaded bool) {
 type layerDescriptor struct {
 	digest          digest.Digest
 	diffID          layer.DiffID
+	ref             reference.Canonical // manifest reference, used for lazy pulling
 	repoInfo        *registry.RepositoryInfo
 	repo            distribution.Repository
 	metadataService metadata.V2MetadataService
 	tmpFile         *os.File
 	verifier        digest.Verifier
 	src             distribution.Descriptor
+
+	// partialDownloadRoot is the directory in which the download is
+	// persisted, so it can be resumed if it is interrupted.
+	partialDownloadRoot string
 }
 
 func (ld *layerDescriptor) Key() string {
@@ -187,10 +192,29 @@ func (ld *layerDescriptor) Download(ctx context.Context, progressOutput progress
 	)
 
 	if ld.tmpFile == nil {
-		ld.tmpFile, err = createDownloadFile()
+		ld.tmpFile, err = openPartialDownload(ld.partialDownloadRoot, ld.digest)
 		if err != nil {
 			return nil, 0, xfer.DoNotRetry{Err: err}
 		}
+		if ld.partialDownloadRoot != "" {
+			// Data downloaded by a previous pull is re-hashed, so that
+			// the resumed download is verified as a whole.
+			ld.verifier = ld.digest.Verifier()
+			offset, err = resumePartialDownload(ld.tmpFile, ld.verifier)
+			if err != nil {
+				logrus.Debugf("error resuming partial download of %q: %v", ld.digest, err)
+				offset = 0
+				if err := ld.truncateDownloadFile(); err != nil {
+					return nil, 0, xfer.DoNotRetry{Err: err}
+				}
+			} else if offset != 0 {
+				if ld.verifier.Verified() {
+					logrus.Debugf("using previously downloaded %q", ld.digest)
+					return ld.completeDownload(progressOutput, offset)
+				}
+				logrus.Debugf("attempting to resume download of %q from %d bytes downloaded by a previous pull", ld.digest, offset)
+			}
+		}
 	} else {
 		offset, err = ld.tmpFile.Seek(0, io.SeekEnd)
 		if err != nil {
@@ -201,7 +225,7 @@ func (ld *layerDescriptor) Download(ctx context.Context, progressOutput progress
 			if err := os.Remove(ld.tmpFile.Name()); err != nil {
 				logrus.Errorf("Failed to remove temp file: %s", ld.tmpFile.Name())
 			}
-			ld.tmpFile, err = createDownloadFile()
+			ld.tmpFile, err = openPartialDownload(ld.partialDownloadRoot, ld.digest)
 			if err != nil {
 				return nil, 0, xfer.DoNotRetry{Err: err}
 			}
@@ -284,14 +308,26 @@ func (ld *layerDescriptor) Download(ctx context.Context, progressOutput progress
 
 			return nil, 0, err
 		}
+		// Do not keep the corrupt data for a future pull to resume.
+		if err := ld.truncateDownloadFile(); err != nil {
+			logrus.WithError(err).Errorf("Failed to discard download file: %s", tmpFile.Name())
+		}
 		return nil, 0, xfer.DoNotRetry{Err: err}
 	}
 
+	return ld.completeDownload(progressOutput, size)
+}
+
+// completeDownload hands off the verified download file to the download
+// manager.
+func (ld *layerDescriptor) completeDownload(progressOutput progress.Output, size int64) (io.ReadCloser, int64, error) {
+	tmpFile := ld.tmpFile
+
 	progress.Update(progressOutput, ld.ID(), "Download complete")
 
 	logrus.Debugf("Downloaded %s to tempfile %s", ld.ID(), tmpFile.Name())
 
-	_, err = tmpFile.Seek(0, io.SeekStart)
+	_, err := tmpFile.Seek(0, io.SeekStart)
 	if err != nil {
 		tmpFile.Close()
 		if err := os.Remove(tmpFile.Name()); err != nil {
@@ -319,6 +355,11 @@ func (ld *layerDescriptor) Download(ctx context.Context, progressOutput progress
 func (ld *layerDescriptor) Close() {
 	if ld.tmpFile != nil {
 		ld.tmpFile.Close()
+		if ld.partialDownloadRoot != "" {
+			// Keep the partially downloaded data, so that it can be
+			// resumed by a future pull.
+			return
+		}
 		if err := os.RemoveAll(ld.tmpFile.Name()); err != nil {
 			logrus.Errorf("Failed to remove temp file: %s", ld.tmpFile.Name())
 		}
@@ -426,6 +467,12 @@ func (p *puller) pullTag(ctx context.Context, ref reference.Named, platform *spe
 		}
 	}
 
+	if p.config.ManifestVerifier != nil {
+		if err := p.config.ManifestVerifier.VerifyManifest(ctx, p.repo, ref, dgst); err != nil {
+			return false, err
+		}
+	}
+
 	logrus.Debugf("Pulling ref from V2 registry: %s", reference.FamiliarString(ref))
 	progress.Message(p.config.ProgressOutput, tagOrDigest, "Pulling from "+reference.FamiliarName(p.repo.Named()))
 
@@ -579,6 +626,8 @@ func (p *puller) pullSchema1(ctx context.Context, ref reference.Reference, unver
 			repoInfo:        p.repoInfo,
 			repo:            p.repo,
 			metadataService: p.metadataService,
+
+			partialDownloadRoot: p.config.PartialDownloadRoot,
 		}
 
 		descriptors = append(descriptors, layerDescriptor)
@@ -612,6 +661,11 @@ func (p *puller) pullSchema2Layers(ctx context.Context, target distribution.Desc
 		return target.Digest, nil
 	}
 
+	manifestRef, err := reference.WithDigest(p.repoInfo.Name, target.Digest)
+	if err != nil {
+		return "", err
+	}
+
 	var descriptors []xfer.DownloadDescriptor
 
 	// Note that the order of this loop is in the direction of bottom-most
@@ -622,10 +676,13 @@ func (p *puller) pullSchema2Layers(ctx context.Context, target distribution.Desc
 		}
 		layerDescriptor := &layerDescriptor{
 			digest:          d.Digest,
+			ref:             manifestRef,
 			repo:            p.repo,
 			repoInfo:        p.repoInfo,
 			metadataService: p.metadataService,
 			src:             d,
+
+			partialDownloadRoot: p.config.PartialDownloadRoot,
 		}
 
 		descriptors = append(descriptors, layerDescriptor)
@@ -32,9 +32,11 @@ func Push(ctx context.Context, ref reference.Named, config *ImagePushConfig) err
 
 	progress.Messagef(config.ProgressOutput, "", "The push refers to repository [%s]", repoInfo.Name.Name())
 
-	associations := config.ReferenceStore.ReferencesByName(repoInfo.Name)
-	if len(associations) == 0 {
-		return fmt.Errorf("An image does not exist locally with the tag: %s", reference.FamiliarName(repoInfo.Name))
+	if len(config.ManifestList) == 0 {
+		associations := config.ReferenceStore.ReferencesByName(repoInfo.Name)
+		if len(associations) == 0 {
+			return fmt.Errorf("An image does not exist locally with the tag: %s", reference.FamiliarName(repoInfo.Name))
+		}
 	}
 
 	var (
@@ -2,6 +2,7 @@ package distribution // import "github.com/docker/docker/distribution"
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -10,7 +11,10 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/containerd/containerd/platforms"
 	"github.com/docker/distribution"
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/ocischema"
 	"github.com/docker/distribution/manifest/schema1"
 	"github.com/docker/distribution/manifest/schema2"
 	"github.com/docker/distribution/reference"
@@ -19,12 +23,15 @@ import (
 	apitypes "github.com/docker/docker/api/types"
 	"github.com/docker/docker/distribution/metadata"
 	"github.com/docker/docker/distribution/xfer"
+	"github.com/docker/docker/image/provenance"
 	"github.com/docker/docker/layer"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/pkg/progress"
 	"github.com/docker/docker/pkg/stringid"
+	refstore "github.com/docker/docker/reference"
 	"github.com/docker/docker/registry"
 	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -92,6 +99,15 @@ func (p *pusher) push(ctx context.Context) (err error) {
 }
 
 func (p *pusher) pushRepository(ctx context.Context) (err error) {
+	if len(p.config.ManifestList) > 0 {
+		namedTagged, isNamedTagged := p.ref.(reference.NamedTagged)
+		if !isNamedTagged {
+			return errors.New("a manifest list can only be pushed to a tag")
+		}
+		_, err := p.pushManifestList(ctx, namedTagged, p.config.ManifestList, p.config.ManifestListMediaType)
+		return err
+	}
+
 	if namedTagged, isNamedTagged := p.ref.(reference.NamedTagged); isNamedTagged {
 		imageID, err := p.config.ReferenceStore.Get(p.ref)
 		if err != nil {
@@ -124,27 +140,124 @@ func (p *pusher) pushRepository(ctx context.Context) (err error) {
 }
 
 func (p *pusher) pushTag(ctx context.Context, ref reference.NamedTagged, id digest.Digest) error {
+	if variants := p.config.ReferenceStore.Platforms(ref); len(variants) > 1 {
+		return p.pushPlatformVariants(ctx, ref, variants)
+	}
+
+	desc, err := p.pushImage(ctx, ref, id, true)
+	if err != nil {
+		return err
+	}
+	p.pushed(ref, desc)
+	return nil
+}
+
+// pushPlatformVariants pushes a manifest list of the platform variants of ref,
+// which is tagged with the tag of ref.
+func (p *pusher) pushPlatformVariants(ctx context.Context, ref reference.NamedTagged, variants []refstore.PlatformAssociation) error {
+	entries := make([]ManifestListEntry, 0, len(variants))
+	for _, v := range variants {
+		entries = append(entries, ManifestListEntry{ID: v.ID, Platform: v.Platform})
+	}
+	desc, err := p.pushManifestList(ctx, ref, entries, "")
+	if err != nil {
+		return err
+	}
+	if id, err := p.config.ReferenceStore.Get(ref); err == nil {
+		return addDigestReference(p.config.ReferenceStore, ref, desc.Digest, id)
+	}
+	return nil
+}
+
+// pushManifestList pushes the images of entries, and a manifest list of them
+// with the given media type that is tagged with the tag of ref. It returns the
+// descriptor of the manifest list.
+func (p *pusher) pushManifestList(ctx context.Context, ref reference.NamedTagged, entries []ManifestListEntry, mediaType string) (distribution.Descriptor, error) {
+	if mediaType == "" {
+		mediaType = manifestlist.MediaTypeManifestList
+	}
+
+	descriptors := make([]manifestlist.ManifestDescriptor, 0, len(entries))
+	for _, e := range entries {
+		desc, err := p.pushImage(ctx, ref, e.ID, false)
+		if err != nil {
+			return distribution.Descriptor{}, err
+		}
+		progress.Messagef(p.config.ProgressOutput, "", "%s: %s digest: %s size: %d", ref.Tag(), platforms.Format(e.Platform), desc.Digest, desc.Size)
+		desc.Annotations = e.Annotations
+		descriptors = append(descriptors, manifestlist.ManifestDescriptor{
+			Descriptor: desc,
+			Platform: manifestlist.PlatformSpec{
+				Architecture: e.Platform.Architecture,
+				OS:           e.Platform.OS,
+				OSVersion:    e.Platform.OSVersion,
+				OSFeatures:   e.Platform.OSFeatures,
+				Variant:      e.Platform.Variant,
+			},
+		})
+	}
+
+	list, err := manifestlist.FromDescriptorsWithMediaType(descriptors, mediaType)
+	if err != nil {
+		return distribution.Descriptor{}, err
+	}
+	manSvc, err := p.repo.Manifests(ctx)
+	if err != nil {
+		return distribution.Descriptor{}, err
+	}
+	if _, err := manSvc.Put(ctx, list, distribution.WithTag(ref.Tag())); err != nil {
+		return distribution.Descriptor{}, err
+	}
+	_, payload, err := list.Payload()
+	if err != nil {
+		return distribution.Descriptor{}, err
+	}
+	desc := distribution.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(payload),
+		Size:      int64(len(payload)),
+	}
+	p.pushed(ref, desc)
+	return desc, nil
+}
+
+// pushed reports that the manifest with the given descriptor was pushed
+// with the tag of ref.
+func (p *pusher) pushed(ref reference.NamedTagged, desc distribution.Descriptor) {
+	progress.Messagef(p.config.ProgressOutput, "", "%s: digest: %s size: %d", ref.Tag(), desc.Digest, desc.Size)
+
+	// Signal digest to the trust client so it can sign the
+	// push, if appropriate.
+	progress.Aux(p.config.ProgressOutput, apitypes.PushResult{Tag: ref.Tag(), Digest: desc.Digest.String(), Size: int(desc.Size)})
+}
+
+// pushImage pushes the image with the given ID, and returns the descriptor of
+// its manifest. The manifest is tagged with the tag of ref if tagged is true,
+// and pushed by digest otherwise. Only a tagged image is referenced by the
+// digest of its manifest in the repository of ref, as images that are pushed
+// by digest as entries of a manifest list may be images of other repositories.
+func (p *pusher) pushImage(ctx context.Context, ref reference.NamedTagged, id digest.Digest, tagged bool) (distribution.Descriptor, error) {
 	logrus.Debugf("Pushing repository: %s", reference.FamiliarString(ref))
 
 	imgConfig, err := p.config.ImageStore.Get(ctx, id)
 	if err != nil {
-		return fmt.Errorf("could not find image from tag %s: %v", reference.FamiliarString(ref), err)
+		return distribution.Descriptor{}, fmt.Errorf("could not find image from tag %s: %v", reference.FamiliarString(ref), err)
 	}
 
 	rootfs, err := rootFSFromConfig(imgConfig)
 	if err != nil {
-		return fmt.Errorf("unable to get rootfs for image %s: %s", reference.FamiliarString(ref), err)
+		return distribution.Descriptor{}, fmt.Errorf("unable to get rootfs for image %s: %s", reference.FamiliarString(ref), err)
 	}
 
 	l, err := p.config.LayerStores.Get(rootfs.ChainID())
 	if err != nil {
-		return fmt.Errorf("failed to get top layer from image: %v", err)
+		return distribution.Descriptor{}, fmt.Errorf("failed to get top layer from image: %v", err)
 	}
 	defer l.Release()
 
 	hmacKey, err := metadata.ComputeV2MetadataHMACKey(p.config.AuthConfig)
 	if err != nil {
-		return fmt.Errorf("failed to compute hmac key of auth config: %v", err)
+		return distribution.Descriptor{}, fmt.Errorf("failed to compute hmac key of auth config: %v", err)
 	}
 
 	var descriptors []xfer.UploadDescriptor
@@ -170,26 +283,30 @@ func (p *pusher) pushTag(ctx context.Context, ref reference.NamedTagged, id dige
 	}
 
 	if err := p.config.UploadManager.Upload(ctx, descriptors, p.config.ProgressOutput); err != nil {
-		return err
+		return distribution.Descriptor{}, err
 	}
 
 	// Try schema2 first
 	builder := schema2.NewManifestBuilder(p.repo.Blobs(ctx), p.config.ConfigMediaType, imgConfig)
 	manifest, err := manifestFromBuilder(ctx, builder, descriptors)
 	if err != nil {
-		return err
+		return distribution.Descriptor{}, err
 	}
 
 	manSvc, err := p.repo.Manifests(ctx)
 	if err != nil {
-		return err
+		return distribution.Descriptor{}, err
 	}
 
-	putOptions := []distribution.ManifestServiceOption{distribution.WithTag(ref.Tag())}
+	var putOptions []distribution.ManifestServiceOption
+	if tagged {
+		putOptions = append(putOptions, distribution.WithTag(ref.Tag()))
+	}
 	if _, err = manSvc.Put(ctx, manifest, putOptions...); err != nil {
-		if runtime.GOOS == "windows" || p.config.TrustKey == nil || p.config.RequireSchema2 {
+		// Schema1 manifests can only be pushed by tag.
+		if runtime.GOOS == "windows" || p.config.TrustKey == nil || p.config.RequireSchema2 || !tagged {
 			logrus.Warnf("failed to upload schema2 manifest: %v", err)
-			return err
+			return distribution.Descriptor{}, err
 		}
 
 		// This is a temporary environment variables used in CI to allow pushing
@@ -199,9 +316,9 @@ func (p *pusher) pushTag(ctx context.Context, ref reference.NamedTagged, id dige
 			if err.Error() == "tag invalid" {
 				msg := "[DEPRECATED] support for pushing manifest v2 schema1 images has been removed. More information at https://docs.docker.com/registry/spec/deprecated-schema-v1/"
 				logrus.WithError(err).Error(msg)
-				return errors.Wrap(err, msg)
+				return distribution.Descriptor{}, errors.Wrap(err, msg)
 			}
-			return err
+			return distribution.Descriptor{}, err
 		}
 
 		logrus.Warnf("failed to upload schema2 manifest: %v - falling back to schema1", err)
@@ -209,16 +326,16 @@ func (p *pusher) pushTag(ctx context.Context, ref reference.NamedTagged, id dige
 		// Note: this fallback is deprecated, see log messages below
 		manifestRef, err := reference.WithTag(p.repo.Named(), ref.Tag())
 		if err != nil {
-			return err
+			return distribution.Descriptor{}, err
 		}
 		builder = schema1.NewConfigManifestBuilder(p.repo.Blobs(ctx), p.config.TrustKey, manifestRef, imgConfig)
 		manifest, err = manifestFromBuilder(ctx, builder, descriptors)
 		if err != nil {
-			return err
+			return distribution.Descriptor{}, err
 		}
 
 		if _, err = manSvc.Put(ctx, manifest, putOptions...); err != nil {
-			return err
+			return distribution.Descriptor{}, err
 		}
 
 		// schema2 failed but schema1 succeeded
@@ -235,22 +352,201 @@ func (p *pusher) pushTag(ctx context.Context, ref reference.NamedTagged, id dige
 	case *schema2.DeserializedManifest:
 		_, canonicalManifest, err = v.Payload()
 		if err != nil {
-			return err
+			return distribution.Descriptor{}, err
 		}
 	}
 
 	manifestDigest := digest.FromBytes(canonicalManifest)
-	progress.Messagef(p.config.ProgressOutput, "", "%s: digest: %s size: %d", ref.Tag(), manifestDigest, len(canonicalManifest))
 
-	if err := addDigestReference(p.config.ReferenceStore, ref, manifestDigest, id); err != nil {
+	if tagged {
+		if err := addDigestReference(p.config.ReferenceStore, ref, manifestDigest, id); err != nil {
+			return distribution.Descriptor{}, err
+		}
+	}
+
+	if _, ok := manifest.(*schema2.DeserializedManifest); ok {
+		subject := distribution.Descriptor{
+			MediaType: schema2.MediaTypeManifest,
+			Digest:    manifestDigest,
+			Size:      int64(len(canonicalManifest)),
+		}
+		if err := p.pushProvenance(ctx, manSvc, subject, id); err != nil {
+			// The image itself was pushed, so don't fail the push if the
+			// registry does not accept the provenance.
+			logrus.WithError(err).Warnf("failed to push provenance of %s", reference.FamiliarString(ref))
+			progress.Messagef(p.config.ProgressOutput, "", "%s: failed to push provenance: %v", ref.Tag(), err)
+		}
+	}
+
+	mediaType, _, err := manifest.Payload()
+	if err != nil {
+		return distribution.Descriptor{}, err
+	}
+	return distribution.Descriptor{
+		MediaType: mediaType,
+		Digest:    manifestDigest,
+		Size:      int64(len(canonicalManifest)),
+	}, nil
+}
+
+// emptyConfig is the config of OCI artifacts that have no config.
+var emptyConfig = []byte("{}")
+
+// mediaTypeEmptyJSON is the media type of emptyConfig.
+const mediaTypeEmptyJSON = "application/vnd.oci.empty.v1+json"
+
+// artifactManifest is an OCI image manifest for an artifact that refers to
+// another manifest. ocischema.Manifest has no subject field, so the manifest
+// is encoded with this type and then decoded as an ocischema manifest, which
+// retains the original JSON.
+type artifactManifest struct {
+	SchemaVersion int                       `json:"schemaVersion"`
+	MediaType     string                    `json:"mediaType"`
+	ArtifactType  string                    `json:"artifactType"`
+	Config        distribution.Descriptor   `json:"config"`
+	Layers        []distribution.Descriptor `json:"layers"`
+	Subject       distribution.Descriptor   `json:"subject"`
+	Annotations   map[string]string         `json:"annotations,omitempty"`
+}
+
+// pushProvenance pushes the provenance recorded for the image with the given
+// ID as an OCI artifact that refers to the subject manifest. Registries that
+// don't support the referrers API find it through the referrers tag schema.
+func (p *pusher) pushProvenance(ctx context.Context, manSvc distribution.ManifestService, subject distribution.Descriptor, id digest.Digest) error {
+	store, ok := p.config.ImageStore.(ImageProvenanceStore)
+	if !ok {
+		return nil
+	}
+	dt, err := store.GetProvenance(ctx, id)
+	if err != nil || len(dt) == 0 {
 		return err
 	}
 
-	// Signal digest to the trust client so it can sign the
-	// push, if appropriate.
-	progress.Aux(p.config.ProgressOutput, apitypes.PushResult{Tag: ref.Tag(), Digest: manifestDigest.String(), Size: len(canonicalManifest)})
+	blobs := p.repo.Blobs(ctx)
+	layer, err := blobs.Put(ctx, provenance.MediaType, dt)
+	if err != nil {
+		return err
+	}
+	layer.MediaType = provenance.MediaType
+	config, err := blobs.Put(ctx, mediaTypeEmptyJSON, emptyConfig)
+	if err != nil {
+		return err
+	}
+	config.MediaType = mediaTypeEmptyJSON
+
+	mfst, err := json.Marshal(artifactManifest{
+		SchemaVersion: 2,
+		MediaType:     specs.MediaTypeImageManifest,
+		ArtifactType:  provenance.MediaType,
+		Config:        config,
+		Layers:        []distribution.Descriptor{layer},
+		Subject:       subject,
+		Annotations:   map[string]string{"in-toto.io/predicate-type": provenance.PredicateType},
+	})
+	if err != nil {
+		return err
+	}
+	var artifact ocischema.DeserializedManifest
+	if err := artifact.UnmarshalJSON(mfst); err != nil {
+		return err
+	}
+	artifactDigest, err := manSvc.Put(ctx, &artifact)
+	if err != nil {
+		return err
+	}
 
-	return nil
+	// The referrers tag schema is only used if the registry does not
+	// support the referrers API.
+	if r, ok := p.repo.(*referrersRepository); ok {
+		supported, err := r.supportsReferrers(ctx, subject.Digest)
+		if err != nil || supported {
+			return err
+		}
+	}
+
+	tag := referrersTag(subject.Digest)
+	existing, err := manSvc.Get(ctx, "", distribution.WithTag(tag))
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	index, err := referrersIndex(existing, referrerDescriptor{
+		MediaType:    specs.MediaTypeImageManifest,
+		ArtifactType: provenance.MediaType,
+		Digest:       artifactDigest,
+		Size:         int64(len(mfst)),
+		Annotations:  map[string]string{"in-toto.io/predicate-type": provenance.PredicateType},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = manSvc.Put(ctx, index, distribution.WithTag(tag))
+	return err
+}
+
+// referrerDescriptor is the descriptor of a referrer in a referrers index.
+// Unlike the descriptors of manifest lists, it has no platform.
+type referrerDescriptor struct {
+	MediaType    string            `json:"mediaType"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Digest       digest.Digest     `json:"digest"`
+	Size         int64             `json:"size"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+// referrersIndexManifest is a referrers index. The descriptors are kept as
+// is, so that the descriptors that were added by other clients are retained.
+type referrersIndexManifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	Manifests     []json.RawMessage `json:"manifests"`
+}
+
+// referrersTag returns the tag of the index that lists the referrers of the
+// manifest with the given digest, as defined by the referrers tag schema of
+// the OCI distribution spec.
+func referrersTag(dgst digest.Digest) string {
+	return dgst.Algorithm().String() + "-" + dgst.Encoded()
+}
+
+// referrersIndex returns the referrers index that results from adding desc
+// to the existing index, which may be nil.
+func referrersIndex(existing distribution.Manifest, desc referrerDescriptor) (distribution.Manifest, error) {
+	index := referrersIndexManifest{SchemaVersion: 2, MediaType: specs.MediaTypeImageIndex}
+	if existing != nil {
+		mediaType, payload, err := existing.Payload()
+		if err != nil {
+			return nil, err
+		}
+		if mediaType != specs.MediaTypeImageIndex {
+			return nil, errors.Errorf("unexpected referrers index type %s", mediaType)
+		}
+		if err := json.Unmarshal(payload, &index); err != nil {
+			return nil, err
+		}
+		for _, raw := range index.Manifests {
+			var d referrerDescriptor
+			if err := json.Unmarshal(raw, &d); err != nil {
+				return nil, err
+			}
+			if d.Digest == desc.Digest {
+				return existing, nil
+			}
+		}
+	}
+	raw, err := json.Marshal(desc)
+	if err != nil {
+		return nil, err
+	}
+	index.Manifests = append(index.Manifests, raw)
+	dt, err := json.Marshal(index)
+	if err != nil {
+		return nil, err
+	}
+	var list manifestlist.DeserializedManifestList
+	if err := list.UnmarshalJSON(dt); err != nil {
+		return nil, err
+	}
+	return &list, nil
 }
 
 func manifestFromBuilder(ctx context.Context, builder distribution.ManifestBuilder, descriptors []xfer.UploadDescriptor) (distribution.Manifest, error) {
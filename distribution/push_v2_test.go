@@ -5,9 +5,11 @@ import (
 	"net/http"
 	"net/url"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/docker/distribution"
+	"github.com/docker/distribution/manifest/manifestlist"
 	"github.com/docker/distribution/manifest/schema2"
 	"github.com/docker/distribution/reference"
 	"github.com/docker/distribution/registry/api/errcode"
@@ -18,6 +20,7 @@ import (
 	refstore "github.com/docker/docker/reference"
 	"github.com/docker/docker/registry"
 	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 func TestGetRepositoryMountCandidates(t *testing.T) {
@@ -466,6 +469,53 @@ func TestLayerAlreadyExists(t *testing.T) {
 	}
 }
 
+func TestReferrersIndex(t *testing.T) {
+	first := referrerDescriptor{MediaType: "application/vnd.oci.image.manifest.v1+json", Digest: digest.FromString("first"), Size: 1}
+	second := referrerDescriptor{MediaType: "application/vnd.oci.image.manifest.v1+json", Digest: digest.FromString("second"), Size: 2}
+
+	index, err := referrersIndex(nil, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err = referrersIndex(index, second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err = referrersIndex(index, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mediaType, payload, err := index.Payload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mediaType != "application/vnd.oci.image.index.v1+json" {
+		t.Errorf("unexpected media type %s", mediaType)
+	}
+	if strings.Contains(string(payload), "platform") {
+		t.Errorf("unexpected platform in referrers index %s", payload)
+	}
+	var digests []digest.Digest
+	for _, d := range index.(*manifestlist.DeserializedManifestList).Manifests {
+		digests = append(digests, d.Digest)
+	}
+	if expected := []digest.Digest{first.Digest, second.Digest}; !reflect.DeepEqual(digests, expected) {
+		t.Errorf("expected referrers %v, got %v", expected, digests)
+	}
+
+	if _, err := referrersIndex(&schema2.DeserializedManifest{}, first); err == nil {
+		t.Error("expected an error for a referrers tag that is not an index")
+	}
+}
+
+func TestReferrersTag(t *testing.T) {
+	dgst := digest.FromString("manifest")
+	if tag := referrersTag(dgst); tag != "sha256-"+dgst.Encoded() {
+		t.Errorf("unexpected referrers tag %s", tag)
+	}
+}
+
 type mockReferenceStore struct {
 }
 
@@ -481,9 +531,18 @@ func (s *mockReferenceStore) AddTag(ref reference.Named, id digest.Digest, force
 func (s *mockReferenceStore) AddDigest(ref reference.Canonical, id digest.Digest, force bool) error {
 	return nil
 }
+func (s *mockReferenceStore) AddPlatform(ref reference.Named, platform specs.Platform, id digest.Digest) error {
+	return nil
+}
+func (s *mockReferenceStore) Platforms(ref reference.Named) []refstore.PlatformAssociation {
+	return nil
+}
 func (s *mockReferenceStore) Delete(ref reference.Named) (bool, error) {
 	return true, nil
 }
+func (s *mockReferenceStore) DeletePlatform(ref reference.Named, id digest.Digest) (bool, error) {
+	return true, nil
+}
 func (s *mockReferenceStore) Get(ref reference.Named) (digest.Digest, error) {
 	return "", nil
 }
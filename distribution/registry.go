@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/docker/distribution"
@@ -16,6 +17,7 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/dockerversion"
 	"github.com/docker/docker/registry"
+	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
@@ -108,13 +110,18 @@ func newRepository(
 		}
 
 		creds := registry.NewStaticCredentialStore(authConfig)
-		tokenHandlerOptions := auth.TokenHandlerOptions{
-			Transport:   authTransport,
-			Credentials: creds,
-			Scopes:      []auth.Scope{scope},
-			ClientID:    registry.AuthClientID,
-		}
-		tokenHandler := auth.NewTokenHandlerWithOptions(tokenHandlerOptions)
+		tokenHandler := tokenHandlers.get(tokenHandlerKey(endpoint.URL, scope, authConfig), func() auth.AuthenticationHandler {
+			// The token handler is cached and used by the operations of other
+			// clients, so it must not use the transport of this operation,
+			// which sends the headers of this client.
+			tokenTransport := transport.NewTransport(base.Clone(), registry.Headers(dockerversion.DockerUserAgent(context.Background()), nil)...)
+			return auth.NewTokenHandlerWithOptions(auth.TokenHandlerOptions{
+				Transport:   tokenTransport,
+				Credentials: creds,
+				Scopes:      []auth.Scope{scope},
+				ClientID:    registry.AuthClientID,
+			})
+		})
 		basicHandler := auth.NewBasicHandler(creds)
 		modifiers = append(modifiers, auth.NewAuthorizer(challengeManager, tokenHandler, basicHandler))
 	}
@@ -130,12 +137,48 @@ func newRepository(
 
 	repo, err = client.NewRepository(repoNameRef, endpoint.URL.String(), tr)
 	if err != nil {
-		err = fallbackError{
+		return nil, fallbackError{
 			err:         err,
 			transportOK: true,
 		}
 	}
-	return
+	return &referrersRepository{
+		Repository: repo,
+		client:     &http.Client{Transport: tr},
+		baseURL:    strings.TrimSuffix(endpoint.URL.String(), "/") + "/v2/" + repoName,
+	}, nil
+}
+
+// referrersRepository is a repository that can query the referrers API of the
+// OCI distribution spec, which the distribution client does not support.
+type referrersRepository struct {
+	distribution.Repository
+	client  *http.Client
+	baseURL string
+}
+
+// supportsReferrers returns whether the registry supports the referrers API,
+// by listing the referrers of the manifest with the given digest. Registries
+// that don't support it respond with 404 Not Found.
+func (r *referrersRepository) supportsReferrers(ctx context.Context, dgst digest.Digest) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.baseURL+"/referrers/"+dgst.String(), nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", ocispec.MediaTypeImageIndex)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case client.SuccessStatus(resp.StatusCode):
+		return true, nil
+	default:
+		return false, client.HandleErrorResponse(resp)
+	}
 }
 
 type existingTokenHandler struct {
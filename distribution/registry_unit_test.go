@@ -12,6 +12,7 @@ import (
 	"github.com/docker/docker/api/types"
 	registrytypes "github.com/docker/docker/api/types/registry"
 	"github.com/docker/docker/registry"
+	"github.com/opencontainers/go-digest"
 	"github.com/sirupsen/logrus"
 )
 
@@ -120,3 +121,81 @@ func TestTokenPassThruDifferentHost(t *testing.T) {
 		t.Fatal("Redirect should not forward Authorization header to another host")
 	}
 }
+
+func TestSupportsReferrers(t *testing.T) {
+	dgst := digest.FromString("manifest")
+	for _, tc := range []struct {
+		status    int
+		supported bool
+		err       bool
+	}{
+		{status: http.StatusOK, supported: true},
+		{status: http.StatusNotFound, supported: false},
+		{status: http.StatusInternalServerError, err: true},
+	} {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v2/library/busybox/referrers/"+dgst.String() {
+				t.Errorf("unexpected path %s", r.URL.Path)
+			}
+			w.WriteHeader(tc.status)
+		}))
+		r := &referrersRepository{client: ts.Client(), baseURL: ts.URL + "/v2/library/busybox"}
+		supported, err := r.supportsReferrers(context.Background(), dgst)
+		ts.Close()
+		if (err != nil) != tc.err {
+			t.Errorf("status %d: unexpected error %v", tc.status, err)
+		}
+		if supported != tc.supported {
+			t.Errorf("status %d: expected supported %v, got %v", tc.status, tc.supported, supported)
+		}
+	}
+}
+
+func TestCachedTokenHandlerTransport(t *testing.T) {
+	var tokenRequests []http.Header
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			tokenRequests = append(tokenRequests, r.Header.Clone())
+			_, _ = w.Write([]byte(`{"token":"sometoken","expires_in":300}`))
+		case r.Header.Get("Authorization") == "":
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+ts.URL+`/token",service="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		case strings.HasSuffix(r.URL.Path, "/tags/list"):
+			_, _ = w.Write([]byte(`{"name":"testcachedtoken","tags":[]}`))
+		}
+	}))
+	defer ts.Close()
+
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint := registry.APIEndpoint{URL: uri, Version: 2}
+	n, _ := reference.ParseNormalizedNamed("testcachedtoken")
+	repoInfo := &registry.RepositoryInfo{Name: n, Index: &registrytypes.IndexInfo{Name: "testrepo"}}
+	authConfig := &types.AuthConfig{Username: "user", Password: "pass"}
+
+	for _, client := range []string{"first", "second"} {
+		repo, err := newRepository(context.Background(), repoInfo, endpoint, http.Header{"X-Meta-Client": {client}}, authConfig, "pull")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.Manifests(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.Tags(context.Background()).All(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The token of the first operation is reused by the second one, and
+	// the token request does not send the headers of either client.
+	if len(tokenRequests) != 1 {
+		t.Fatalf("expected 1 token request, got %d", len(tokenRequests))
+	}
+	if client := tokenRequests[0].Get("X-Meta-Client"); client != "" {
+		t.Errorf("unexpected client header %q in token request", client)
+	}
+}
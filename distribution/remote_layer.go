@@ -0,0 +1,98 @@
+package distribution // import "github.com/docker/docker/distribution"
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/containerd/stargz-snapshotter/estargz"
+	"github.com/docker/docker/distribution/xfer"
+	"github.com/docker/docker/layer"
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+)
+
+// additionalLayerStore is a xfer.RemoteLayerSource for eStargz layers, which
+// are served by a lazily-fetching filesystem, such as stargz-store, that
+// is mounted at root. Layers are looked up using the "additional layer store"
+// layout:
+//
+//	<root>/<base64 of the image reference>/<layer digest>/diff
+//	<root>/<base64 of the image reference>/<layer digest>/info
+//	<root>/<base64 of the image reference>/<layer digest>/blob
+//
+// Accessing the layer's directory causes the filesystem to resolve the image
+// reference and mount the layer, which is fetched on demand from the
+// registry.
+type additionalLayerStore struct {
+	root string
+}
+
+// NewAdditionalLayerStore returns a xfer.RemoteLayerSource, which lazily pulls
+// eStargz layers from the additional layer store filesystem mounted at root.
+func NewAdditionalLayerStore(root string) xfer.RemoteLayerSource {
+	return &additionalLayerStore{root: root}
+}
+
+// additionalLayerInfo is the layer information provided by the "info" file of
+// an additional layer store.
+type additionalLayerInfo struct {
+	CompressedDigest   digest.Digest `json:"compressed-diff-digest,omitempty"`
+	CompressedSize     int64         `json:"compressed-size,omitempty"`
+	UncompressedDigest digest.Digest `json:"diff-digest,omitempty"`
+	UncompressedSize   int64         `json:"diff-size,omitempty"`
+}
+
+func (s *additionalLayerStore) RemoteLayer(ctx context.Context, descriptor xfer.DownloadDescriptor) (layer.RemoteLayer, bool) {
+	ld, ok := descriptor.(*layerDescriptor)
+	if !ok || ld.ref == nil {
+		return layer.RemoteLayer{}, false
+	}
+	if _, ok := ld.src.Annotations[estargz.TOCJSONDigestAnnotation]; !ok {
+		// not an eStargz layer
+		return layer.RemoteLayer{}, false
+	}
+
+	dir := filepath.Join(s.root, base64.StdEncoding.EncodeToString([]byte(ld.ref.String())), ld.digest.String())
+	info, err := readAdditionalLayerInfo(filepath.Join(dir, "info"))
+	if err != nil {
+		logrus.WithError(err).Debugf("layer %s is not available for lazy pulling", ld.digest)
+		return layer.RemoteLayer{}, false
+	}
+	if info.CompressedDigest != "" && info.CompressedDigest != ld.digest {
+		logrus.Warnf("layer %s provided by additional layer store has unexpected digest %s", ld.digest, info.CompressedDigest)
+		return layer.RemoteLayer{}, false
+	}
+	if err := info.UncompressedDigest.Validate(); err != nil {
+		logrus.WithError(err).Warnf("layer %s provided by additional layer store has invalid diff ID", ld.digest)
+		return layer.RemoteLayer{}, false
+	}
+	if ld.diffID != "" && ld.diffID != layer.DiffID(info.UncompressedDigest) {
+		logrus.Warnf("layer %s provided by additional layer store has unexpected diff ID %s", ld.digest, info.UncompressedDigest)
+		return layer.RemoteLayer{}, false
+	}
+
+	return layer.RemoteLayer{
+		DiffID:     layer.DiffID(info.UncompressedDigest),
+		Size:       info.UncompressedSize,
+		DiffPath:   filepath.Join(dir, "diff"),
+		BlobPath:   filepath.Join(dir, "blob"),
+		Descriptor: ld.src,
+	}, true
+}
+
+func readAdditionalLayerInfo(path string) (*additionalLayerInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var info additionalLayerInfo
+	if err := json.NewDecoder(f).Decode(&info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
@@ -0,0 +1,68 @@
+package distribution // import "github.com/docker/docker/distribution"
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/stargz-snapshotter/estargz"
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/layer"
+	"github.com/opencontainers/go-digest"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestAdditionalLayerStore(t *testing.T) {
+	root := t.TempDir()
+
+	named, err := reference.ParseNormalizedNamed("example.com/lazy/image")
+	assert.NilError(t, err)
+	ref, err := reference.WithDigest(named, digest.FromString("manifest"))
+	assert.NilError(t, err)
+
+	layerDigest := digest.FromString("estargz layer")
+	diffID := digest.FromString("uncompressed estargz layer")
+
+	dir := filepath.Join(root, base64.StdEncoding.EncodeToString([]byte(ref.String())), layerDigest.String())
+	assert.NilError(t, os.MkdirAll(filepath.Join(dir, "diff"), 0755))
+	info := `{"compressed-diff-digest":"` + layerDigest.String() + `","diff-digest":"` + diffID.String() + `","diff-size":1234}`
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "info"), []byte(info), 0644))
+
+	estargzDescriptor := distribution.Descriptor{
+		Digest: layerDigest,
+		Annotations: map[string]string{
+			estargz.TOCJSONDigestAnnotation: digest.FromString("toc").String(),
+		},
+	}
+
+	s := NewAdditionalLayerStore(root)
+
+	t.Run("estargz layer", func(t *testing.T) {
+		remote, ok := s.RemoteLayer(context.Background(), &layerDescriptor{digest: layerDigest, ref: ref, src: estargzDescriptor})
+		assert.Assert(t, ok)
+		assert.Check(t, is.Equal(remote.DiffID, layer.DiffID(diffID)))
+		assert.Check(t, is.Equal(remote.Size, int64(1234)))
+		assert.Check(t, is.Equal(remote.DiffPath, filepath.Join(dir, "diff")))
+		assert.Check(t, is.Equal(remote.BlobPath, filepath.Join(dir, "blob")))
+	})
+
+	t.Run("ordinary layer", func(t *testing.T) {
+		_, ok := s.RemoteLayer(context.Background(), &layerDescriptor{digest: layerDigest, ref: ref, src: distribution.Descriptor{Digest: layerDigest}})
+		assert.Check(t, !ok)
+	})
+
+	t.Run("layer not in store", func(t *testing.T) {
+		otherDigest := digest.FromString("other layer")
+		_, ok := s.RemoteLayer(context.Background(), &layerDescriptor{digest: otherDigest, ref: ref, src: estargzDescriptor})
+		assert.Check(t, !ok)
+	})
+
+	t.Run("diff ID mismatch", func(t *testing.T) {
+		_, ok := s.RemoteLayer(context.Background(), &layerDescriptor{digest: layerDigest, diffID: layer.DiffID(digest.FromString("other")), ref: ref, src: estargzDescriptor})
+		assert.Check(t, !ok)
+	})
+}
@@ -23,6 +23,7 @@ func GetRepository(ctx context.Context, ref reference.Named, config *ImagePullCo
 	if err != nil {
 		return nil, err
 	}
+	resolveAuthConfig(ctx, &config.Config, repoInfo)
 
 	for _, endpoint := range endpoints {
 		repository, lastError = newRepository(ctx, repoInfo, endpoint, nil, config.AuthConfig, "pull")
@@ -26,6 +26,7 @@ type LayerDownloadManager struct {
 	tm                  *transferManager
 	waitDuration        time.Duration
 	maxDownloadAttempts int
+	remoteLayerSource   RemoteLayerSource
 }
 
 // SetConcurrency sets the max concurrent downloads for each pull
@@ -58,6 +59,25 @@ func WithMaxDownloadAttempts(max int) DownloadOption {
 	}
 }
 
+// WithRemoteLayerSource configures the download manager to lazily pull
+// layers that are available from src, instead of downloading and extracting
+// them. Layers that are not available from src are downloaded in full. Lazy
+// pulling requires a layer store that implements layer.RemoteStore.
+func WithRemoteLayerSource(src RemoteLayerSource) DownloadOption {
+	return func(dlm *LayerDownloadManager) {
+		dlm.remoteLayerSource = src
+	}
+}
+
+// RemoteLayerSource provides layers with their content served by a remote
+// filesystem, which fetches file contents on demand.
+type RemoteLayerSource interface {
+	// RemoteLayer returns the remote layer for the given descriptor. It
+	// returns false if the layer cannot be pulled lazily, in which case it
+	// is downloaded in full.
+	RemoteLayer(ctx context.Context, descriptor DownloadDescriptor) (layer.RemoteLayer, bool)
+}
+
 type downloadTransfer struct {
 	transfer
 
@@ -263,6 +283,14 @@ func (ldm *LayerDownloadManager) makeDownloadFunc(descriptor DownloadDescriptor,
 				}
 			}
 
+			defer descriptor.Close()
+
+			if remote, ok := ldm.remoteLayer(d.transfer.context(), descriptor); ok {
+				if d.registerRemote(descriptor, remote, parentLayer, parentDownload, progressOutput) {
+					return
+				}
+			}
+
 			var (
 				downloadReader io.ReadCloser
 				size           int64
@@ -270,8 +298,6 @@ func (ldm *LayerDownloadManager) makeDownloadFunc(descriptor DownloadDescriptor,
 				retries        int
 			)
 
-			defer descriptor.Close()
-
 			for {
 				downloadReader, size, err = descriptor.Download(d.transfer.context(), progressOutput)
 				if err == nil {
@@ -385,6 +411,61 @@ func (ldm *LayerDownloadManager) makeDownloadFunc(descriptor DownloadDescriptor,
 	}
 }
 
+// remoteLayer returns the remote layer for descriptor if lazy pulling is
+// enabled, and the layer is available from the remote layer source.
+func (ldm *LayerDownloadManager) remoteLayer(ctx context.Context, descriptor DownloadDescriptor) (layer.RemoteLayer, bool) {
+	if ldm.remoteLayerSource == nil {
+		return layer.RemoteLayer{}, false
+	}
+	if _, ok := ldm.layerStore.(layer.RemoteStore); !ok {
+		return layer.RemoteLayer{}, false
+	}
+	return ldm.remoteLayerSource.RemoteLayer(ctx, descriptor)
+}
+
+// registerRemote registers a remote layer on top of parentDownload's resulting
+// layer if parentDownload is non-nil, or on top of parentLayer otherwise. It
+// returns false if the remote layer could not be registered, in which case
+// the layer should be downloaded instead.
+func (d *downloadTransfer) registerRemote(descriptor DownloadDescriptor, remote layer.RemoteLayer, parentLayer layer.ChainID, parentDownload *downloadTransfer, progressOutput progress.Output) bool {
+	if parentDownload != nil {
+		select {
+		case <-d.transfer.context().Done():
+			d.err = errors.New("layer registration cancelled")
+			return true
+		case <-parentDownload.done():
+		}
+
+		l, err := parentDownload.result()
+		if err != nil {
+			d.err = err
+			return true
+		}
+		parentLayer = l.ChainID()
+	}
+
+	l, err := d.layerStore.(layer.RemoteStore).RegisterRemote(parentLayer, remote)
+	if err != nil {
+		logrus.Warnf("Failed to register remote layer %s, falling back to download: %v", descriptor.ID(), err)
+		return false
+	}
+	d.layer = l
+
+	progress.Update(progressOutput, descriptor.ID(), "Pull complete (lazy)")
+
+	if withRegistered, ok := descriptor.(DigestRegisterer); ok {
+		withRegistered.Registered(d.layer.DiffID())
+	}
+
+	go func() {
+		<-d.transfer.released()
+		if d.layer != nil {
+			layer.ReleaseAndLog(d.layerStore, d.layer)
+		}
+	}()
+	return true
+}
+
 // makeDownloadFuncFromDownload returns a function that performs the layer
 // registration when the layer data is coming from an existing download. It
 // waits for sourceDownload and parentDownload to complete, and then
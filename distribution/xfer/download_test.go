@@ -422,3 +422,105 @@ func TestMaxDownloadAttempts(t *testing.T) {
 		})
 	}
 }
+
+type mockRemoteLayerStore struct {
+	mockLayerStore
+}
+
+func (ls *mockRemoteLayerStore) RegisterRemote(parentID layer.ChainID, remote layer.RemoteLayer) (layer.Layer, error) {
+	var (
+		parent layer.Layer
+		err    error
+	)
+
+	if parentID != "" {
+		parent, err = ls.Get(parentID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	l := &mockLayer{parent: parent, diffID: remote.DiffID}
+	l.chainID = createChainIDFromParent(parentID, l.diffID)
+
+	ls.layers[l.chainID] = l
+	return l, nil
+}
+
+type mockRemoteLayerSource struct {
+	layers map[string]layer.RemoteLayer
+}
+
+func (s *mockRemoteLayerSource) RemoteLayer(_ context.Context, descriptor DownloadDescriptor) (layer.RemoteLayer, bool) {
+	remote, ok := s.layers[descriptor.ID()]
+	return remote, ok
+}
+
+func TestRemoteLayerDownload(t *testing.T) {
+	layerStore := &mockRemoteLayerStore{mockLayerStore{make(map[layer.ChainID]*mockLayer)}}
+	remoteDiffID := layer.DiffID(digest.FromString("remote"))
+	remoteSource := &mockRemoteLayerSource{layers: map[string]layer.RemoteLayer{
+		"id2": {DiffID: remoteDiffID},
+	}}
+	ldm := NewLayerDownloadManager(layerStore, maxDownloadConcurrency, WithRemoteLayerSource(remoteSource), func(m *LayerDownloadManager) { m.waitDuration = time.Millisecond })
+
+	progressChan := make(chan progress.Progress)
+	progressDone := make(chan struct{})
+	receivedProgress := make(map[string]progress.Progress)
+
+	go func() {
+		for p := range progressChan {
+			receivedProgress[p.ID] = p
+		}
+		close(progressDone)
+	}()
+
+	descriptors := downloadDescriptors(nil)[:3]
+	rootFS, releaseFunc, err := ldm.Download(context.Background(), *image.NewRootFS(), descriptors, progress.ChanOutput(progressChan))
+	assert.NilError(t, err)
+	releaseFunc()
+
+	close(progressChan)
+	<-progressDone
+
+	assert.Equal(t, len(rootFS.DiffIDs), len(descriptors))
+	for i, d := range descriptors {
+		descriptor := d.(*mockDownloadDescriptor)
+		expectedDiffID, expectedAction := descriptor.expectedDiffID, "Pull complete"
+		if _, ok := remoteSource.layers[d.ID()]; ok {
+			expectedDiffID, expectedAction = remoteDiffID, "Pull complete (lazy)"
+		}
+		assert.Equal(t, rootFS.DiffIDs[i], expectedDiffID)
+		assert.Equal(t, descriptor.registeredDiffID, expectedDiffID)
+		assert.Equal(t, receivedProgress[d.ID()].Action, expectedAction)
+	}
+}
+
+func TestRemoteLayerDownloadUnsupportedStore(t *testing.T) {
+	layerStore := &mockLayerStore{make(map[layer.ChainID]*mockLayer)}
+	remoteSource := &mockRemoteLayerSource{layers: map[string]layer.RemoteLayer{
+		"id1": {DiffID: layer.DiffID(digest.FromString("remote"))},
+	}}
+	ldm := NewLayerDownloadManager(layerStore, maxDownloadConcurrency, WithRemoteLayerSource(remoteSource), func(m *LayerDownloadManager) { m.waitDuration = time.Millisecond })
+
+	progressChan := make(chan progress.Progress)
+	progressDone := make(chan struct{})
+
+	go func() {
+		for range progressChan {
+		}
+		close(progressDone)
+	}()
+
+	// The layer store does not support remote layers, so all layers must be
+	// downloaded in full.
+	descriptors := downloadDescriptors(nil)[:1]
+	rootFS, releaseFunc, err := ldm.Download(context.Background(), *image.NewRootFS(), descriptors, progress.ChanOutput(progressChan))
+	assert.NilError(t, err)
+	releaseFunc()
+
+	close(progressChan)
+	<-progressDone
+
+	assert.Equal(t, rootFS.DiffIDs[0], descriptors[0].(*mockDownloadDescriptor).expectedDiffID)
+}
@@ -67,3 +67,9 @@ type ErrDeadline interface {
 type ErrDataLoss interface {
 	DataLoss()
 }
+
+// ErrResourceExhausted signals that the client exceeded a limit, such as a
+// rate limit, and should retry the request later.
+type ErrResourceExhausted interface {
+	ResourceExhausted()
+}
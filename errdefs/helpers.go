@@ -262,6 +262,26 @@ func DataLoss(err error) error {
 	return errDataLoss{err}
 }
 
+type errResourceExhausted struct{ error }
+
+func (errResourceExhausted) ResourceExhausted() {}
+
+func (e errResourceExhausted) Cause() error {
+	return e.error
+}
+
+func (e errResourceExhausted) Unwrap() error {
+	return e.error
+}
+
+// ResourceExhausted is a helper to create an error of the class with the same name from any error type
+func ResourceExhausted(err error) error {
+	if err == nil || IsResourceExhausted(err) {
+		return err
+	}
+	return errResourceExhausted{err}
+}
+
 // FromContext returns the error class from the passed in context
 func FromContext(ctx context.Context) error {
 	e := ctx.Err()
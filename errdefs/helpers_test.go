@@ -187,6 +187,22 @@ func TestDataLoss(t *testing.T) {
 	}
 }
 
+func TestResourceExhausted(t *testing.T) {
+	if IsResourceExhausted(errTest) {
+		t.Fatalf("did not expect resource exhausted error, got %T", errTest)
+	}
+	e := ResourceExhausted(errTest)
+	if !IsResourceExhausted(e) {
+		t.Fatalf("expected resource exhausted error, got %T", e)
+	}
+	if cause := e.(causal).Cause(); cause != errTest {
+		t.Fatalf("causual should be errTest, got: %v", cause)
+	}
+	if !errors.Is(e, errTest) {
+		t.Fatalf("expected resource exhausted error to match errTest")
+	}
+}
+
 func TestUnavailable(t *testing.T) {
 	if IsUnavailable(errTest) {
 		t.Fatalf("did not expect unavaillable error, got %T", errTest)
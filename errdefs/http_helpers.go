@@ -28,6 +28,8 @@ func FromStatusCode(err error, statusCode int) error {
 		err = NotModified(err)
 	case http.StatusNotImplemented:
 		err = NotImplemented(err)
+	case http.StatusTooManyRequests:
+		err = ResourceExhausted(err)
 	case http.StatusInternalServerError:
 		if !IsSystem(err) && !IsUnknown(err) && !IsDataLoss(err) && !IsDeadline(err) && !IsCancelled(err) {
 			err = System(err)
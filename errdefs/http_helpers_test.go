@@ -56,6 +56,11 @@ func TestFromStatusCode(t *testing.T) {
 			status: http.StatusNotImplemented,
 			check:  IsNotImplemented,
 		},
+		{
+			err:    testErr,
+			status: http.StatusTooManyRequests,
+			check:  IsResourceExhausted,
+		},
 		{
 			err:    testErr,
 			status: http.StatusInternalServerError,
@@ -19,6 +19,7 @@ func getImplementer(err error) error {
 		ErrCancelled,
 		ErrDeadline,
 		ErrDataLoss,
+		ErrResourceExhausted,
 		ErrUnknown:
 		return err
 	case causer:
@@ -105,3 +106,9 @@ func IsDataLoss(err error) bool {
 	_, ok := getImplementer(err).(ErrDataLoss)
 	return ok
 }
+
+// IsResourceExhausted returns if the passed in error is an ErrResourceExhausted
+func IsResourceExhausted(err error) bool {
+	_, ok := getImplementer(err).(ErrResourceExhausted)
+	return ok
+}
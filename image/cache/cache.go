@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	containertypes "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/dockerversion"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/layer"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
 // NewLocal returns a local image cache, based on parent chain
@@ -23,11 +25,21 @@ func NewLocal(store image.Store) *LocalImageCache {
 // LocalImageCache is cache based on parent chain.
 type LocalImageCache struct {
 	store image.Store
+	// created, if set, is the creation time of the images that are found in
+	// the cache.
+	created *time.Time
+}
+
+// SetCreated restricts the cache to images that were created at t, such as
+// the SOURCE_DATE_EPOCH of a reproducible build, so that images that were
+// created at other times are not found in the cache.
+func (lic *LocalImageCache) SetCreated(t time.Time) {
+	lic.created = &t
 }
 
 // GetCache returns the image id found in the cache
 func (lic *LocalImageCache) GetCache(imgID string, config *containertypes.Config) (string, error) {
-	return getImageIDAndError(getLocalCachedImage(lic.store, image.ID(imgID), config))
+	return getImageIDAndError(getLocalCachedImage(lic.store, image.ID(imgID), config, lic.created))
 }
 
 // New returns an image cache, based on history objects
@@ -41,8 +53,17 @@ func New(store image.Store) *ImageCache {
 // ImageCache is cache based on history objects. Requires initial set of images.
 type ImageCache struct {
 	sources         []*image.Image
+	fetchers        map[image.ID]func() error
 	store           image.Store
 	localImageCache *LocalImageCache
+	created         *time.Time
+}
+
+// SetCreated restricts the cache to images that were created at t, like
+// LocalImageCache.SetCreated.
+func (ic *ImageCache) SetCreated(t time.Time) {
+	ic.created = &t
+	ic.localImageCache.SetCreated(t)
 }
 
 // Populate adds an image to the cache (to be queried later)
@@ -50,6 +71,45 @@ func (ic *ImageCache) Populate(image *image.Image) {
 	ic.sources = append(ic.sources, image)
 }
 
+// PopulateRemote adds an image that is not available locally to the cache.
+// Only the configuration of the image is needed to find cache matches; fetch
+// is called to make the image available locally before it is first used for
+// a cache hit.
+func (ic *ImageCache) PopulateRemote(img *image.Image, fetch func() error) {
+	if ic.fetchers == nil {
+		ic.fetchers = make(map[image.ID]func() error)
+	}
+	ic.fetchers[img.ID()] = fetch
+	ic.Populate(img)
+}
+
+// fetch makes the target image available locally, if it was added with
+// PopulateRemote.
+func (ic *ImageCache) fetch(target *image.Image) error {
+	fetch, ok := ic.fetchers[target.ID()]
+	if !ok {
+		return nil
+	}
+	if err := fetch(); err != nil {
+		return errors.Wrapf(err, "failed to fetch cache source %v", target.ID())
+	}
+	delete(ic.fetchers, target.ID())
+	return nil
+}
+
+// removeSource removes the cache source target, of which the image could not
+// be fetched, so that it is not fetched again.
+func (ic *ImageCache) removeSource(target *image.Image) {
+	sources := make([]*image.Image, 0, len(ic.sources))
+	for _, s := range ic.sources {
+		if s != target {
+			sources = append(sources, s)
+		}
+	}
+	ic.sources = sources
+	delete(ic.fetchers, target.ID())
+}
+
 // GetCache returns the image id found in the cache
 func (ic *ImageCache) GetCache(parentID string, cfg *containertypes.Config) (string, error) {
 	imgID, err := ic.localImageCache.GetCache(parentID, cfg)
@@ -78,6 +138,17 @@ func (ic *ImageCache) GetCache(parentID string, cfg *containertypes.Config) (str
 		if !isValidParent(target, parent) || !isValidConfig(cfg, target.History[lenHistory]) {
 			continue
 		}
+		if ic.created != nil && !target.History[lenHistory].Created.Equal(*ic.created) {
+			continue
+		}
+
+		if err := ic.fetch(target); err != nil {
+			// The cache source is only an optimization, so the build goes
+			// on without it.
+			logrus.WithError(err).Warn("building without cache source")
+			ic.removeSource(target)
+			continue
+		}
 
 		if len(target.History)-1 == lenHistory { // last
 			if parent != nil {
@@ -213,9 +284,10 @@ func getImageIDAndError(img *image.Image, err error) (string, error) {
 
 // getLocalCachedImage returns the most recent created image that is a child
 // of the image with imgID, that had the same config when it was
-// created. nil is returned if a child cannot be found. An error is
-// returned if the parent image cannot be found.
-func getLocalCachedImage(imageStore image.Store, imgID image.ID, config *containertypes.Config) (*image.Image, error) {
+// created, and that was created at created if it is set. nil is returned if
+// a child cannot be found. An error is returned if the parent image cannot be
+// found.
+func getLocalCachedImage(imageStore image.Store, imgID image.ID, config *containertypes.Config, created *time.Time) (*image.Image, error) {
 	// Loop on the children of the given image and check the config
 	getMatch := func(siblings []image.ID) (*image.Image, error) {
 		var match *image.Image
@@ -225,6 +297,9 @@ func getLocalCachedImage(imageStore image.Store, imgID image.ID, config *contain
 				return nil, fmt.Errorf("unable to find image %q", id)
 			}
 
+			if created != nil && !img.Created.Equal(*created) {
+				continue
+			}
 			if compare(&img.ContainerConfig, config) {
 				// check for the most up to date match
 				if match == nil || match.Created.Before(img.Created) {
@@ -0,0 +1,132 @@
+package cache // import "github.com/docker/docker/image/cache"
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/layer"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+type mockLayerGetReleaser struct{}
+
+func (ls *mockLayerGetReleaser) Get(layer.ChainID) (layer.Layer, error) {
+	return nil, nil
+}
+
+func (ls *mockLayerGetReleaser) Release(layer.Layer) ([]layer.Metadata, error) {
+	return nil, nil
+}
+
+func TestImageCachePopulateRemote(t *testing.T) {
+	fs, err := image.NewFSStoreBackend(t.TempDir())
+	assert.NilError(t, err)
+	store, err := image.NewImageStore(fs, &mockLayerGetReleaser{})
+	assert.NilError(t, err)
+
+	parentID, err := store.Create([]byte(`{"rootfs":{"type":"layers"},"history":[{"created_by":"base","empty_layer":true}]}`))
+	assert.NilError(t, err)
+
+	targetConfig := []byte(`{"rootfs":{"type":"layers"},"history":[{"created_by":"base","empty_layer":true},{"created_by":"step1","empty_layer":true},{"created_by":"step2","empty_layer":true}]}`)
+	target, err := image.NewFromJSON(targetConfig)
+	assert.NilError(t, err)
+
+	var fetched int
+	ic := New(store)
+	ic.PopulateRemote(target, func() error {
+		fetched++
+		_, err := store.Create(targetConfig)
+		return err
+	})
+
+	id, err := ic.GetCache(parentID.String(), &containertypes.Config{Cmd: []string{"other"}})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(id, ""))
+	assert.Check(t, is.Equal(fetched, 0), "expected cache source not to be fetched on a cache miss")
+
+	id, err = ic.GetCache(parentID.String(), &containertypes.Config{Cmd: []string{"step1"}})
+	assert.NilError(t, err)
+	assert.Check(t, id != "")
+	assert.Check(t, is.Equal(fetched, 1))
+
+	id, err = ic.GetCache(id, &containertypes.Config{Cmd: []string{"step2"}})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(id, target.ID().String()))
+	assert.Check(t, is.Equal(fetched, 1), "expected cache source to be fetched only once")
+}
+
+func TestImageCachePopulateRemoteFetchError(t *testing.T) {
+	fs, err := image.NewFSStoreBackend(t.TempDir())
+	assert.NilError(t, err)
+	store, err := image.NewImageStore(fs, &mockLayerGetReleaser{})
+	assert.NilError(t, err)
+
+	target, err := image.NewFromJSON([]byte(`{"rootfs":{"type":"layers"},"history":[{"created_by":"step1","empty_layer":true}]}`))
+	assert.NilError(t, err)
+
+	var fetched int
+	ic := New(store)
+	ic.PopulateRemote(target, func() error {
+		fetched++
+		return errors.New("registry unavailable")
+	})
+
+	// The build goes on without the cache source.
+	id, err := ic.GetCache("", &containertypes.Config{Cmd: []string{"step1"}})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(id, ""))
+	assert.Check(t, is.Equal(fetched, 1))
+
+	id, err = ic.GetCache("", &containertypes.Config{Cmd: []string{"step1"}})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(id, ""))
+	assert.Check(t, is.Equal(fetched, 1), "expected cache source not to be fetched again")
+}
+
+func TestImageCacheSetCreated(t *testing.T) {
+	fs, err := image.NewFSStoreBackend(t.TempDir())
+	assert.NilError(t, err)
+	store, err := image.NewImageStore(fs, &mockLayerGetReleaser{})
+	assert.NilError(t, err)
+
+	parentID, err := store.Create([]byte(`{"rootfs":{"type":"layers"},"history":[{"created_by":"base","empty_layer":true}]}`))
+	assert.NilError(t, err)
+	childID, err := store.Create([]byte(`{"created":"2022-01-01T00:00:00Z","container_config":{"Cmd":["step1"]},"rootfs":{"type":"layers"},"history":[{"created_by":"base","empty_layer":true},{"created":"2022-01-01T00:00:00Z","created_by":"step1","empty_layer":true}]}`))
+	assert.NilError(t, err)
+	assert.NilError(t, store.SetParent(childID, parentID))
+	config := &containertypes.Config{Cmd: []string{"step1"}}
+
+	local := NewLocal(store)
+	id, err := local.GetCache(parentID.String(), config)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(id, childID.String()))
+
+	local.SetCreated(time.Unix(0, 0))
+	id, err = local.GetCache(parentID.String(), config)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(id, ""), "expected image created at another time not to be used as cache")
+
+	local.SetCreated(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	id, err = local.GetCache(parentID.String(), config)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(id, childID.String()))
+
+	// Images of the cache sources are also restricted by their history.
+	child, err := store.Get(childID)
+	assert.NilError(t, err)
+	ic := New(store)
+	ic.Populate(child)
+	ic.SetCreated(time.Unix(0, 0))
+	id, err = ic.GetCache(parentID.String(), config)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(id, ""), "expected image created at another time not to be used as cache")
+
+	ic.SetCreated(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	id, err = ic.GetCache(parentID.String(), config)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(id, childID.String()))
+}
@@ -186,6 +186,9 @@ type ChildConfig struct {
 	DiffID          layer.DiffID
 	ContainerConfig *container.Config
 	Config          *container.Config
+	// Created is the creation time of the image. The current time is used if
+	// it is not set.
+	Created time.Time
 }
 
 // NewChildImage creates a new Image as a child of this image.
@@ -206,6 +209,9 @@ func NewChildImage(img *Image, child ChildConfig, os string) *Image {
 		child.Comment,
 		strings.Join(child.ContainerConfig.Cmd, " "),
 		isEmptyLayer)
+	if !child.Created.IsZero() {
+		imgHistory.Created = child.Created
+	}
 
 	return &Image{
 		V1Image: V1Image{
@@ -272,7 +278,8 @@ type Exporter interface {
 	Save([]string, io.Writer) error
 }
 
-// NewFromJSON creates an Image configuration from json.
+// NewFromJSON creates an Image configuration from json. The ID of the image is
+// the digest of src.
 func NewFromJSON(src []byte) (*Image, error) {
 	img := &Image{}
 
@@ -284,6 +291,7 @@ func NewFromJSON(src []byte) (*Image, error) {
 	}
 
 	img.rawJSON = src
+	img.computedID = ID(digest.FromBytes(src))
 
 	return img, nil
 }
@@ -6,6 +6,7 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/layer"
@@ -146,3 +147,15 @@ func TestNewChildImageFromImageWithRootFS(t *testing.T) {
 	assert.Check(t, !cmp.Equal(parent.RootFS.DiffIDs, newImage.RootFS.DiffIDs),
 		"RootFS should be copied not mutated")
 }
+
+func TestNewChildImageCreated(t *testing.T) {
+	created := time.Unix(1600000000, 0).UTC()
+	newImage := NewChildImage(&Image{}, ChildConfig{
+		ContainerConfig: &container.Config{},
+		Config:          &container.Config{},
+		Created:         created,
+	}, "platform")
+	assert.Check(t, newImage.Created.Equal(created))
+	assert.Assert(t, is.Len(newImage.History, 1))
+	assert.Check(t, newImage.History[0].Created.Equal(created))
+}
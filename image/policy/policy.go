@@ -0,0 +1,194 @@
+// Package policy implements the image signature policy of the daemon, which
+// decides which images may be pulled and run, based on the repository they
+// come from and the signatures of their manifests.
+package policy // import "github.com/docker/docker/image/policy"
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+const (
+	// TypeAccept accepts any image.
+	TypeAccept = "insecureAcceptAnything"
+	// TypeReject rejects all images.
+	TypeReject = "reject"
+	// TypeSignedBy accepts images with a valid signature by one of the keys
+	// of the requirement.
+	TypeSignedBy = "signedBy"
+)
+
+// Requirement is the requirement that images of a repository must satisfy.
+type Requirement struct {
+	Type string `json:"type"`
+	// Keys are the names of the keys in the trust store of which a valid
+	// signature is required, if Type is TypeSignedBy.
+	Keys []string `json:"keys,omitempty"`
+}
+
+// Policy is an image signature policy.
+type Policy struct {
+	// Default is the requirement of repositories that don't match any of
+	// the patterns in Repositories.
+	Default Requirement `json:"default"`
+	// Repositories maps repository patterns to their requirement. A pattern
+	// is either a fully qualified repository name, such as
+	// "docker.io/library/busybox", or a prefix that ends in "/*", such as
+	// "registry.example.com/*". The longest matching pattern applies.
+	Repositories map[string]Requirement `json:"repositories,omitempty"`
+	// TrustStore is the directory that holds the public keys, as PEM files
+	// named "<key>.pub". It defaults to the "trust" directory next to the
+	// policy file.
+	TrustStore string `json:"trustStore,omitempty"`
+
+	keys map[string]crypto.PublicKey
+}
+
+// Load reads the policy at path, and the keys it refers to from its trust
+// store.
+func Load(path string) (*Policy, error) {
+	dt, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p Policy
+	dec := json.NewDecoder(bytes.NewReader(dt))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return nil, errors.Wrapf(err, "invalid image policy %s", path)
+	}
+	if p.TrustStore == "" {
+		p.TrustStore = filepath.Join(filepath.Dir(path), "trust")
+	}
+	if err := p.validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid image policy %s", path)
+	}
+	return &p, nil
+}
+
+func (p *Policy) validate() error {
+	p.keys = make(map[string]crypto.PublicKey)
+	if err := p.validateRequirement(p.Default); err != nil {
+		return errors.Wrap(err, "default")
+	}
+	for pattern, req := range p.Repositories {
+		if err := validatePattern(pattern); err != nil {
+			return err
+		}
+		if err := p.validateRequirement(req); err != nil {
+			return errors.Wrap(err, pattern)
+		}
+	}
+	return nil
+}
+
+func (p *Policy) validateRequirement(req Requirement) error {
+	switch req.Type {
+	case TypeAccept, TypeReject:
+		if len(req.Keys) != 0 {
+			return errors.Errorf("keys are not supported by requirement type %q", req.Type)
+		}
+		return nil
+	case TypeSignedBy:
+		if len(req.Keys) == 0 {
+			return errors.Errorf("requirement type %q requires keys", req.Type)
+		}
+	default:
+		return errors.Errorf("unknown requirement type %q", req.Type)
+	}
+	for _, name := range req.Keys {
+		if _, ok := p.keys[name]; ok {
+			continue
+		}
+		key, err := loadKey(filepath.Join(p.TrustStore, name+".pub"))
+		if err != nil {
+			return errors.Wrapf(err, "failed to load key %q", name)
+		}
+		p.keys[name] = key
+	}
+	return nil
+}
+
+func validatePattern(pattern string) error {
+	name := pattern
+	if strings.HasSuffix(pattern, "/*") {
+		// A prefix pattern must still be a valid (partial) repository
+		// name, so validate it as if it named a repository.
+		name = strings.TrimSuffix(pattern, "*") + "x"
+	}
+	named, err := reference.ParseNormalizedNamed(name)
+	if err != nil || named.Name() != name {
+		return errors.Errorf("invalid repository pattern %q: must be a fully qualified repository name, optionally ending in \"/*\"", pattern)
+	}
+	return nil
+}
+
+func loadKey(path string) (crypto.PublicKey, error) {
+	dt, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(dt)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, errors.Errorf("%s does not contain a PEM encoded public key", path)
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// RequirementFor returns the requirement of the repository of name.
+func (p *Policy) RequirementFor(name reference.Named) Requirement {
+	repo := name.Name()
+	var match string
+	for pattern := range p.Repositories {
+		if matches(pattern, repo) && len(pattern) > len(match) {
+			match = pattern
+		}
+	}
+	if match == "" {
+		return p.Default
+	}
+	return p.Repositories[match]
+}
+
+func matches(pattern, repo string) bool {
+	if prefix := strings.TrimSuffix(pattern, "*"); prefix != pattern {
+		return strings.HasPrefix(repo, prefix)
+	}
+	return pattern == repo
+}
+
+// Verify returns an error if the manifest with the given digest in the
+// repository of name does not satisfy the requirement of that repository,
+// given its signatures.
+func (p *Policy) Verify(name reference.Named, dgst digest.Digest, signatures []Signature) error {
+	req := p.RequirementFor(name)
+	switch req.Type {
+	case TypeAccept:
+		return nil
+	case TypeSignedBy:
+	default:
+		return errors.Errorf("images from %s are rejected by the image policy", reference.FamiliarName(name))
+	}
+	if len(signatures) == 0 {
+		return errors.Errorf("%s@%s is not signed", reference.FamiliarName(name), dgst)
+	}
+	var lastErr error
+	for _, sig := range signatures {
+		for _, key := range req.Keys {
+			if lastErr = sig.verify(p.keys[key], name, dgst); lastErr == nil {
+				return nil
+			}
+		}
+	}
+	return errors.Wrapf(lastErr, "%s@%s has no valid signature", reference.FamiliarName(name), dgst)
+}
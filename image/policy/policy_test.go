@@ -0,0 +1,158 @@
+package policy // import "github.com/docker/docker/image/policy"
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func writeKey(t *testing.T, dir, name string) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	assert.NilError(t, err)
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	assert.NilError(t, os.MkdirAll(dir, 0o755))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, name+".pub"), pemBytes, 0o644))
+	return key
+}
+
+func writePolicy(t *testing.T, dir, policy string) string {
+	t.Helper()
+	path := filepath.Join(dir, "policy.json")
+	assert.NilError(t, os.WriteFile(path, []byte(policy), 0o644))
+	return path
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, repo string, dgst digest.Digest) Signature {
+	t.Helper()
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":%q},"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`, repo, dgst))
+	hash := sha256.Sum256(payload)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	assert.NilError(t, err)
+	return Signature{Payload: payload, Signature: sig}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	testCases := []struct {
+		doc    string
+		policy string
+		err    string
+	}{
+		{
+			doc:    "missing default",
+			policy: `{}`,
+			err:    `unknown requirement type ""`,
+		},
+		{
+			doc:    "unknown field",
+			policy: `{"default":{"type":"reject"},"foo":true}`,
+			err:    `unknown field "foo"`,
+		},
+		{
+			doc:    "signedBy without keys",
+			policy: `{"default":{"type":"signedBy"}}`,
+			err:    `requires keys`,
+		},
+		{
+			doc:    "missing key",
+			policy: `{"default":{"type":"signedBy","keys":["missing"]}}`,
+			err:    `failed to load key "missing"`,
+		},
+		{
+			doc:    "short name pattern",
+			policy: `{"default":{"type":"reject"},"repositories":{"busybox":{"type":"insecureAcceptAnything"}}}`,
+			err:    `invalid repository pattern "busybox"`,
+		},
+		{
+			doc:    "wildcard in the middle",
+			policy: `{"default":{"type":"reject"},"repositories":{"docker.io/*/busybox":{"type":"insecureAcceptAnything"}}}`,
+			err:    `invalid repository pattern "docker.io/*/busybox"`,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.doc, func(t *testing.T) {
+			_, err := Load(writePolicy(t, t.TempDir(), tc.policy))
+			assert.Check(t, is.ErrorContains(err, tc.err))
+		})
+	}
+}
+
+func TestRequirementFor(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, filepath.Join(dir, "trust"), "release")
+	p, err := Load(writePolicy(t, dir, `{
+		"default": {"type": "reject"},
+		"repositories": {
+			"docker.io/library/*": {"type": "insecureAcceptAnything"},
+			"docker.io/library/busybox": {"type": "signedBy", "keys": ["release"]},
+			"registry.example.com/*": {"type": "signedBy", "keys": ["release"]}
+		}
+	}`))
+	assert.NilError(t, err)
+
+	for ref, expected := range map[string]string{
+		"busybox":                          TypeSignedBy,
+		"alpine":                           TypeAccept,
+		"docker.io/library/alpine:3":       TypeAccept,
+		"registry.example.com/team/app":    TypeSignedBy,
+		"registry.example.com.evil.io/app": TypeReject,
+		"someone/app":                      TypeReject,
+	} {
+		named, err := reference.ParseNormalizedNamed(ref)
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(p.RequirementFor(named).Type, expected), ref)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	release := writeKey(t, filepath.Join(dir, "trust"), "release")
+	other := writeKey(t, filepath.Join(dir, "trust"), "other")
+	p, err := Load(writePolicy(t, dir, `{
+		"default": {"type": "insecureAcceptAnything"},
+		"repositories": {
+			"docker.io/library/busybox": {"type": "signedBy", "keys": ["release"]},
+			"docker.io/evil/*": {"type": "reject"}
+		}
+	}`))
+	assert.NilError(t, err)
+
+	busybox, _ := reference.ParseNormalizedNamed("busybox")
+	dgst := digest.FromString("manifest")
+
+	assert.Check(t, p.Verify(busybox, dgst, []Signature{sign(t, release, "index.docker.io/library/busybox", dgst)}))
+	assert.Check(t, p.Verify(busybox, dgst, []Signature{
+		sign(t, other, "docker.io/library/busybox", dgst),
+		sign(t, release, "docker.io/library/busybox", dgst),
+	}))
+
+	assert.Check(t, is.ErrorContains(p.Verify(busybox, dgst, nil), "busybox@"+dgst.String()+" is not signed"))
+	assert.Check(t, is.ErrorContains(p.Verify(busybox, dgst, []Signature{sign(t, other, "docker.io/library/busybox", dgst)}), "invalid signature"))
+	assert.Check(t, is.ErrorContains(p.Verify(busybox, dgst, []Signature{sign(t, release, "docker.io/library/busybox", digest.FromString("other"))}), "signature is for manifest"))
+	assert.Check(t, is.ErrorContains(p.Verify(busybox, dgst, []Signature{sign(t, release, "docker.io/library/alpine", dgst)}), "signature is for repository alpine"))
+
+	tampered := sign(t, release, "docker.io/library/busybox", dgst)
+	tampered.Payload[0] = ' '
+	assert.Check(t, is.ErrorContains(p.Verify(busybox, dgst, []Signature{tampered}), "invalid signature"))
+
+	evil, _ := reference.ParseNormalizedNamed("evil/app")
+	assert.Check(t, is.ErrorContains(p.Verify(evil, dgst, nil), "images from evil/app are rejected by the image policy"))
+
+	alpine, _ := reference.ParseNormalizedNamed("alpine")
+	assert.Check(t, p.Verify(alpine, dgst, nil))
+}
@@ -0,0 +1,166 @@
+package policy // import "github.com/docker/docker/image/policy"
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/manifest/ocischema"
+	"github.com/docker/distribution/manifest/schema2"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/distribution/registry/api/errcode"
+	v2 "github.com/docker/distribution/registry/api/v2"
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+// signatureAnnotation is the annotation of the layers of a cosign signature
+// manifest that holds the base64 encoded signature of the layer.
+const signatureAnnotation = "dev.cosignproject.cosign/signature"
+
+// signatureTypes are the accepted types of signature payloads.
+var signatureTypes = map[string]bool{
+	"cosign container image signature": true,
+	"atomic container signature":       true,
+}
+
+// Signature is a signature of an image manifest in the simple signing
+// format that is used by cosign.
+type Signature struct {
+	// Payload is the signed payload, which identifies the manifest.
+	Payload []byte `json:"payload"`
+	// Signature is the signature of the payload.
+	Signature []byte `json:"signature"`
+}
+
+// payload is the signed payload of a Signature.
+type payload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest digest.Digest `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+// verify returns an error if s is not a valid signature by key of the
+// manifest with the given digest in the repository of name.
+func (s Signature) verify(key crypto.PublicKey, name reference.Named, dgst digest.Digest) error {
+	if err := verifySignature(key, s.Payload, s.Signature); err != nil {
+		return err
+	}
+	var p payload
+	if err := json.Unmarshal(s.Payload, &p); err != nil {
+		return errors.Wrap(err, "invalid signature payload")
+	}
+	if !signatureTypes[p.Critical.Type] {
+		return errors.Errorf("unsupported signature type %q", p.Critical.Type)
+	}
+	if p.Critical.Image.DockerManifestDigest != dgst {
+		return errors.Errorf("signature is for manifest %s", p.Critical.Image.DockerManifestDigest)
+	}
+	signed, err := reference.ParseNormalizedNamed(p.Critical.Identity.DockerReference)
+	if err != nil {
+		return errors.Wrap(err, "invalid signature identity")
+	}
+	if signed.Name() != name.Name() {
+		return errors.Errorf("signature is for repository %s", reference.FamiliarName(signed))
+	}
+	return nil
+}
+
+func verifySignature(key crypto.PublicKey, message, sig []byte) error {
+	hash := sha256.Sum256(message)
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(k, hash[:], sig) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case *rsa.PublicKey:
+		return errors.Wrap(rsa.VerifyPKCS1v15(k, crypto.SHA256, hash[:], sig), "invalid signature")
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, message, sig) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	default:
+		return errors.Errorf("unsupported key type %T", key)
+	}
+}
+
+// signatureTag returns the tag of the cosign signature manifest of the
+// manifest with the given digest.
+func signatureTag(dgst digest.Digest) string {
+	return dgst.Algorithm().String() + "-" + dgst.Encoded() + ".sig"
+}
+
+// Fetch returns the signatures of the manifest with the given digest from
+// repo, which are stored in a manifest tagged according to the cosign
+// signature tag scheme.
+func Fetch(ctx context.Context, repo distribution.Repository, dgst digest.Digest) ([]Signature, error) {
+	ms, err := repo.Manifests(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m, err := ms.Get(ctx, "", distribution.WithTag(signatureTag(dgst)))
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to fetch signatures")
+	}
+
+	var layers []distribution.Descriptor
+	switch v := m.(type) {
+	case *ocischema.DeserializedManifest:
+		layers = v.Layers
+	case *schema2.DeserializedManifest:
+		layers = v.Layers
+	default:
+		return nil, errors.Errorf("unexpected signature manifest type %T", m)
+	}
+
+	var signatures []Signature
+	blobs := repo.Blobs(ctx)
+	for _, l := range layers {
+		encoded, ok := l.Annotations[signatureAnnotation]
+		if !ok {
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid signature in layer %s", l.Digest)
+		}
+		dt, err := blobs.Get(ctx, l.Digest)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to fetch signature payload")
+		}
+		if l.Digest.Validate() != nil || l.Digest.Algorithm().FromBytes(dt) != l.Digest {
+			return nil, errors.Errorf("signature payload does not match digest %s", l.Digest)
+		}
+		signatures = append(signatures, Signature{Payload: dt, Signature: sig})
+	}
+	return signatures, nil
+}
+
+func isNotFound(err error) bool {
+	switch v := err.(type) {
+	case errcode.Errors:
+		return len(v) > 0 && isNotFound(v[0])
+	case errcode.Error:
+		return v.Code == v2.ErrorCodeManifestUnknown
+	case distribution.ErrManifestUnknown:
+		return true
+	}
+	return false
+}
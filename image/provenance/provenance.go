@@ -0,0 +1,134 @@
+// Package provenance defines the provenance documents that are recorded for
+// images built by the daemon. They are in-toto statements with a SLSA
+// provenance predicate.
+package provenance // import "github.com/docker/docker/image/provenance"
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/containerd/containerd/platforms"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/dockerversion"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// StatementType is the type of in-toto statements.
+	StatementType = "https://in-toto.io/Statement/v0.1"
+	// PredicateType is the type of SLSA provenance predicates.
+	PredicateType = "https://slsa.dev/provenance/v0.2"
+	// MediaType is the media type of provenance documents that are pushed to
+	// a registry.
+	MediaType = "application/vnd.in-toto+json"
+
+	// BuildTypeClassic is the build type of images built by the classic builder.
+	BuildTypeClassic = "https://mobyproject.org/buildtypes/dockerfile-classic@v1"
+	// BuildTypeBuildKit is the build type of images built by BuildKit.
+	BuildTypeBuildKit = "https://mobyproject.org/buildkit@v1"
+)
+
+// DigestSet maps digest algorithms to the encoded digest of an artifact.
+type DigestSet map[string]string
+
+// NewDigestSet returns the DigestSet of dgst, or nil if dgst is not a valid
+// digest.
+func NewDigestSet(dgst digest.Digest) DigestSet {
+	if dgst.Validate() != nil {
+		return nil
+	}
+	return DigestSet{dgst.Algorithm().String(): dgst.Encoded()}
+}
+
+// Statement is an in-toto statement about an image.
+type Statement struct {
+	Type          string    `json:"_type"`
+	PredicateType string    `json:"predicateType"`
+	Subject       []Subject `json:"subject"`
+	Predicate     Predicate `json:"predicate"`
+}
+
+// Subject is an artifact that a statement applies to.
+type Subject struct {
+	Name   string    `json:"name"`
+	Digest DigestSet `json:"digest"`
+}
+
+// Predicate is a SLSA provenance predicate.
+type Predicate struct {
+	Builder    Builder    `json:"builder"`
+	BuildType  string     `json:"buildType"`
+	Invocation Invocation `json:"invocation"`
+	Metadata   *Metadata  `json:"metadata,omitempty"`
+	Materials  []Material `json:"materials,omitempty"`
+}
+
+// Builder identifies the entity that executed the build.
+type Builder struct {
+	ID string `json:"id"`
+}
+
+// Invocation describes the event that started the build.
+type Invocation struct {
+	ConfigSource ConfigSource `json:"configSource"`
+	// Parameters are the build options, using the same names as the
+	// attributes of BuildKit frontends, for example "build-arg:FOO".
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// ConfigSource describes where the build definition came from.
+type ConfigSource struct {
+	URI        string    `json:"uri,omitempty"`
+	Digest     DigestSet `json:"digest,omitempty"`
+	EntryPoint string    `json:"entryPoint,omitempty"`
+}
+
+// Metadata is additional information about the build.
+type Metadata struct {
+	BuildStartedOn  *time.Time `json:"buildStartedOn,omitempty"`
+	BuildFinishedOn *time.Time `json:"buildFinishedOn,omitempty"`
+}
+
+// Material is an input of the build.
+type Material struct {
+	URI    string    `json:"uri"`
+	Digest DigestSet `json:"digest,omitempty"`
+}
+
+// New returns a statement about the image with the given ID, built with the
+// given build type by this daemon.
+func New(imageID digest.Digest, buildType string) *Statement {
+	return &Statement{
+		Type:          StatementType,
+		PredicateType: PredicateType,
+		Subject:       []Subject{{Name: "_", Digest: NewDigestSet(imageID)}},
+		Predicate: Predicate{
+			Builder:   Builder{ID: "https://mobyproject.org/dockerd@" + dockerversion.Version},
+			BuildType: buildType,
+		},
+	}
+}
+
+// ImageURI returns the package URL of the image with the given reference,
+// for use as the URI of a material. The reference is returned as is if it
+// cannot be parsed.
+func ImageURI(ref string, platform *specs.Platform) string {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return ref
+	}
+	uri := "pkg:docker/" + reference.FamiliarName(named)
+	switch r := named.(type) {
+	case reference.Canonical:
+		uri += "@" + r.Digest().String()
+	case reference.Tagged:
+		uri += "@" + r.Tag()
+	default:
+		uri += "@latest"
+	}
+	if platform != nil {
+		uri += "?platform=" + url.QueryEscape(platforms.Format(*platform))
+	}
+	return uri
+}
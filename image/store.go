@@ -2,6 +2,7 @@ package image // import "github.com/docker/docker/image"
 
 import (
 	"fmt"
+	"os"
 	"sync"
 	"time"
 
@@ -23,6 +24,12 @@ type Store interface {
 	GetParent(id ID) (ID, error)
 	SetLastUpdated(id ID) error
 	GetLastUpdated(id ID) (time.Time, error)
+	SetPinned(id ID, pinned bool) error
+	IsPinned(id ID) bool
+	SetProvenance(id ID, provenance []byte) error
+	GetProvenance(id ID) ([]byte, error)
+	SetSignatures(id ID, signatures []byte) error
+	GetSignatures(id ID) ([]byte, error)
 	Children(id ID) []ID
 	Map() map[ID]*Image
 	Heads() map[ID]*Image
@@ -290,6 +297,52 @@ func (is *store) GetLastUpdated(id ID) (time.Time, error) {
 	return time.Parse(time.RFC3339Nano, string(bytes))
 }
 
+// SetPinned marks the image ID as pinned, or removes the mark if pinned is
+// false. Pinned images are protected against pruning and non-forced removal.
+func (is *store) SetPinned(id ID, pinned bool) error {
+	if !pinned {
+		return is.fs.DeleteMetadata(id.Digest(), "pinned")
+	}
+	return is.fs.SetMetadata(id.Digest(), "pinned", []byte("true"))
+}
+
+// IsPinned returns whether the image ID is pinned
+func (is *store) IsPinned(id ID) bool {
+	bytes, err := is.fs.GetMetadata(id.Digest(), "pinned")
+	return err == nil && string(bytes) == "true"
+}
+
+// SetProvenance stores the provenance document of the image ID
+func (is *store) SetProvenance(id ID, provenance []byte) error {
+	return is.fs.SetMetadata(id.Digest(), "provenance", provenance)
+}
+
+// GetProvenance returns the provenance document of the image ID, or nil if no
+// provenance was recorded for the image.
+func (is *store) GetProvenance(id ID) ([]byte, error) {
+	provenance, err := is.fs.GetMetadata(id.Digest(), "provenance")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return provenance, err
+}
+
+// SetSignatures stores the verified signatures of the manifests of the image
+// ID
+func (is *store) SetSignatures(id ID, signatures []byte) error {
+	return is.fs.SetMetadata(id.Digest(), "signatures", signatures)
+}
+
+// GetSignatures returns the verified signatures of the manifests of the image
+// ID, or nil if no signatures were recorded for the image.
+func (is *store) GetSignatures(id ID) ([]byte, error) {
+	signatures, err := is.fs.GetMetadata(id.Digest(), "signatures")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return signatures, err
+}
+
 func (is *store) Children(id ID) []ID {
 	is.RLock()
 	defer is.RUnlock()
@@ -174,6 +174,62 @@ func TestGetAndSetLastUpdated(t *testing.T) {
 	assert.Check(t, cmp.Equal(updated.IsZero(), false))
 }
 
+func TestSetAndIsPinned(t *testing.T) {
+	store, cleanup := defaultImageStore(t)
+	defer cleanup()
+
+	id, err := store.Create([]byte(`{"comment": "abc1", "rootfs": {"type": "layers"}}`))
+	assert.NilError(t, err)
+	assert.Check(t, !store.IsPinned(id))
+
+	assert.NilError(t, store.SetPinned(id, true))
+	assert.Check(t, store.IsPinned(id))
+
+	// pinning is idempotent
+	assert.NilError(t, store.SetPinned(id, true))
+	assert.Check(t, store.IsPinned(id))
+
+	assert.NilError(t, store.SetPinned(id, false))
+	assert.Check(t, !store.IsPinned(id))
+
+	// unpinning an image that is not pinned is not an error
+	assert.NilError(t, store.SetPinned(id, false))
+}
+
+func TestSetAndGetProvenance(t *testing.T) {
+	store, cleanup := defaultImageStore(t)
+	defer cleanup()
+
+	id, err := store.Create([]byte(`{"comment": "abc1", "rootfs": {"type": "layers"}}`))
+	assert.NilError(t, err)
+
+	provenance, err := store.GetProvenance(id)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Nil(provenance))
+
+	assert.NilError(t, store.SetProvenance(id, []byte(`{"_type":"statement"}`)))
+	provenance, err = store.GetProvenance(id)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(string(provenance), `{"_type":"statement"}`))
+}
+
+func TestSetAndGetSignatures(t *testing.T) {
+	store, cleanup := defaultImageStore(t)
+	defer cleanup()
+
+	id, err := store.Create([]byte(`{"comment": "abc1", "rootfs": {"type": "layers"}}`))
+	assert.NilError(t, err)
+
+	signatures, err := store.GetSignatures(id)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Nil(signatures))
+
+	assert.NilError(t, store.SetSignatures(id, []byte(`[]`)))
+	signatures, err = store.GetSignatures(id)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(string(signatures), `[]`))
+}
+
 func TestStoreLen(t *testing.T) {
 	store, cleanup := defaultImageStore(t)
 	defer cleanup()
@@ -696,6 +696,56 @@ func TestBuildPlatformInvalid(t *testing.T) {
 	assert.Assert(t, errdefs.IsInvalidParameter(err))
 }
 
+func TestBuildSourceDateEpoch(t *testing.T) {
+	skip.If(t, testEnv.DaemonInfo.OSType == "windows", "FIXME")
+	defer setupTest(t)()
+
+	dockerfile := `FROM busybox
+RUN echo foo > /foo
+ENV FOO=bar`
+
+	ctx := context.Background()
+	source := fakecontext.New(t, "", fakecontext.WithDockerfile(dockerfile))
+	defer source.Close()
+
+	apiclient := testEnv.APIClient()
+	build := func(epoch string, noCache bool) string {
+		t.Helper()
+		resp, err := apiclient.ImageBuild(ctx,
+			source.AsTarReader(t),
+			types.ImageBuildOptions{
+				Remove:      true,
+				ForceRemove: true,
+				NoCache:     noCache,
+				BuildArgs:   map[string]*string{"SOURCE_DATE_EPOCH": &epoch},
+			})
+		assert.NilError(t, err)
+		out := bytes.NewBuffer(nil)
+		_, err = io.Copy(out, resp.Body)
+		resp.Body.Close()
+		assert.NilError(t, err)
+
+		imageIDs, err := getImageIDsFromBuild(out.Bytes())
+		assert.NilError(t, err)
+		assert.Assert(t, is.Len(imageIDs, 1), out.String())
+		return imageIDs[0]
+	}
+
+	// Builds of the same inputs produce the same image, even if they don't
+	// share the cache.
+	id := build("0", true)
+	assert.Check(t, is.Equal(build("0", true), id))
+
+	img, _, err := apiclient.ImageInspectWithRaw(ctx, id)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(img.Created, "1970-01-01T00:00:00Z"))
+	assert.Check(t, is.Equal(img.Container, ""))
+	assert.Check(t, is.Equal(img.ContainerConfig.Hostname, ""))
+
+	// Images created at another time are not used as cache.
+	assert.Check(t, build("1", false) != id)
+}
+
 func writeTarRecord(t *testing.T, w *tar.Writer, fn, contents string) {
 	err := w.WriteHeader(&tar.Header{
 		Name:     fn,
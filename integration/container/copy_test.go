@@ -11,9 +11,11 @@ import (
 	"testing"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/versions"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/integration/internal/container"
 	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/docker/testutil/fakecontext"
 	"gotest.tools/v3/assert"
@@ -207,3 +209,76 @@ func TestCopyFromContainer(t *testing.T) {
 		})
 	}
 }
+
+func TestCopyBetweenContainersSync(t *testing.T) {
+	skip.If(t, testEnv.DaemonInfo.OSType == "windows")
+	skip.If(t, versions.LessThan(testEnv.DaemonAPIVersion(), "1.42"), "requires API v1.42")
+	defer setupTest(t)()
+
+	ctx := context.Background()
+	apiClient := testEnv.APIClient()
+
+	src := container.Create(ctx, t, apiClient)
+	dst := container.Create(ctx, t, apiClient)
+
+	putFiles := func(cid string, files map[string]string) {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		assert.NilError(t, tw.WriteHeader(&tar.Header{Name: "sync/", Typeflag: tar.TypeDir, Mode: 0755}))
+		for name, content := range files {
+			assert.NilError(t, tw.WriteHeader(&tar.Header{Name: "sync/" + name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}))
+			_, err := tw.Write([]byte(content))
+			assert.NilError(t, err)
+		}
+		assert.NilError(t, tw.Close())
+		assert.NilError(t, apiClient.CopyToContainer(ctx, cid, "/", &buf, types.CopyToContainerOptions{}))
+	}
+	putFiles(src, map[string]string{"a": "hello", "b": "world"})
+	putFiles(dst, map[string]string{"b": "old", "c": "extra"})
+
+	err := apiClient.CopyBetweenContainers(ctx, dst, "/sync", src, "/sync", types.CopyBetweenContainersOptions{Sync: true, Delete: true})
+	assert.NilError(t, err)
+
+	srcManifest, err := apiClient.ContainerArchiveManifest(ctx, src, "/sync")
+	assert.NilError(t, err)
+	dstManifest, err := apiClient.ContainerArchiveManifest(ctx, dst, "/sync")
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(dstManifest, 2))
+	for i, e := range dstManifest {
+		assert.Check(t, is.Equal(e.Path, srcManifest[i].Path))
+		assert.Check(t, is.Equal(e.Digest, srcManifest[i].Digest))
+	}
+
+	// Sync local files to the container using its manifest.
+	dir := t.TempDir()
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "a"), []byte("hello"), 0644))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "d"), []byte("new"), 0644))
+
+	entries := make([]archive.ManifestEntry, 0, len(dstManifest))
+	for _, e := range dstManifest {
+		entries = append(entries, archive.ManifestEntry(e))
+	}
+	changes, err := archive.ChangesManifest(dir, entries)
+	assert.NilError(t, err)
+	layer, err := archive.ExportChanges(dir, changes, idtools.IdentityMapping{})
+	assert.NilError(t, err)
+	defer layer.Close()
+	err = apiClient.CopyToContainer(ctx, dst, "/sync", layer, types.CopyToContainerOptions{Sync: true})
+	assert.NilError(t, err)
+
+	dstManifest, err = apiClient.ContainerArchiveManifest(ctx, dst, "/sync")
+	assert.NilError(t, err)
+	var paths []string
+	for _, e := range dstManifest {
+		paths = append(paths, e.Path)
+	}
+	assert.Check(t, is.DeepEqual(paths, []string{"a", "d"}))
+
+	// Replacing a directory with a file requires AllowOverwriteDirWithFile.
+	putFiles(src, map[string]string{"x": "file"})
+	putFiles(dst, map[string]string{"x/y": "dir"})
+	err = apiClient.CopyBetweenContainers(ctx, dst, "/sync", src, "/sync", types.CopyBetweenContainersOptions{Sync: true})
+	assert.Check(t, is.ErrorContains(err, "cannot overwrite directory"))
+	err = apiClient.CopyBetweenContainers(ctx, dst, "/sync", src, "/sync", types.CopyBetweenContainersOptions{Sync: true, AllowOverwriteDirWithFile: true})
+	assert.NilError(t, err)
+}
@@ -2,13 +2,18 @@ package container // import "github.com/docker/docker/integration/container"
 
 import (
 	"context"
+	"io"
 	"testing"
 	"time"
 
+	"github.com/docker/docker/api/types"
 	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/versions"
 	"github.com/docker/docker/integration/internal/container"
 	"github.com/docker/docker/pkg/archive"
+	"github.com/opencontainers/go-digest"
 	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
 	"gotest.tools/v3/poll"
 	"gotest.tools/v3/skip"
 )
@@ -41,3 +46,44 @@ func TestDiff(t *testing.T) {
 	assert.NilError(t, err)
 	assert.DeepEqual(t, expected, items)
 }
+
+func TestDiffDetails(t *testing.T) {
+	skip.If(t, testEnv.OSType == "windows", "detailed diff is not supported on Windows")
+	skip.If(t, versions.LessThan(testEnv.DaemonAPIVersion(), "1.42"), "requires API v1.42")
+	defer setupTest(t)()
+	client := testEnv.APIClient()
+	ctx := context.Background()
+
+	cID := container.Run(ctx, t, client, container.WithCmd("sh", "-c", `mkdir /foo; echo xyzzy > /foo/bar; chown 1000:1000 /etc/passwd`))
+	poll.WaitOn(t, container.IsInState(ctx, client, cID, "exited"), poll.WithDelay(100*time.Millisecond))
+
+	changes, errs := client.ContainerDiffDetails(ctx, cID, types.ContainerDiffOptions{
+		Paths:   []string{"/foo", "/etc/passwd"},
+		Digests: true,
+	})
+	byPath := map[string]containertypes.FilesystemChange{}
+	for done := false; !done; {
+		select {
+		case change := <-changes:
+			byPath[change.Path] = change
+		case err := <-errs:
+			assert.Equal(t, err, io.EOF)
+			done = true
+		}
+	}
+
+	assert.Assert(t, is.Len(byPath, 3))
+
+	bar := byPath["/foo/bar"]
+	assert.Check(t, is.Equal(bar.Kind, uint8(archive.ChangeAdd)))
+	assert.Assert(t, bar.Old == nil)
+	assert.Check(t, is.Equal(bar.New.Size, int64(6)))
+	assert.Check(t, is.Equal(bar.New.Digest, digest.FromString("xyzzy\n")))
+
+	passwd := byPath["/etc/passwd"]
+	assert.Check(t, is.Equal(passwd.Kind, uint8(archive.ChangeModify)))
+	assert.Assert(t, passwd.Old != nil && passwd.New != nil)
+	assert.Check(t, is.Equal(passwd.Old.UID, 0))
+	assert.Check(t, is.Equal(passwd.New.UID, 1000))
+	assert.Check(t, is.Equal(passwd.Old.Digest, passwd.New.Digest))
+}
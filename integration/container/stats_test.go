@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/versions"
 	"github.com/docker/docker/integration/internal/container"
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
@@ -55,3 +57,37 @@ func TestStats(t *testing.T) {
 	err = json.NewDecoder(resp.Body).Decode(&v)
 	assert.Assert(t, is.ErrorContains(err, ""), io.EOF)
 }
+
+func TestStatsAll(t *testing.T) {
+	skip.If(t, testEnv.DaemonInfo.CgroupDriver == "none")
+	skip.If(t, versions.LessThan(testEnv.DaemonAPIVersion(), "1.42"), "requires API v1.42")
+
+	defer setupTest(t)()
+	client := testEnv.APIClient()
+	ctx := context.Background()
+
+	withLabel := func(c *container.TestContainerConfig) {
+		c.Config.Labels = map[string]string{"stats-all": "true"}
+	}
+	cID1 := container.Run(ctx, t, client, withLabel)
+	cID2 := container.Run(ctx, t, client, withLabel)
+	container.Run(ctx, t, client)
+
+	batches, errs := client.ContainerStatsAll(ctx, types.ContainerStatsAllOptions{
+		Filters: filters.NewArgs(filters.Arg("label", "stats-all=true")),
+	})
+	var batch types.StatsBatch
+	select {
+	case batch = <-batches:
+	case err := <-errs:
+		t.Fatal(err)
+	}
+	assert.Check(t, is.Equal(<-errs, io.EOF))
+
+	ids := map[string]bool{}
+	for _, s := range batch.Stats {
+		ids[s.ID] = true
+		assert.Check(t, !s.Read.IsZero())
+	}
+	assert.Check(t, is.DeepEqual(ids, map[string]bool{cID1: true, cID2: true}))
+}
@@ -0,0 +1,123 @@
+package image // import "github.com/docker/docker/integration/image"
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
+	"github.com/docker/docker/api/types"
+	imagetypes "github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/versions"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/testutil/registry"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/skip"
+)
+
+func TestPushManifestList(t *testing.T) {
+	skip.If(t, versions.LessThan(testEnv.DaemonAPIVersion(), "1.42"), "requires API v1.42")
+	skip.If(t, testEnv.IsRemoteDaemon, "cannot run registry when remote daemon")
+	skip.If(t, testEnv.OSType == "windows", "TODO enable on windows")
+	skip.If(t, testEnv.IsRootless, "rootless mode has different view of localhost")
+	defer setupTest(t)()
+
+	reg := registry.NewV2(t)
+	defer reg.Close()
+	reg.WaitReady(t)
+
+	client := testEnv.APIClient()
+	ctx := context.Background()
+	name := strings.ToLower(t.Name())
+
+	// The images differ in their content, so that they have different
+	// manifests.
+	importImage := func(platform string) string {
+		t.Helper()
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		assert.NilError(t, tw.WriteHeader(&tar.Header{Name: "platform", Mode: 0o644, Size: int64(len(platform))}))
+		_, err := tw.Write([]byte(platform))
+		assert.NilError(t, err)
+		assert.NilError(t, tw.Close())
+
+		ref := name + ":" + strings.ReplaceAll(platform, "/", "-")
+		rdr, err := client.ImageImport(ctx, types.ImageImportSource{Source: &buf, SourceName: "-"}, ref, types.ImageImportOptions{Platform: platform})
+		assert.NilError(t, err)
+		_, err = io.Copy(io.Discard, rdr)
+		rdr.Close()
+		assert.NilError(t, err)
+
+		img, _, err := client.ImageInspectWithRaw(ctx, ref)
+		assert.NilError(t, err)
+		return img.ID
+	}
+	amd64 := importImage("linux/amd64")
+	arm64 := importImage("linux/arm64")
+
+	repo := path.Join(registry.DefaultURL, name)
+	rdr, err := client.ImagePushManifestList(ctx, repo+":latest", imagetypes.ManifestList{
+		Manifests: []imagetypes.ManifestListEntry{
+			{Image: amd64},
+			{Image: name + ":linux-arm64", Annotations: map[string]string{"com.example.key": "value"}},
+		},
+	}, types.ImagePushOptions{RegistryAuth: "{}"})
+	assert.NilError(t, err)
+	out := &strings.Builder{}
+	err = jsonmessage.DisplayJSONMessagesStream(rdr, out, 0, false, nil)
+	rdr.Close()
+	assert.NilError(t, err, out.String())
+
+	var list manifestlist.ManifestList
+	getManifest(t, name, "latest", manifestlist.MediaTypeManifestList, &list)
+	assert.Check(t, is.Equal(list.MediaType, manifestlist.MediaTypeManifestList))
+	assert.Assert(t, is.Len(list.Manifests, 2))
+
+	for i, expected := range []struct {
+		id           string
+		architecture string
+		annotations  map[string]string
+	}{
+		{id: amd64, architecture: "amd64"},
+		{id: arm64, architecture: "arm64", annotations: map[string]string{"com.example.key": "value"}},
+	} {
+		entry := list.Manifests[i]
+		assert.Check(t, is.Equal(entry.MediaType, schema2.MediaTypeManifest))
+		assert.Check(t, is.Equal(entry.Platform.OS, "linux"))
+		assert.Check(t, is.Equal(entry.Platform.Architecture, expected.architecture))
+		assert.Check(t, is.DeepEqual(entry.Annotations, expected.annotations))
+
+		// The entries are the manifests of the local images.
+		var m schema2.Manifest
+		getManifest(t, name, entry.Digest.String(), schema2.MediaTypeManifest, &m)
+		assert.Check(t, is.Equal(m.Config.Digest.String(), expected.id))
+
+		// The local images are not referenced by the digests of the entries,
+		// as they are not images of the repository of the manifest list.
+		img, _, err := client.ImageInspectWithRaw(ctx, expected.id)
+		assert.NilError(t, err)
+		assert.Check(t, is.Len(img.RepoDigests, 0))
+	}
+}
+
+// getManifest decodes the manifest with the given tag or digest of the
+// repository with the given name of the test registry into v.
+func getManifest(t *testing.T, name, reference, mediaType string, v interface{}) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, "http://"+registry.DefaultURL+"/v2/"+name+"/manifests/"+reference, nil)
+	assert.NilError(t, err)
+	req.Header.Set("Accept", mediaType)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NilError(t, err)
+	defer resp.Body.Close()
+	assert.Assert(t, is.Equal(resp.StatusCode, http.StatusOK), reference)
+	assert.NilError(t, json.NewDecoder(resp.Body).Decode(v))
+}
@@ -57,3 +57,41 @@ func TestRemoveImageOrphaning(t *testing.T) {
 	_, _, err = client.ImageInspectWithRaw(ctx, commitResp2.ID)
 	assert.Check(t, is.ErrorContains(err, "No such image:"))
 }
+
+func TestRemovePinnedImage(t *testing.T) {
+	defer setupTest(t)()
+	ctx := context.Background()
+	client := testEnv.APIClient()
+
+	imgName := strings.ToLower(t.Name())
+
+	cID := container.Create(ctx, t, client, container.WithCmd(""))
+	commitResp, err := client.ContainerCommit(ctx, cID, types.ContainerCommitOptions{
+		Reference: imgName,
+	})
+	assert.NilError(t, err)
+
+	err = client.ImagePin(ctx, imgName)
+	assert.NilError(t, err)
+
+	resp, _, err := client.ImageInspectWithRaw(ctx, commitResp.ID)
+	assert.NilError(t, err)
+	assert.Check(t, resp.Metadata.Pinned)
+
+	// removing the last reference of a pinned image must be forced
+	_, err = client.ImageRemove(ctx, imgName, types.ImageRemoveOptions{})
+	assert.Check(t, is.ErrorContains(err, "is pinned"))
+
+	_, err = client.ImageRemove(ctx, commitResp.ID, types.ImageRemoveOptions{})
+	assert.Check(t, is.ErrorContains(err, "image is pinned"))
+
+	err = client.ImageUnpin(ctx, imgName)
+	assert.NilError(t, err)
+
+	resp, _, err = client.ImageInspectWithRaw(ctx, commitResp.ID)
+	assert.NilError(t, err)
+	assert.Check(t, !resp.Metadata.Pinned)
+
+	_, err = client.ImageRemove(ctx, imgName, types.ImageRemoveOptions{})
+	assert.NilError(t, err)
+}
@@ -0,0 +1,119 @@
+package layer // import "github.com/docker/docker/layer"
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/opencontainers/go-digest"
+)
+
+// FileDigest holds the content digest of a regular file in a layer.
+type FileDigest struct {
+	// Path is the path of the file within the layer.
+	Path string
+	// Digest is the digest of the file's content.
+	Digest digest.Digest
+	// Size is the size of the file's content.
+	Size int64
+}
+
+// FileDigestStore represents a layer store capable of computing the content
+// digests of the regular files in a layer.
+type FileDigestStore interface {
+	// FileDigests returns the digests of the regular files in the diff of
+	// the layer. Digests are computed once and cached in the layer's
+	// metadata.
+	FileDigests(ChainID) ([]FileDigest, error)
+}
+
+// FileDigests returns the content digests of the regular files in the diff of
+// the layer identified by chainID.
+func (ls *layerStore) FileDigests(chainID ChainID) ([]FileDigest, error) {
+	if digests, err := ls.store.GetFileDigests(chainID); err == nil {
+		return digests, nil
+	}
+
+	l, err := ls.Get(chainID)
+	if err != nil {
+		return nil, err
+	}
+	defer ReleaseAndLog(ls, l)
+
+	rc, err := l.TarStream()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	digests, err := computeFileDigests(rc)
+	if err != nil {
+		return nil, err
+	}
+	if err := ls.store.SetFileDigests(chainID, digests); err != nil {
+		return nil, err
+	}
+	return digests, nil
+}
+
+// computeFileDigests computes the content digests of the regular files in the
+// tar stream. Files that are overwritten later in the stream are only
+// included once.
+func computeFileDigests(r io.Reader) ([]FileDigest, error) {
+	var (
+		digests = []FileDigest{}
+		seen    = map[string]int{}
+		tr      = tar.NewReader(r)
+	)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		digester := digest.Canonical.Digester()
+		size, err := io.Copy(digester.Hash(), tr)
+		if err != nil {
+			return nil, err
+		}
+		fd := FileDigest{Path: hdr.Name, Digest: digester.Digest(), Size: size}
+		if i, ok := seen[hdr.Name]; ok {
+			digests[i] = fd
+			continue
+		}
+		seen[hdr.Name] = len(digests)
+		digests = append(digests, fd)
+	}
+	// Drain any trailing data, so that the layer's tar stream is verified.
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return nil, err
+	}
+	return digests, nil
+}
+
+func (fms *fileMetadataStore) GetFileDigests(layer ChainID) ([]FileDigest, error) {
+	content, err := os.ReadFile(fms.getLayerFilename(layer, "file-digests.json"))
+	if err != nil {
+		return nil, err
+	}
+	var digests []FileDigest
+	if err := json.Unmarshal(content, &digests); err != nil {
+		return nil, err
+	}
+	return digests, nil
+}
+
+func (fms *fileMetadataStore) SetFileDigests(layer ChainID, digests []FileDigest) error {
+	content, err := json.Marshal(digests)
+	if err != nil {
+		return err
+	}
+	return ioutils.AtomicWriteFile(fms.getLayerFilename(layer, "file-digests.json"), content, 0644)
+}
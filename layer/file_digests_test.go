@@ -0,0 +1,59 @@
+package layer // import "github.com/docker/docker/layer"
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestComputeFileDigests(t *testing.T) {
+	tar, err := tarFromFiles(
+		newTestFile("/etc/hosts", []byte("mydomain 10.0.0.1"), 0644),
+		newTestFile("/etc/profile", []byte("PATH=/usr/bin"), 0644),
+		newTestFile("/empty", []byte{}, 0644),
+	)
+	assert.NilError(t, err)
+
+	digests, err := computeFileDigests(bytes.NewReader(tar))
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(digests, []FileDigest{
+		{Path: "empty", Digest: digest.FromString(""), Size: 0},
+		{Path: "etc/hosts", Digest: digest.FromString("mydomain 10.0.0.1"), Size: 17},
+		{Path: "etc/profile", Digest: digest.FromString("PATH=/usr/bin"), Size: 13},
+	}))
+}
+
+func TestFileDigestsCached(t *testing.T) {
+	// TODO Windows: Figure out why this is failing
+	if runtime.GOOS == "windows" {
+		t.Skip("Failing on Windows")
+	}
+	ls, _, cleanup := newTestStore(t)
+	defer cleanup()
+
+	l, err := createLayer(ls, "", initWithFiles(newTestFile("/etc/hosts", []byte("mydomain 10.0.0.1"), 0644)))
+	assert.NilError(t, err)
+
+	fds := ls.(FileDigestStore)
+	digests, err := fds.FileDigests(l.ChainID())
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(digests, []FileDigest{
+		{Path: "etc/hosts", Digest: digest.FromString("mydomain 10.0.0.1"), Size: 17},
+	}))
+
+	cached, err := os.ReadFile(ls.(*layerStore).store.getLayerFilename(l.ChainID(), "file-digests.json"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Contains(string(cached), digest.FromString("mydomain 10.0.0.1").String()))
+
+	digests, err = fds.FileDigests(l.ChainID())
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(digests, 1))
+
+	_, err = fds.FileDigests(ChainID(digest.FromString("missing")))
+	assert.Check(t, is.Equal(err, ErrLayerDoesNotExist))
+}
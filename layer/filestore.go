@@ -106,6 +106,10 @@ func (fm *fileMetadataTransaction) SetDescriptor(ref distribution.Descriptor) er
 	return fm.ws.WriteFile("descriptor.json", jsonRef, 0644)
 }
 
+func (fm *fileMetadataTransaction) SetRemoteBlob(path string) error {
+	return fm.ws.WriteFile("remote-blob", []byte(path), 0644)
+}
+
 func (fm *fileMetadataTransaction) TarSplitWriter(compressInput bool) (io.WriteCloser, error) {
 	f, err := fm.ws.FileWriter("tar-split.json.gz", os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
@@ -218,6 +222,17 @@ func (fms *fileMetadataStore) GetDescriptor(layer ChainID) (distribution.Descrip
 	return ref, err
 }
 
+func (fms *fileMetadataStore) GetRemoteBlob(layer ChainID) (string, error) {
+	content, err := os.ReadFile(fms.getLayerFilename(layer, "remote-blob"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return string(content), nil
+}
+
 func (fms *fileMetadataStore) TarSplitReader(layer ChainID) (io.ReadCloser, error) {
 	fz, err := os.Open(fms.getLayerFilename(layer, "tar-split.json.gz"))
 	if err != nil {
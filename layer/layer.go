@@ -191,6 +191,33 @@ type DescribableStore interface {
 	RegisterWithDescriptor(io.Reader, ChainID, distribution.Descriptor) (Layer, error)
 }
 
+// RemoteLayer describes a layer whose content is provided by a remote
+// filesystem, which fetches file contents on demand, instead of being
+// extracted from a tar stream.
+type RemoteLayer struct {
+	// DiffID is the content hash of the uncompressed layer tar stream.
+	DiffID DiffID
+
+	// Size is the size of the layer content.
+	Size int64
+
+	// DiffPath is the directory holding the layer content.
+	DiffPath string
+
+	// BlobPath is the path of the compressed layer blob, which is used to
+	// produce tar streams of the layer.
+	BlobPath string
+
+	// Descriptor is the descriptor of the layer blob.
+	Descriptor distribution.Descriptor
+}
+
+// RemoteStore represents a layer store capable of registering layers
+// with their content provided by a remote filesystem.
+type RemoteStore interface {
+	RegisterRemote(parent ChainID, remote RemoteLayer) (Layer, error)
+}
+
 // CreateChainID returns ID for a layerDigest slice
 func CreateChainID(dgsts []DiffID) ChainID {
 	return createChainIDFromParent("", dgsts...)
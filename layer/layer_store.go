@@ -10,7 +10,9 @@ import (
 
 	"github.com/docker/distribution"
 	"github.com/docker/docker/daemon/graphdriver"
+	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/pkg/plugingetter"
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/moby/locker"
@@ -154,6 +156,11 @@ func (ls *layerStore) loadLayer(layer ChainID) (*roLayer, error) {
 		return nil, fmt.Errorf("failed to get descriptor for %s: %s", layer, err)
 	}
 
+	remoteBlob, err := ls.store.GetRemoteBlob(layer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get remote blob for %s: %s", layer, err)
+	}
+
 	cl = &roLayer{
 		chainID:    layer,
 		diffID:     diff,
@@ -162,6 +169,7 @@ func (ls *layerStore) loadLayer(layer ChainID) (*roLayer, error) {
 		layerStore: ls,
 		references: map[Layer]struct{}{},
 		descriptor: descriptor,
+		remoteBlob: remoteBlob,
 	}
 
 	if parent != "" {
@@ -265,6 +273,37 @@ func (ls *layerStore) Register(ts io.Reader, parent ChainID) (Layer, error) {
 }
 
 func (ls *layerStore) registerWithDescriptor(ts io.Reader, parent ChainID, descriptor distribution.Descriptor) (Layer, error) {
+	return ls.register(parent, descriptor, func(layer *roLayer, pid string) error {
+		return ls.driver.Create(layer.cacheID, pid, nil)
+	}, func(tx *fileMetadataTransaction, layer *roLayer, pid string) error {
+		return ls.applyTar(tx, ts, pid, layer)
+	})
+}
+
+// RegisterRemote registers a layer with its content provided by a remote
+// filesystem. The graphdriver must support using the remote directory as
+// the layer's content; the layer's tar stream is produced from the remote
+// blob.
+func (ls *layerStore) RegisterRemote(parent ChainID, remote RemoteLayer) (Layer, error) {
+	rd, ok := ls.driver.(graphdriver.RemoteDiffDriver)
+	if !ok {
+		return nil, fmt.Errorf("graphdriver %s does not support remote layers", ls.driver)
+	}
+	return ls.register(parent, remote.Descriptor, func(layer *roLayer, pid string) error {
+		return rd.CreateFromRemote(layer.cacheID, pid, remote.DiffPath)
+	}, func(tx *fileMetadataTransaction, layer *roLayer, pid string) error {
+		layer.diffID = remote.DiffID
+		layer.size = remote.Size
+		layer.remoteBlob = remote.BlobPath
+		logrus.Debugf("Registered remote layer %s from %s to %s, size: %d", layer.diffID, remote.DiffPath, layer.cacheID, layer.size)
+		return nil
+	})
+}
+
+// register creates a new layer on top of parent. The create function creates
+// the layer in the graphdriver, and the apply function populates it, and must
+// set the layer's diffID and size.
+func (ls *layerStore) register(parent ChainID, descriptor distribution.Descriptor, create func(layer *roLayer, pid string) error, apply func(tx *fileMetadataTransaction, layer *roLayer, pid string) error) (Layer, error) {
 	// err is used to hold the error which will always trigger
 	// cleanup of creates sources but may not be an error returned
 	// to the caller (already exists).
@@ -304,7 +343,7 @@ func (ls *layerStore) registerWithDescriptor(ts io.Reader, parent ChainID, descr
 		descriptor:     descriptor,
 	}
 
-	if err = ls.driver.Create(layer.cacheID, pid, nil); err != nil {
+	if err = create(layer, pid); err != nil {
 		return nil, err
 	}
 
@@ -325,7 +364,7 @@ func (ls *layerStore) registerWithDescriptor(ts io.Reader, parent ChainID, descr
 		}
 	}()
 
-	if err = ls.applyTar(tx, ts, pid, layer); err != nil {
+	if err = apply(tx, layer, pid); err != nil {
 		return nil, err
 	}
 
@@ -688,6 +727,22 @@ func (ls *layerStore) initMount(graphID, parent, mountLabel string, initFunc Mou
 }
 
 func (ls *layerStore) getTarStream(rl *roLayer) (io.ReadCloser, error) {
+	if rl.remoteBlob != "" {
+		f, err := os.Open(rl.remoteBlob)
+		if err != nil {
+			return nil, err
+		}
+		rc, err := archive.DecompressStream(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return ioutils.NewReadCloserWrapper(rc, func() error {
+			rc.Close()
+			return f.Close()
+		}), nil
+	}
+
 	if !ls.useTarSplit {
 		var parentCacheID string
 		if rl.parent != nil {
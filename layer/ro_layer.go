@@ -17,6 +17,10 @@ type roLayer struct {
 	layerStore *layerStore
 	descriptor distribution.Descriptor
 
+	// remoteBlob is the path of the compressed blob of a layer with its
+	// content provided by a remote filesystem.
+	remoteBlob string
+
 	referenceCount int
 	references     map[Layer]struct{}
 }
@@ -144,6 +148,11 @@ func storeLayer(tx *fileMetadataTransaction, layer *roLayer) error {
 			return err
 		}
 	}
+	if layer.remoteBlob != "" {
+		if err := tx.SetRemoteBlob(layer.remoteBlob); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
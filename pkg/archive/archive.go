@@ -385,6 +385,53 @@ func ReplaceFileTarWrapper(inputTarStream io.ReadCloser, mods map[string]TarModi
 	return pipeReader
 }
 
+// ClampTimestamps converts inputTarStream to a new tar stream in which the
+// modification, access and change times of entries that are later than t are
+// set to t. Closing the returned stream closes inputTarStream.
+func ClampTimestamps(inputTarStream io.ReadCloser, t time.Time) io.ReadCloser {
+	pipeReader, pipeWriter := io.Pipe()
+	done := make(chan error, 1)
+
+	go func() {
+		tarReader := tar.NewReader(inputTarStream)
+		tarWriter := tar.NewWriter(pipeWriter)
+
+		err := func() error {
+			for {
+				hdr, err := tarReader.Next()
+				if err == io.EOF {
+					return tarWriter.Close()
+				}
+				if err != nil {
+					return err
+				}
+				clampTime(&hdr.ModTime, t)
+				clampTime(&hdr.AccessTime, t)
+				clampTime(&hdr.ChangeTime, t)
+				if err := tarWriter.WriteHeader(hdr); err != nil {
+					return err
+				}
+				if _, err := pools.Copy(tarWriter, tarReader); err != nil {
+					return err
+				}
+			}
+		}()
+		pipeWriter.CloseWithError(err)
+		done <- inputTarStream.Close()
+	}()
+
+	return ioutils.NewReadCloserWrapper(pipeReader, func() error {
+		pipeReader.Close()
+		return <-done
+	})
+}
+
+func clampTime(ts *time.Time, t time.Time) {
+	if ts.After(t) {
+		*ts = t
+	}
+}
+
 // Extension returns the extension of a file that uses the specified compression algorithm.
 func (compression *Compression) Extension() string {
 	switch *compression {
@@ -1288,6 +1288,53 @@ func TestReplaceFileTarWrapper(t *testing.T) {
 	}
 }
 
+func TestClampTimestamps(t *testing.T) {
+	epoch := time.Unix(1600000000, 0)
+	older := epoch.Add(-time.Hour)
+	newer := epoch.Add(time.Hour)
+
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for name, mtime := range map[string]time.Time{"older": older, "newer": newer} {
+		content := []byte(name)
+		assert.NilError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(content)),
+			ModTime:  mtime,
+			Typeflag: tar.TypeReg,
+			Format:   tar.FormatPAX,
+		}))
+		_, err := tw.Write(content)
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, tw.Close())
+
+	var closed bool
+	rc := ClampTimestamps(ioutils.NewReadCloserWrapper(buf, func() error {
+		closed = true
+		return nil
+	}), epoch)
+
+	tr := tar.NewReader(rc)
+	expected := map[string]time.Time{"older": older, "newer": epoch}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NilError(t, err)
+		assert.Check(t, hdr.ModTime.Equal(expected[hdr.Name]), "%s: %s", hdr.Name, hdr.ModTime)
+		content, err := io.ReadAll(tr)
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(string(content), hdr.Name))
+		delete(expected, hdr.Name)
+	}
+	assert.Check(t, is.Len(expected, 0))
+	assert.NilError(t, rc.Close())
+	assert.Check(t, closed)
+}
+
 // TestPrefixHeaderReadable tests that files that could be created with the
 // version of this package that was built with <=go17 are still readable.
 func TestPrefixHeaderReadable(t *testing.T) {
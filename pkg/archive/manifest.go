@@ -0,0 +1,264 @@
+package archive // import "github.com/docker/docker/pkg/archive"
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// ManifestEntry describes a file in the manifest of a directory.
+type ManifestEntry struct {
+	// Path is the path of the file relative to the directory, with forward
+	// slashes as separators.
+	Path  string      `json:"path"`
+	Size  int64       `json:"size"`
+	Mode  os.FileMode `json:"mode"`
+	Mtime time.Time   `json:"mtime"`
+	// LinkTarget is the target of symbolic links.
+	LinkTarget string `json:"linkTarget,omitempty"`
+	// Digest is the digest of the content of regular files.
+	Digest digest.Digest `json:"digest,omitempty"`
+}
+
+// Manifest returns the manifest of the files in the directory dir, sorted by
+// path. Files that are removed while the directory is walked are skipped.
+func Manifest(dir string) ([]ManifestEntry, error) {
+	var entries []ManifestEntry
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p != dir {
+				return nil
+			}
+			return err
+		}
+		if p == dir {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+
+		e := ManifestEntry{
+			Path:  filepath.ToSlash(rel),
+			Size:  fi.Size(),
+			Mode:  fi.Mode(),
+			Mtime: fi.ModTime(),
+		}
+		switch {
+		case fi.IsDir():
+			// The size of directories is not a measure of change.
+			e.Size = 0
+		case fi.Mode()&os.ModeSymlink != 0:
+			e.LinkTarget, err = os.Readlink(p)
+		case fi.Mode().IsRegular():
+			e.Digest, err = fileDigest(p)
+		}
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ChangesManifest compares the directory dir with the directory described by
+// manifest, and returns the changes that make the latter equal to dir, which
+// can be exported with ExportChanges. Regular files of the same size but with
+// a different modification time are compared by their digest, so that files
+// that were only touched are not changed.
+func ChangesManifest(dir string, manifest []ManifestEntry) ([]Change, error) {
+	oldEntries := make(map[string]ManifestEntry, len(manifest))
+	for _, e := range manifest {
+		oldEntries[e.Path] = e
+	}
+
+	var changes []Change
+	// isDir records whether the files in dir are directories.
+	isDir := make(map[string]bool)
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == dir {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		isDir[name] = fi.IsDir()
+
+		// As with ChangesDirs, the paths of changes are OS specific.
+		change := Change{Path: filepath.Join(string(os.PathSeparator), rel)}
+		old, ok := oldEntries[name]
+		if !ok {
+			change.Kind = ChangeAdd
+			changes = append(changes, change)
+			return nil
+		}
+		changed, err := manifestEntryChanged(p, fi, old)
+		if err != nil {
+			return err
+		}
+		if changed {
+			change.Kind = ChangeModify
+			changes = append(changes, change)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range manifest {
+		if _, ok := isDir[e.Path]; ok {
+			continue
+		}
+		// Only the topmost deleted file is reported, as deleting it also
+		// deletes its children. Files of a directory that is replaced by a
+		// file are deleted along with the directory.
+		if parent := path.Dir(e.Path); parent == "." || isDir[parent] {
+			changes = append(changes, Change{
+				Path: filepath.Join(string(os.PathSeparator), filepath.FromSlash(e.Path)),
+				Kind: ChangeDelete,
+			})
+		}
+	}
+	return changes, nil
+}
+
+// ManifestChanges compares the directories described by the manifests, and
+// returns the changes that make the directory of oldManifest equal to the
+// directory of newManifest, as ChangesManifest does. Unlike ChangesManifest it
+// doesn't access any file, so regular files are compared by the digests of the
+// manifests.
+func ManifestChanges(oldManifest, newManifest []ManifestEntry) []Change {
+	oldEntries := make(map[string]ManifestEntry, len(oldManifest))
+	for _, e := range oldManifest {
+		oldEntries[e.Path] = e
+	}
+
+	var changes []Change
+	isDir := make(map[string]bool, len(newManifest))
+	for _, e := range newManifest {
+		isDir[e.Path] = e.Mode.IsDir()
+
+		change := Change{Path: filepath.Join(string(os.PathSeparator), filepath.FromSlash(e.Path))}
+		old, ok := oldEntries[e.Path]
+		switch {
+		case !ok:
+			change.Kind = ChangeAdd
+		case manifestEntriesDiffer(old, e):
+			change.Kind = ChangeModify
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+
+	for _, e := range oldManifest {
+		if _, ok := isDir[e.Path]; ok {
+			continue
+		}
+		if parent := path.Dir(e.Path); parent == "." || isDir[parent] {
+			changes = append(changes, Change{
+				Path: filepath.Join(string(os.PathSeparator), filepath.FromSlash(e.Path)),
+				Kind: ChangeDelete,
+			})
+		}
+	}
+	return changes
+}
+
+// manifestEntriesDiffer returns whether the files described by the manifest
+// entries old and e are different, following the rules of
+// manifestEntryChanged.
+func manifestEntriesDiffer(old, e ManifestEntry) bool {
+	if e.Mode.Type() != old.Mode.Type() {
+		return true
+	}
+	if runtime.GOOS != "windows" && e.Mode != old.Mode {
+		return true
+	}
+
+	switch {
+	case e.Mode.IsDir():
+		return false
+	case e.Mode&os.ModeSymlink != 0:
+		return e.LinkTarget != old.LinkTarget
+	case e.Mode.IsRegular():
+		if e.Size != old.Size {
+			return true
+		}
+		if sameFsTime(e.Mtime, old.Mtime) {
+			return false
+		}
+		return e.Digest == "" || e.Digest != old.Digest
+	}
+	return !sameFsTime(e.Mtime, old.Mtime)
+}
+
+// manifestEntryChanged returns whether the file at path with the info fi is
+// different from the file described by the manifest entry e.
+func manifestEntryChanged(path string, fi os.FileInfo, e ManifestEntry) (bool, error) {
+	mode := fi.Mode()
+	if mode.Type() != e.Mode.Type() {
+		return true, nil
+	}
+	// Permissions cannot be compared on Windows, which has no equivalent of
+	// the permissions of the files of Linux containers.
+	if runtime.GOOS != "windows" && mode != e.Mode {
+		return true, nil
+	}
+
+	switch {
+	case mode.IsDir():
+		// Don't look at the modification time of directories, as in
+		// ChangesDirs.
+		return false, nil
+	case mode&os.ModeSymlink != 0:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return false, err
+		}
+		return target != e.LinkTarget, nil
+	case mode.IsRegular():
+		if fi.Size() != e.Size {
+			return true, nil
+		}
+		if sameFsTime(fi.ModTime(), e.Mtime) {
+			return false, nil
+		}
+		if e.Digest == "" {
+			return true, nil
+		}
+		dgst, err := fileDigest(path)
+		if err != nil {
+			return false, err
+		}
+		return dgst != e.Digest, nil
+	}
+	return !sameFsTime(fi.ModTime(), e.Mtime), nil
+}
+
+func fileDigest(path string) (digest.Digest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return digest.FromReader(f)
+}
@@ -0,0 +1,103 @@
+package archive // import "github.com/docker/docker/pkg/archive"
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/idtools"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestManifest(t *testing.T) {
+	dir := t.TempDir()
+	assert.NilError(t, os.MkdirAll(filepath.Join(dir, "dir"), 0755))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "dir", "file"), []byte("hello"), 0640))
+
+	manifest, err := Manifest(dir)
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(manifest, 2))
+
+	assert.Check(t, is.Equal(manifest[0].Path, "dir"))
+	assert.Check(t, manifest[0].Mode.IsDir())
+	assert.Check(t, is.Equal(manifest[0].Size, int64(0)))
+	assert.Check(t, is.Equal(manifest[0].Digest.String(), ""))
+
+	assert.Check(t, is.Equal(manifest[1].Path, "dir/file"))
+	assert.Check(t, manifest[1].Mode.IsRegular())
+	assert.Check(t, is.Equal(manifest[1].Size, int64(5)))
+	assert.Check(t, is.Equal(manifest[1].Digest.String(), "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"))
+}
+
+func TestChangesManifest(t *testing.T) {
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "src")
+	dst := filepath.Join(tmp, "dst")
+
+	for name, content := range map[string]string{
+		"a":       "a",
+		"touched": "same",
+		"dir/b":   "b",
+		"dir/c":   "c",
+		"gone/x":  "x",
+	} {
+		p := filepath.Join(src, filepath.FromSlash(name))
+		assert.NilError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		assert.NilError(t, os.WriteFile(p, []byte(content), 0644))
+	}
+	assert.NilError(t, copyDir(src, dst))
+
+	manifest, err := Manifest(dst)
+	assert.NilError(t, err)
+
+	assert.NilError(t, os.WriteFile(filepath.Join(src, "a"), []byte("aa"), 0644))
+	assert.NilError(t, os.Remove(filepath.Join(src, "dir", "c")))
+	assert.NilError(t, os.RemoveAll(filepath.Join(src, "gone")))
+	assert.NilError(t, os.MkdirAll(filepath.Join(src, "new"), 0755))
+	assert.NilError(t, os.WriteFile(filepath.Join(src, "new", "d"), []byte("d"), 0644))
+	later := time.Now().Add(time.Hour)
+	assert.NilError(t, os.Chtimes(filepath.Join(src, "touched"), later, later))
+
+	changes, err := ChangesManifest(src, manifest)
+	assert.NilError(t, err)
+	sort.Sort(changesByPath(changes))
+	expected := []Change{
+		{Path: "/a", Kind: ChangeModify},
+		{Path: "/dir/c", Kind: ChangeDelete},
+		{Path: "/gone", Kind: ChangeDelete},
+		{Path: "/new", Kind: ChangeAdd},
+		{Path: "/new/d", Kind: ChangeAdd},
+	}
+	for i := range expected {
+		expected[i].Path = filepath.FromSlash(expected[i].Path)
+	}
+	assert.Check(t, is.DeepEqual(changes, expected))
+
+	// Comparing the manifests of both directories finds the same changes.
+	srcManifest, err := Manifest(src)
+	assert.NilError(t, err)
+	manifestChanges := ManifestChanges(manifest, srcManifest)
+	sort.Sort(changesByPath(manifestChanges))
+	assert.Check(t, is.DeepEqual(manifestChanges, expected))
+
+	// Applying the changes to the directory of the manifest makes it equal to
+	// the source, except for the files that were only touched.
+	layer, err := ExportChanges(src, changes, idtools.IdentityMapping{})
+	assert.NilError(t, err)
+	defer layer.Close()
+	_, err = UnpackLayer(dst, layer, &TarOptions{NoLchown: true})
+	assert.NilError(t, err)
+
+	manifest, err = Manifest(dst)
+	assert.NilError(t, err)
+	changes, err = ChangesManifest(src, manifest)
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(changes, 0))
+
+	content, err := os.ReadFile(filepath.Join(dst, "new", "d"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(content), "d"))
+}
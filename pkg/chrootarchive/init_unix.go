@@ -15,6 +15,8 @@ func init() {
 	reexec.Register("docker-applyLayer", applyLayer)
 	reexec.Register("docker-untar", untar)
 	reexec.Register("docker-tar", tar)
+	reexec.Register("docker-manifest", manifest)
+	reexec.Register("docker-exportChanges", exportChanges)
 }
 
 func fatal(err error) {
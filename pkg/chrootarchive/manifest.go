@@ -0,0 +1,22 @@
+package chrootarchive // import "github.com/docker/docker/pkg/chrootarchive"
+
+import (
+	"io"
+
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/idtools"
+)
+
+// Manifest returns the manifest of the files in the directory dir while
+// chrooted to the specified root, as archive.Manifest does. dir must be a
+// path within root.
+func Manifest(dir string, root string) ([]archive.ManifestEntry, error) {
+	return invokeManifest(dir, root)
+}
+
+// ExportChanges produces an archive of the changes of the directory dir while
+// chrooted to the specified root, as archive.ExportChanges does. dir must be
+// a path within root.
+func ExportChanges(dir string, changes []archive.Change, idMapping idtools.IdentityMapping, root string) (io.ReadCloser, error) {
+	return invokeExportChanges(dir, changes, idMapping, root)
+}
@@ -0,0 +1,146 @@
+//go:build !windows
+// +build !windows
+
+package chrootarchive // import "github.com/docker/docker/pkg/chrootarchive"
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/pkg/errors"
+)
+
+type exportChangesOptions struct {
+	Changes   []archive.Change
+	IDMapping idtools.IdentityMapping
+}
+
+// manifest is the entry-point for docker-manifest on re-exec.
+func manifest() {
+	runtime.LockOSThread()
+	flag.Parse()
+
+	if err := realChroot(flag.Arg(1)); err != nil {
+		fatal(err)
+	}
+
+	entries, err := archive.Manifest(flag.Arg(0))
+	if err != nil {
+		fatal(err)
+	}
+	if err := json.NewEncoder(os.Stdout).Encode(entries); err != nil {
+		fatal(err)
+	}
+
+	os.Exit(0)
+}
+
+// exportChanges is the entry-point for docker-exportChanges on re-exec.
+func exportChanges() {
+	runtime.LockOSThread()
+	flag.Parse()
+
+	if err := realChroot(flag.Arg(1)); err != nil {
+		fatal(err)
+	}
+
+	var options exportChangesOptions
+	if err := json.NewDecoder(os.Stdin).Decode(&options); err != nil {
+		fatal(err)
+	}
+
+	rdr, err := archive.ExportChanges(flag.Arg(0), options.Changes, options.IDMapping)
+	if err != nil {
+		fatal(err)
+	}
+	defer rdr.Close()
+
+	if _, err := io.Copy(os.Stdout, rdr); err != nil {
+		fatal(err)
+	}
+
+	os.Exit(0)
+}
+
+// chrootPath returns the path p within root as an absolute path in the
+// chroot.
+func chrootPath(p string, root string) (string, error) {
+	if root == "" {
+		return "", errors.New("root path must not be empty")
+	}
+	rel, err := filepath.Rel(root, p)
+	if err != nil {
+		return "", err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.Errorf("%s is not within %s", p, root)
+	}
+	return filepath.Join("/", rel), nil
+}
+
+func invokeManifest(dir string, root string) ([]archive.ManifestEntry, error) {
+	relDir, err := chrootPath(dir, root)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := reexec.Command("docker-manifest", relDir, root)
+	outBuf, errBuf := new(bytes.Buffer), new(bytes.Buffer)
+	cmd.Stdout, cmd.Stderr = outBuf, errBuf
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "error making manifest: %s", errBuf)
+	}
+
+	var entries []archive.ManifestEntry
+	if err := json.NewDecoder(outBuf).Decode(&entries); err != nil {
+		return nil, errors.Wrap(err, "unable to decode manifest")
+	}
+	return entries, nil
+}
+
+func invokeExportChanges(dir string, changes []archive.Change, idMapping idtools.IdentityMapping, root string) (io.ReadCloser, error) {
+	relDir, err := chrootPath(dir, root)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := reexec.Command("docker-exportChanges", relDir, root)
+
+	errBuff := bytes.NewBuffer(nil)
+	cmd.Stderr = errBuff
+
+	tarR, tarW := io.Pipe()
+	cmd.Stdout = tarW
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting options pipe for export process")
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Wrap(err, "export error on re-exec cmd")
+	}
+
+	go func() {
+		err := cmd.Wait()
+		err = errors.Wrapf(err, "error exporting changes: %s", errBuff)
+		tarW.CloseWithError(err)
+	}()
+
+	if err := json.NewEncoder(stdin).Encode(exportChangesOptions{Changes: changes, IDMapping: idMapping}); err != nil {
+		stdin.Close()
+		return nil, errors.Wrap(err, "export json encode to pipe failed")
+	}
+	stdin.Close()
+
+	return tarR, nil
+}
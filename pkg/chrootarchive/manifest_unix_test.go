@@ -0,0 +1,51 @@
+//go:build !windows
+// +build !windows
+
+package chrootarchive
+
+import (
+	gotar "archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/idtools"
+	"golang.org/x/sys/unix"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/skip"
+)
+
+func TestManifestAndExportChangesWithMaliciousSymlinks(t *testing.T) {
+	skip.If(t, os.Getuid() != 0, "skipping test that requires root")
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	assert.NilError(t, os.MkdirAll(filepath.Join(root, "data"), 0755))
+	assert.NilError(t, os.WriteFile(filepath.Join(root, "data", "file"), []byte("data"), 0644))
+
+	hostFileData := []byte("I am a host file")
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "host-file"), hostFileData, 0644))
+	assert.NilError(t, unix.Symlink(dir, filepath.Join(root, "data", "safe")))
+
+	entries, err := Manifest(filepath.Join(root, "data"), root)
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(entries, 2))
+	assert.Check(t, is.Equal(entries[0].Path, "file"))
+	assert.Check(t, is.Equal(entries[1].Path, "safe"))
+	assert.Check(t, is.Equal(entries[1].LinkTarget, dir))
+
+	changes := []archive.Change{
+		{Path: "/file", Kind: archive.ChangeAdd},
+		{Path: "/safe/host-file", Kind: archive.ChangeAdd},
+	}
+	rdr, err := ExportChanges(filepath.Join(root, "data"), changes, idtools.IdentityMapping{}, root)
+	assert.NilError(t, err)
+	defer rdr.Close()
+
+	tr := gotar.NewReader(rdr)
+	assert.Assert(t, !isDataInTar(t, tr, hostFileData, int64(len(hostFileData))), "host data leaked to archive")
+
+	_, err = Manifest(dir, root)
+	assert.Check(t, is.ErrorContains(err, "is not within"))
+}
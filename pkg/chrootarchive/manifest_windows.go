@@ -0,0 +1,20 @@
+package chrootarchive // import "github.com/docker/docker/pkg/chrootarchive"
+
+import (
+	"io"
+
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/idtools"
+)
+
+func invokeManifest(dir string, root string) ([]archive.ManifestEntry, error) {
+	// Windows does not support chroot, so the manifest is made inline within
+	// the daemon process.
+	return archive.Manifest(dir)
+}
+
+func invokeExportChanges(dir string, changes []archive.Change, idMapping idtools.IdentityMapping, root string) (io.ReadCloser, error) {
+	// Windows does not support chroot, so the changes are exported inline
+	// within the daemon process.
+	return archive.ExportChanges(dir, changes, idMapping)
+}
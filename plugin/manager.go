@@ -40,9 +40,11 @@ type Executor interface {
 	Signal(id string, signal int) error
 }
 
-// EndpointResolver provides looking up registry endpoints for pulling.
+// EndpointResolver provides looking up registry endpoints for pulling, and
+// the credentials of registries that are configured in the daemon.
 type EndpointResolver interface {
 	LookupPullEndpoints(hostname string) (endpoints []registry.APIEndpoint, err error)
+	LookupCredentials(ctx context.Context, hostname string) (*types.AuthConfig, error)
 }
 
 func (pm *Manager) restorePlugin(p *v2.Plugin, c *controller) error {
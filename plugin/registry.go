@@ -36,7 +36,7 @@ func (pm *Manager) newResolver(ctx context.Context, tracker docker.StatusTracker
 	return docker.NewResolver(docker.ResolverOptions{
 		Tracker: tracker,
 		Headers: headers,
-		Hosts:   pm.registryHostsFn(auth, httpFallback),
+		Hosts:   pm.registryHostsFn(ctx, auth, httpFallback),
 	}), nil
 }
 
@@ -55,13 +55,25 @@ func registryHTTPClient(config *tls.Config) *http.Client {
 	}
 }
 
-func (pm *Manager) registryHostsFn(auth *types.AuthConfig, httpFallback bool) docker.RegistryHosts {
+func (pm *Manager) registryHostsFn(ctx context.Context, auth *types.AuthConfig, httpFallback bool) docker.RegistryHosts {
 	return func(hostname string) ([]docker.RegistryHost, error) {
 		eps, err := pm.config.RegistryService.LookupPullEndpoints(hostname)
 		if err != nil {
 			return nil, errors.Wrapf(err, "error resolving repository for %s", hostname)
 		}
 
+		// Use the credentials that are configured in the daemon if the
+		// client did not send any.
+		creds := auth
+		if creds.Username == "" && creds.Password == "" && creds.IdentityToken == "" {
+			daemonCreds, err := pm.config.RegistryService.LookupCredentials(ctx, hostname)
+			if err != nil {
+				logrus.WithError(err).WithField("registryHost", hostname).Warn("Failed to look up registry credentials")
+			} else if daemonCreds != nil {
+				creds = daemonCreds
+			}
+		}
+
 		hosts := make([]docker.RegistryHost, 0, len(eps))
 
 		for _, ep := range eps {
@@ -93,10 +105,10 @@ func (pm *Manager) registryHostsFn(auth *types.AuthConfig, httpFallback bool) do
 				Authorizer: docker.NewDockerAuthorizer(
 					docker.WithAuthClient(client),
 					docker.WithAuthCreds(func(_ string) (string, string, error) {
-						if auth.IdentityToken != "" {
-							return "", auth.IdentityToken, nil
+						if creds.IdentityToken != "" {
+							return "", creds.IdentityToken, nil
 						}
-						return auth.Username, auth.Password, nil
+						return creds.Username, creds.Password, nil
 					}),
 				),
 			})
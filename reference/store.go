@@ -8,9 +8,11 @@ import (
 	"sort"
 	"sync"
 
+	"github.com/containerd/containerd/platforms"
 	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 )
 
@@ -26,13 +28,23 @@ type Association struct {
 	ID  digest.Digest
 }
 
+// A PlatformAssociation associates a platform variant of a reference with
+// an image ID.
+type PlatformAssociation struct {
+	Platform specs.Platform
+	ID       digest.Digest
+}
+
 // Store provides the set of methods which can operate on a reference store.
 type Store interface {
 	References(id digest.Digest) []reference.Named
 	ReferencesByName(ref reference.Named) []Association
 	AddTag(ref reference.Named, id digest.Digest, force bool) error
 	AddDigest(ref reference.Canonical, id digest.Digest, force bool) error
+	AddPlatform(ref reference.Named, platform specs.Platform, id digest.Digest) error
+	Platforms(ref reference.Named) []PlatformAssociation
 	Delete(ref reference.Named) (bool, error)
+	DeletePlatform(ref reference.Named, id digest.Digest) (bool, error)
 	Get(ref reference.Named) (digest.Digest, error)
 }
 
@@ -43,6 +55,10 @@ type store struct {
 	jsonPath string
 	// Repositories is a map of repositories, indexed by name.
 	Repositories map[string]repository
+	// PlatformVariants maps tags that refer to an image for multiple
+	// platforms to the image IDs of its platform variants. The key is a
+	// stringified Reference, as in Repositories.
+	PlatformVariants map[string]platformVariants `json:",omitempty"`
 	// referencesByIDCache is a cache of references indexed by ID, to speed
 	// up References.
 	referencesByIDCache map[digest.Digest]map[string]reference.Named
@@ -52,6 +68,19 @@ type store struct {
 // including the repository name.
 type repository map[string]digest.Digest
 
+// platformVariants maps platforms to the image IDs of the platform variants
+// of a tag. The key is a normalized platform specifier.
+type platformVariants map[string]digest.Digest
+
+func (v platformVariants) contains(id digest.Digest) bool {
+	for _, variantID := range v {
+		if variantID == id {
+			return true
+		}
+	}
+	return false
+}
+
 type lexicalRefs []reference.Named
 
 func (a lexicalRefs) Len() int      { return len(a) }
@@ -79,6 +108,7 @@ func NewReferenceStore(jsonPath string) (Store, error) {
 	store := &store{
 		jsonPath:            abspath,
 		Repositories:        make(map[string]repository),
+		PlatformVariants:    make(map[string]platformVariants),
 		referencesByIDCache: make(map[digest.Digest]map[string]reference.Named),
 	}
 	// Load the json file if it exists, otherwise create it.
@@ -167,21 +197,117 @@ func (store *store) addReference(ref reference.Named, id digest.Digest, force bo
 			)
 		}
 
-		if store.referencesByIDCache[oldID] != nil {
-			delete(store.referencesByIDCache[oldID], refStr)
-			if len(store.referencesByIDCache[oldID]) == 0 {
-				delete(store.referencesByIDCache, oldID)
+		// The platform variants of the tag are kept if it is set to one of
+		// them, and are otherwise replaced by the image.
+		variants := store.PlatformVariants[refStr]
+		if !variants.contains(id) {
+			for _, variantID := range variants {
+				store.uncacheReference(variantID, refStr)
 			}
+			delete(store.PlatformVariants, refStr)
+			variants = nil
+		}
+		if !variants.contains(oldID) {
+			store.uncacheReference(oldID, refStr)
 		}
 	}
 
 	repository[refStr] = id
+	store.cacheReference(id, refStr, ref)
+
+	return store.save()
+}
+
+// AddPlatform sets the tag ref to the image ID of its variant for platform.
+// It replaces the previous variant of the tag for that platform, if any, and
+// keeps the variants of the tag for other platforms. Only images that are
+// added as variants are kept; if the tag was set to another image by AddTag,
+// the caller should add that image as a variant first.
+func (store *store) AddPlatform(ref reference.Named, platform specs.Platform, id digest.Digest) error {
+	if _, isCanonical := ref.(reference.Canonical); isCanonical {
+		return errors.WithStack(invalidTagError("refusing to add a platform variant to a digest reference"))
+	}
+	ref = reference.TagNameOnly(ref)
+
+	refName := reference.FamiliarName(ref)
+	refStr := reference.FamiliarString(ref)
+
+	if refName == string(digest.Canonical) {
+		return errors.WithStack(invalidTagError("refusing to create an ambiguous tag using digest algorithm as name"))
+	}
+
+	key := platforms.Format(platforms.Normalize(platform))
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	repository, exists := store.Repositories[refName]
+	if !exists || repository == nil {
+		repository = make(map[string]digest.Digest)
+		store.Repositories[refName] = repository
+	}
+	variants, exists := store.PlatformVariants[refStr]
+	if !exists || variants == nil {
+		variants = make(platformVariants)
+		store.PlatformVariants[refStr] = variants
+	}
+
+	oldID, exists := repository[refStr]
+	replacedID, replacing := variants[key]
+	if exists && oldID == id && replacing && replacedID == id {
+		return nil
+	}
+
+	variants[key] = id
+	repository[refStr] = id
+	if replacing && replacedID != id && !variants.contains(replacedID) {
+		store.uncacheReference(replacedID, refStr)
+	}
+	if exists && !variants.contains(oldID) {
+		store.uncacheReference(oldID, refStr)
+	}
+	store.cacheReference(id, refStr, ref)
+
+	return store.save()
+}
+
+// Platforms returns the platform variants of the tag ref, sorted by platform.
+// It returns nil if ref is not a tag with platform variants.
+func (store *store) Platforms(ref reference.Named) []PlatformAssociation {
+	refStr := reference.FamiliarString(reference.TagNameOnly(ref))
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var associations []PlatformAssociation
+	for key, id := range store.PlatformVariants[refStr] {
+		platform, err := platforms.Parse(key)
+		if err != nil {
+			// Should never happen
+			continue
+		}
+		associations = append(associations, PlatformAssociation{Platform: platform, ID: id})
+	}
+	sort.Slice(associations, func(i, j int) bool {
+		return platforms.Format(associations[i].Platform) < platforms.Format(associations[j].Platform)
+	})
+	return associations
+}
+
+func (store *store) cacheReference(id digest.Digest, refStr string, ref reference.Named) {
 	if store.referencesByIDCache[id] == nil {
 		store.referencesByIDCache[id] = make(map[string]reference.Named)
 	}
 	store.referencesByIDCache[id][refStr] = ref
+}
 
-	return store.save()
+func (store *store) uncacheReference(id digest.Digest, refStr string) {
+	if store.referencesByIDCache[id] != nil {
+		delete(store.referencesByIDCache[id], refStr)
+		if len(store.referencesByIDCache[id]) == 0 {
+			delete(store.referencesByIDCache, id)
+		}
+	}
 }
 
 // Delete deletes a reference from the store. It returns true if a deletion
@@ -210,18 +336,81 @@ func (store *store) Delete(ref reference.Named) (bool, error) {
 		if len(repository) == 0 {
 			delete(store.Repositories, refName)
 		}
-		if store.referencesByIDCache[id] != nil {
-			delete(store.referencesByIDCache[id], refStr)
-			if len(store.referencesByIDCache[id]) == 0 {
-				delete(store.referencesByIDCache, id)
-			}
+		store.uncacheReference(id, refStr)
+		for _, variantID := range store.PlatformVariants[refStr] {
+			store.uncacheReference(variantID, refStr)
 		}
+		delete(store.PlatformVariants, refStr)
 		return true, store.save()
 	}
 
 	return false, ErrDoesNotExist
 }
 
+// DeletePlatform deletes the platform variant with the image ID id of the tag
+// ref from the store, and keeps the variants of the tag for other platforms.
+// If the tag was set to id, it is set to one of the remaining variants, and
+// it is deleted if no variants remain. It returns true if a deletion
+// happened, or false if id is not an image of the tag.
+func (store *store) DeletePlatform(ref reference.Named, id digest.Digest) (bool, error) {
+	if _, isCanonical := ref.(reference.Canonical); isCanonical {
+		return false, errors.WithStack(invalidTagError("refusing to delete a platform variant of a digest reference"))
+	}
+	ref = reference.TagNameOnly(ref)
+
+	refName := reference.FamiliarName(ref)
+	refStr := reference.FamiliarString(ref)
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	repository, exists := store.Repositories[refName]
+	if !exists {
+		return false, ErrDoesNotExist
+	}
+	defaultID, exists := repository[refStr]
+	if !exists {
+		return false, ErrDoesNotExist
+	}
+
+	variants := store.PlatformVariants[refStr]
+	removed := false
+	for key, variantID := range variants {
+		if variantID == id {
+			delete(variants, key)
+			removed = true
+		}
+	}
+	if !removed && defaultID != id {
+		return false, nil
+	}
+
+	if len(variants) == 0 {
+		delete(repository, refStr)
+		if len(repository) == 0 {
+			delete(store.Repositories, refName)
+		}
+		delete(store.PlatformVariants, refStr)
+		store.uncacheReference(id, refStr)
+		store.uncacheReference(defaultID, refStr)
+		return true, store.save()
+	}
+	if defaultID == id {
+		keys := make([]string, 0, len(variants))
+		for key := range variants {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		repository[refStr] = variants[keys[0]]
+	}
+	if len(variants) == 1 {
+		// The tag is only set to the image of the remaining variant.
+		delete(store.PlatformVariants, refStr)
+	}
+	store.uncacheReference(id, refStr)
+	return true, store.save()
+}
+
 // Get retrieves an item from the store by reference
 func (store *store) Get(ref reference.Named) (digest.Digest, error) {
 	if canonical, ok := ref.(reference.Canonical); ok {
@@ -337,10 +526,18 @@ func (store *store) reload() error {
 				// Should never happen
 				continue
 			}
-			if store.referencesByIDCache[refID] == nil {
-				store.referencesByIDCache[refID] = make(map[string]reference.Named)
-			}
-			store.referencesByIDCache[refID][refStr] = ref
+			store.cacheReference(refID, refStr, ref)
+		}
+	}
+
+	for refStr, variants := range store.PlatformVariants {
+		ref, err := reference.ParseNormalizedNamed(refStr)
+		if err != nil {
+			// Should never happen
+			continue
+		}
+		for _, id := range variants {
+			store.cacheReference(id, refStr, ref)
 		}
 	}
 
@@ -9,6 +9,7 @@ import (
 
 	"github.com/docker/distribution/reference"
 	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
 )
@@ -356,3 +357,102 @@ func TestInvalidTags(t *testing.T) {
 	err = store.AddTag(ref, id, true)
 	assert.Check(t, is.ErrorContains(err, ""))
 }
+
+func TestPlatforms(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := NewReferenceStore(filepath.Join(tmpDir, "repositories.json"))
+	assert.NilError(t, err)
+
+	const (
+		amd64ID   = digest.Digest("sha256:470022b8af682154f57a2163d030eb369549549cba00edc69e1b99b46bb924d6")
+		arm64ID   = digest.Digest("sha256:ae300ebc4a4f00693702cfb0a5e0b7bc527b353828dc86ad09fb95c8a681b793")
+		newArm64  = digest.Digest("sha256:6153498b9ac00968d71b66cca4eac37e990b5f9eb50c26877eb8799c8847451b")
+		unrelated = digest.Digest("sha256:6c9917af4c4e05001b346421959d7ea81b6dc9d25718466a37a6add865dfd7fc")
+	)
+	amd64 := specs.Platform{OS: "linux", Architecture: "amd64"}
+	arm64 := specs.Platform{OS: "linux", Architecture: "arm64"}
+
+	ref, err := reference.ParseNormalizedNamed("username/repo")
+	assert.NilError(t, err)
+	tag, err := reference.WithTag(ref, "latest")
+	assert.NilError(t, err)
+
+	assert.NilError(t, store.AddPlatform(ref, amd64, amd64ID))
+	assert.NilError(t, store.AddPlatform(ref, arm64, arm64ID))
+
+	id, err := store.Get(ref)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(id, arm64ID))
+	assert.Check(t, is.DeepEqual(store.Platforms(tag), []PlatformAssociation{
+		{Platform: specs.Platform{OS: "linux", Architecture: "amd64"}, ID: amd64ID},
+		{Platform: specs.Platform{OS: "linux", Architecture: "arm64"}, ID: arm64ID},
+	}))
+	assert.Check(t, is.DeepEqual(refStrings(store.References(amd64ID)), []string{tag.String()}))
+	assert.Check(t, is.DeepEqual(refStrings(store.References(arm64ID)), []string{tag.String()}))
+
+	// Replacing the variant for a platform drops the previous image.
+	assert.NilError(t, store.AddPlatform(ref, arm64, newArm64))
+	assert.Check(t, is.Len(store.References(arm64ID), 0))
+	assert.Check(t, is.Len(store.Platforms(ref), 2))
+
+	// Variants survive a reload.
+	store, err = NewReferenceStore(filepath.Join(tmpDir, "repositories.json"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(store.Platforms(ref), 2))
+	assert.Check(t, is.DeepEqual(refStrings(store.References(amd64ID)), []string{tag.String()}))
+
+	// Setting the tag to one of its variants keeps the variants.
+	assert.NilError(t, store.AddTag(ref, amd64ID, true))
+	assert.Check(t, is.Len(store.Platforms(ref), 2))
+	assert.Check(t, is.DeepEqual(refStrings(store.References(newArm64)), []string{tag.String()}))
+
+	// Setting the tag to another image replaces the variants.
+	assert.NilError(t, store.AddTag(ref, unrelated, true))
+	assert.Check(t, is.Len(store.Platforms(ref), 0))
+	assert.Check(t, is.Len(store.References(amd64ID), 0))
+	assert.Check(t, is.Len(store.References(newArm64), 0))
+
+	// Deleting the tag deletes its variants.
+	assert.NilError(t, store.AddPlatform(ref, arm64, arm64ID))
+	deleted, err := store.Delete(ref)
+	assert.NilError(t, err)
+	assert.Check(t, deleted)
+	assert.Check(t, is.Len(store.Platforms(ref), 0))
+	assert.Check(t, is.Len(store.References(arm64ID), 0))
+
+	// Deleting a variant keeps the other variants of the tag.
+	assert.NilError(t, store.AddPlatform(ref, amd64, amd64ID))
+	assert.NilError(t, store.AddPlatform(ref, arm64, arm64ID))
+	deleted, err = store.DeletePlatform(ref, arm64ID)
+	assert.NilError(t, err)
+	assert.Check(t, deleted)
+	id, err = store.Get(ref)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(id, amd64ID))
+	assert.Check(t, is.Len(store.References(arm64ID), 0))
+	assert.Check(t, is.DeepEqual(refStrings(store.References(amd64ID)), []string{tag.String()}))
+
+	deleted, err = store.DeletePlatform(ref, unrelated)
+	assert.NilError(t, err)
+	assert.Check(t, !deleted)
+
+	// Deleting the last variant deletes the tag.
+	deleted, err = store.DeletePlatform(ref, amd64ID)
+	assert.NilError(t, err)
+	assert.Check(t, deleted)
+	_, err = store.Get(ref)
+	assert.Check(t, is.ErrorIs(err, ErrDoesNotExist))
+	assert.Check(t, is.Len(store.References(amd64ID), 0))
+
+	canonical, err := reference.WithDigest(ref, amd64ID)
+	assert.NilError(t, err)
+	assert.Check(t, is.ErrorContains(store.AddPlatform(canonical, amd64, amd64ID), "digest reference"))
+}
+
+func refStrings(refs []reference.Named) []string {
+	var strs []string
+	for _, ref := range refs {
+		strs = append(strs, ref.String())
+	}
+	return strs
+}
@@ -17,6 +17,14 @@ type ServiceOptions struct {
 	AllowNondistributableArtifacts []string `json:"allow-nondistributable-artifacts,omitempty"`
 	Mirrors                        []string `json:"registry-mirrors,omitempty"`
 	InsecureRegistries             []string `json:"insecure-registries,omitempty"`
+
+	// CredentialHelpers are the credential helpers of registries, by
+	// hostname, which provide the credentials of the registries when the
+	// client does not send any.
+	CredentialHelpers map[string]string `json:"credential-helpers,omitempty"`
+	// CredentialsStore is the credential helper of registries that have no
+	// credential helper in CredentialHelpers.
+	CredentialsStore string `json:"credentials-store,omitempty"`
 }
 
 // serviceConfig holds daemon configuration for the registry service.
@@ -0,0 +1,132 @@
+package registry // import "github.com/docker/docker/registry"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os/exec"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// credentialHelperPrefix is the prefix of the names of the binaries of
+	// credential helpers.
+	credentialHelperPrefix = "docker-credential-"
+
+	// credentialHelperTokenUsername is the username with which credential
+	// helpers return identity tokens instead of passwords.
+	credentialHelperTokenUsername = "<token>"
+
+	// credentialsNotFound is the message of credential helpers for servers
+	// that have no credentials.
+	credentialsNotFound = "credentials not found in native keychain"
+)
+
+// for mocking in unit tests
+var execCredentialHelper = func(ctx context.Context, helper, serverURL string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, credentialHelperPrefix+helper, "get")
+	cmd.Stdin = strings.NewReader(serverURL)
+	return cmd.Output()
+}
+
+// credentialHelpers looks up the credentials of registries in the credential
+// helpers that are configured in the daemon, so that the daemon can
+// authenticate operations for which the client did not send credentials.
+type credentialHelpers struct {
+	// helpers are the credential helpers of registries, by hostname.
+	helpers map[string]string
+	// defaultHelper is the credential helper of registries that have no
+	// credential helper of their own.
+	defaultHelper string
+}
+
+// newCredentialHelpers returns the credential helpers of options.
+func newCredentialHelpers(options ServiceOptions) (credentialHelpers, error) {
+	c := credentialHelpers{helpers: make(map[string]string)}
+	if options.CredentialsStore != "" {
+		if err := validateCredentialHelper(options.CredentialsStore); err != nil {
+			return credentialHelpers{}, err
+		}
+		c.defaultHelper = options.CredentialsStore
+	}
+	for hostname, helper := range options.CredentialHelpers {
+		if err := validateCredentialHelper(helper); err != nil {
+			return credentialHelpers{}, err
+		}
+		c.helpers[credentialHelperKey(hostname)] = helper
+	}
+	return c, nil
+}
+
+func validateCredentialHelper(helper string) error {
+	if helper == "" || strings.ContainsAny(helper, `/\`) {
+		return invalidParamf("invalid credential helper %q", helper)
+	}
+	return nil
+}
+
+// credentialHelperKey returns the hostname of a registry, for which the
+// hostnames of Docker Hub are normalized to IndexName.
+func credentialHelperKey(hostname string) string {
+	hostname = ConvertToHostname(hostname)
+	switch hostname {
+	case IndexHostname, DefaultRegistryHost:
+		return IndexName
+	}
+	return hostname
+}
+
+// get returns the credentials of the registry with the given hostname, or nil
+// if it has none.
+func (c credentialHelpers) get(ctx context.Context, hostname string) (*types.AuthConfig, error) {
+	key := credentialHelperKey(hostname)
+	helper, ok := c.helpers[key]
+	if !ok {
+		helper = c.defaultHelper
+	}
+	if helper == "" {
+		return nil, nil
+	}
+
+	// Credentials of Docker Hub are stored with the address of the index,
+	// as the CLI does.
+	serverURL := key
+	if key == IndexName {
+		serverURL = IndexServer
+	}
+
+	out, err := execCredentialHelper(ctx, helper, serverURL)
+	if err != nil {
+		if bytes.Contains(out, []byte(credentialsNotFound)) {
+			return nil, nil
+		}
+		msg := strings.TrimSpace(string(out))
+		if exitErr, ok := err.(*exec.ExitError); ok && msg == "" {
+			msg = strings.TrimSpace(string(exitErr.Stderr))
+		}
+		if msg != "" {
+			err = errors.New(msg)
+		}
+		return nil, errors.Wrapf(err, "credential helper %s failed to get credentials of %s", helper, key)
+	}
+
+	var creds struct {
+		ServerURL string
+		Username  string
+		Secret    string
+	}
+	if err := json.Unmarshal(out, &creds); err != nil {
+		return nil, errors.Wrapf(err, "invalid credentials of %s from credential helper %s", key, helper)
+	}
+	authConfig := &types.AuthConfig{ServerAddress: serverURL}
+	if creds.Username == credentialHelperTokenUsername {
+		authConfig.IdentityToken = creds.Secret
+	} else {
+		authConfig.Username = creds.Username
+		authConfig.Password = creds.Secret
+	}
+	return authConfig, nil
+}
@@ -0,0 +1,72 @@
+package registry // import "github.com/docker/docker/registry"
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestNewCredentialHelpersInvalid(t *testing.T) {
+	_, err := newCredentialHelpers(ServiceOptions{CredentialsStore: "../evil"})
+	assert.Check(t, is.ErrorContains(err, `invalid credential helper "../evil"`))
+	_, err = newCredentialHelpers(ServiceOptions{CredentialHelpers: map[string]string{"registry.example.com": ""}})
+	assert.Check(t, is.ErrorContains(err, `invalid credential helper ""`))
+}
+
+func TestLookupCredentials(t *testing.T) {
+	var calls []string
+	defer func(orig func(context.Context, string, string) ([]byte, error)) { execCredentialHelper = orig }(execCredentialHelper)
+	execCredentialHelper = func(_ context.Context, helper, serverURL string) ([]byte, error) {
+		calls = append(calls, helper+" "+serverURL)
+		switch serverURL {
+		case IndexServer:
+			return []byte(`{"ServerURL":"https://index.docker.io/v1/","Username":"hub-user","Secret":"hub-pass"}`), nil
+		case "registry.example.com":
+			return []byte(`{"ServerURL":"registry.example.com","Username":"<token>","Secret":"refresh-token"}`), nil
+		case "broken.example.com":
+			return []byte("helper crashed\n"), errors.New("exit status 1")
+		}
+		return []byte(credentialsNotFound + "\n"), errors.New("exit status 1")
+	}
+
+	s, err := NewService(ServiceOptions{
+		CredentialsStore: "store",
+		CredentialHelpers: map[string]string{
+			"https://registry.example.com": "example",
+		},
+	})
+	assert.NilError(t, err)
+
+	creds, err := s.LookupCredentials(context.Background(), "docker.io")
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(creds, &types.AuthConfig{Username: "hub-user", Password: "hub-pass", ServerAddress: IndexServer}))
+
+	creds, err = s.LookupCredentials(context.Background(), "registry.example.com")
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(creds, &types.AuthConfig{IdentityToken: "refresh-token", ServerAddress: "registry.example.com"}))
+
+	creds, err = s.LookupCredentials(context.Background(), "other.example.com")
+	assert.NilError(t, err)
+	assert.Check(t, is.Nil(creds))
+
+	_, err = s.LookupCredentials(context.Background(), "broken.example.com")
+	assert.Check(t, is.ErrorContains(err, "credential helper store failed to get credentials of broken.example.com: helper crashed"))
+
+	assert.Check(t, is.DeepEqual(calls, []string{
+		"store " + IndexServer,
+		"example registry.example.com",
+		"store other.example.com",
+		"store broken.example.com",
+	}))
+
+	s, err = NewService(ServiceOptions{})
+	assert.NilError(t, err)
+	creds, err = s.LookupCredentials(context.Background(), "docker.io")
+	assert.NilError(t, err)
+	assert.Check(t, is.Nil(creds))
+	assert.Check(t, is.Len(calls, 4))
+}
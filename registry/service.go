@@ -22,6 +22,7 @@ type Service interface {
 	LookupPullEndpoints(hostname string) (endpoints []APIEndpoint, err error)
 	LookupPushEndpoints(hostname string) (endpoints []APIEndpoint, err error)
 	ResolveRepository(name reference.Named) (*RepositoryInfo, error)
+	LookupCredentials(ctx context.Context, hostname string) (*types.AuthConfig, error)
 	Search(ctx context.Context, term string, limit int, authConfig *types.AuthConfig, userAgent string, headers map[string][]string) (*registry.SearchResults, error)
 	ServiceConfig() *registry.ServiceConfig
 	LoadAllowNondistributableArtifacts([]string) error
@@ -32,16 +33,21 @@ type Service interface {
 // defaultService is a registry service. It tracks configuration data such as a list
 // of mirrors.
 type defaultService struct {
-	config *serviceConfig
-	mu     sync.RWMutex
+	config      *serviceConfig
+	credentials credentialHelpers
+	mu          sync.RWMutex
 }
 
 // NewService returns a new instance of defaultService ready to be
 // installed into an engine.
 func NewService(options ServiceOptions) (Service, error) {
 	config, err := newServiceConfig(options)
+	if err != nil {
+		return &defaultService{config: config}, err
+	}
+	credentials, err := newCredentialHelpers(options)
 
-	return &defaultService{config: config}, err
+	return &defaultService{config: config, credentials: credentials}, err
 }
 
 // ServiceConfig returns a copy of the public registry service's configuration.
@@ -117,6 +123,14 @@ func (s *defaultService) Auth(ctx context.Context, authConfig *types.AuthConfig,
 	return "", "", err
 }
 
+// LookupCredentials returns the credentials of the registry with the given
+// hostname from the credential helpers that are configured in the daemon, or
+// nil if the registry has no credentials. It is used for operations for
+// which the client did not send credentials.
+func (s *defaultService) LookupCredentials(ctx context.Context, hostname string) (*types.AuthConfig, error) {
+	return s.credentials.get(ctx, hostname)
+}
+
 // splitReposSearchTerm breaks a search term into an index name and remote name
 func splitReposSearchTerm(reposName string) (string, string) {
 	nameParts := strings.SplitN(reposName, "/", 2)